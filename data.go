@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"html"
 	"html/template"
@@ -10,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fiatjaf/njump/nip101g"
 	"github.com/nbd-wtf/go-nostr/nip19"
 	"github.com/nbd-wtf/go-nostr/nip31"
 	"github.com/nbd-wtf/go-nostr/nip52"
@@ -45,6 +45,8 @@ type Data struct {
 	Kind33501Metadata        *Kind33501Metadata
 	Kind30501Metadata        *Kind30501Metadata
 	TournamentMetadata       *TournamentMetadata
+	LeagueMetadata           *nip101g.League
+	GolfProblems             nip101g.ValidationErrors
 }
 
 type Kind1501Metadata struct {
@@ -61,6 +63,9 @@ type Kind1501Metadata struct {
 	HolePars      []int // par per hole from courseSnapshot (index 0 = hole 1)
 	TotalPar      int   // sum of hole pars
 	HoleCount     int   // number of holes
+	Handicaps     map[string]float64 // handicap index by player pubkey
+	Format        string             // scoring format from the "format" tag, "" for stroke play
+	Teams         []nip101g.Team     // from "team" tags
 }
 
 type HoleScore struct {
@@ -71,38 +76,14 @@ type HoleScore struct {
 }
 
 type Kind33501Metadata struct {
-	DTag           string
-	Title          string
-	Location       string
-	Country        string
-	Website        string
-	Architect      string
-	Established    string
-	ImageURL       string
-	OperatorPubkey string
-	Holes          []Course33501Hole
-	Tees           []Course33501Tee
-	Yardages       []Course33501Yardage
-	TotalPar       int
+	nip101g.Course
 }
 
-type Course33501Hole struct {
-	Number   int
-	Par      int
-	Handicap int
-}
-
-type Course33501Tee struct {
-	Name   string
-	Rating float64
-	Slope  int
-}
-
-type Course33501Yardage struct {
-	Hole int
-	Tee  string
-	Yards int
-}
+type (
+	Course33501Hole    = nip101g.CourseHole
+	Course33501Tee     = nip101g.CourseTee
+	Course33501Yardage = nip101g.CourseYardage
+)
 
 type Kind30501Metadata struct {
 	DTag       string
@@ -189,39 +170,19 @@ func grabData(ctx context.Context, code string, withRelays bool) (Data, error) {
 	case 31923:
 		data.templateId = Tournament
 		data.content = event.Content
-		tm := &TournamentMetadata{}
-		if titleTag := event.Tags.Find("title"); titleTag != nil {
-			tm.Title = titleTag[1]
-		}
-		if nameTag := event.Tags.Find("name"); nameTag != nil && tm.Title == "" {
-			tm.Title = nameTag[1]
-		}
-		if locTag := event.Tags.Find("location"); locTag != nil {
-			tm.Location = locTag[1]
-		}
-		if startTag := event.Tags.Find("start"); startTag != nil {
-			if ts, err := strconv.ParseInt(startTag[1], 10, 64); err == nil {
-				tm.StartUnix = ts
-			}
-		}
-		if statusTag := event.Tags.Find("status"); statusTag != nil {
-			tm.TournamentStatus = statusTag[1]
-		}
-		if courseTag := event.Tags.Find("course"); courseTag != nil {
-			tm.CourseCoord = courseTag[1]
-		}
-		if teeTag := event.Tags.Find("tee"); teeTag != nil {
-			tm.TeeSet = teeTag[1]
-		}
-		if imgTag := event.Tags.Find("image"); imgTag != nil {
-			tm.Image = imgTag[1]
-		}
-		for _, tag := range event.Tags {
-			if len(tag) >= 2 && tag[0] == "p" {
-				tm.RosterPubkeys = append(tm.RosterPubkeys, tag[1])
-			}
+		tournament, problems := nip101g.ParseTournament(*event)
+		data.TournamentMetadata = newTournamentMetadata(tournament)
+		data.GolfProblems = problems
+	case 31924:
+		if !nip101g.IsLeague(*event) {
+			data.templateId = Other
+			break
 		}
-		data.TournamentMetadata = tm
+		data.templateId = League
+		data.content = event.Content
+		league, problems := nip101g.ParseLeague(*event)
+		data.LeagueMetadata = &league
+		data.GolfProblems = problems
 	case 31922:
 		data.templateId = CalendarEvent
 		data.kind31922Or31923Metadata = &Kind31922Or31923Metadata{CalendarEvent: nip52.ParseCalendarEvent(*event)}
@@ -244,100 +205,51 @@ func grabData(ctx context.Context, code string, withRelays bool) (Data, error) {
 	case 1501, 1502:
 		data.templateId = GolfRound
 		data.content = event.Content
-		
-		// Parse golf round metadata from NIP-101g tags
-		golfData := &Kind1501Metadata{}
-		
-		// Parse JSON content for course_snapshot
-		if event.Content != "" {
-			var contentData map[string]interface{}
-			if err := json.Unmarshal([]byte(event.Content), &contentData); err == nil {
-				if snapshot, ok := contentData["course_snapshot"].(map[string]interface{}); ok {
-					if name, ok := snapshot["course_name"].(string); ok {
-						golfData.CourseName = name
-					}
-					if tee, ok := snapshot["tee_set"].(string); ok {
-						golfData.TeeSet = tee
-					}
-					if holeCount, ok := snapshot["hole_count"].(float64); ok {
-						golfData.HoleCount = int(holeCount)
-					}
-					if holes, ok := snapshot["holes"].([]interface{}); ok {
-						if golfData.HoleCount == 0 {
-							golfData.HoleCount = len(holes)
-						}
-						golfData.HolePars = make([]int, golfData.HoleCount)
-						for _, h := range holes {
-							if hm, ok := h.(map[string]interface{}); ok {
-								num := 0
-								par := 0
-								if n, ok := hm["hole_number"].(float64); ok {
-									num = int(n)
-								}
-								if p, ok := hm["par"].(float64); ok {
-									par = int(p)
-								}
-								if num >= 1 && num <= golfData.HoleCount {
-									golfData.HolePars[num-1] = par
-									golfData.TotalPar += par
-								}
-							}
-						}
-					}
-				}
-				if notes, ok := contentData["notes"].(string); ok {
-					golfData.Notes = notes
-				}
-			}
-		}
-		
-		// Parse course reference
-		if courseTag := event.Tags.Find("course"); courseTag != nil {
-			golfData.CourseRef = courseTag[1]
-			// If we don't have a course name from JSON, extract from course reference
-			if golfData.CourseName == "" {
-				// Format: 33501:pubkey:course_id
-				parts := strings.Split(courseTag[1], ":")
-				if len(parts) >= 3 {
-					golfData.CourseName = parts[2] // Use course ID as fallback
-				}
-			}
-		}
-		
-		// Parse date
-		if dateTag := event.Tags.Find("date"); dateTag != nil {
-			golfData.Date = dateTag[1]
-		}
-		
-		// Parse tee set
-		if teeTag := event.Tags.Find("tee"); teeTag != nil {
-			golfData.TeeSet = teeTag[1]
-		}
-		
-		// Parse total score
-		if totalTag := event.Tags.Find("total"); totalTag != nil {
-			if total, err := strconv.Atoi(totalTag[1]); err == nil {
-				golfData.TotalScore = total
+
+		var round nip101g.Round
+		if event.Kind == 1502 {
+			var record nip101g.RoundRecord
+			record, data.GolfProblems = nip101g.ParseRoundRecord(*event)
+			round = record.Round
+		} else {
+			round, data.GolfProblems = nip101g.ParseRound(*event)
+		}
+
+		golfData := &Kind1501Metadata{
+			CourseName: round.Snapshot.CourseName,
+			CourseRef:  round.CourseRef,
+			Date:       round.Date,
+			TeeSet:     round.TeeSet,
+			TotalScore: round.Total,
+			Notes:      round.Notes,
+			HolePars:   round.Snapshot.HolePars,
+			TotalPar:   round.Snapshot.TotalPar,
+			HoleCount:  round.Snapshot.HoleCount,
+			Handicaps:  round.Handicaps,
+			Format:     round.Format,
+			Teams:      round.Teams,
+		}
+
+		// If we don't have a course name from the snapshot, use the course id from the reference
+		if golfData.CourseName == "" {
+			if _, _, courseID, ok := nip101g.Address(round.CourseRef); ok {
+				golfData.CourseName = courseID
 			}
 		}
-		
-		// Parse individual hole scores
-		var holeScores []HoleScore
-		for _, tag := range event.Tags {
-			if len(tag) >= 3 && tag[0] == "score" {
-				if hole, err := strconv.Atoi(tag[1]); err == nil {
-					if score, err := strconv.Atoi(tag[2]); err == nil {
-						holeScores = append(holeScores, HoleScore{
-							Hole:   hole,
-							Score:  score,
-							Strokes: score,
-						})
-					}
-				}
+
+		for _, hs := range round.Scores {
+			par := 0
+			if hs.Hole <= len(golfData.HolePars) {
+				par = golfData.HolePars[hs.Hole-1]
 			}
+			golfData.HoleScores = append(golfData.HoleScores, HoleScore{
+				Hole:    hs.Hole,
+				Score:   hs.Strokes,
+				Par:     par,
+				Strokes: hs.Strokes,
+			})
 		}
-		golfData.HoleScores = holeScores
-		
+
 		// Calculate par and score to par
 		if golfData.TotalPar > 0 {
 			golfData.Par = golfData.TotalPar
@@ -345,180 +257,47 @@ func grabData(ctx context.Context, code string, withRelays bool) (Data, error) {
 			golfData.Par = 72 // fallback for events without courseSnapshot
 		}
 		golfData.ScoreToPar = golfData.TotalScore - golfData.Par
-		
-		// Parse players (p tags)
-		var players []string
-		for _, tag := range event.Tags {
-			if len(tag) >= 2 && tag[0] == "p" {
-				players = append(players, tag[1])
-			}
+
+		for _, player := range round.Players {
+			golfData.Players = append(golfData.Players, player.PubKey)
 		}
-		golfData.Players = players
-		
+
 		data.Kind1501Metadata = golfData
 
 	case 33501:
 		data.templateId = CourseData
 		data.content = event.Content
 
-		courseData := &Kind33501Metadata{}
-
-		// d tag
-		if dTag := event.Tags.Find("d"); dTag != nil {
-			courseData.DTag = dTag[1]
-		}
-
-		// title
-		if titleTag := event.Tags.Find("title"); titleTag != nil {
-			courseData.Title = titleTag[1]
-		}
-
-		// location
-		if locTag := event.Tags.Find("location"); locTag != nil {
-			courseData.Location = locTag[1]
-		}
-
-		// country
-		if countryTag := event.Tags.Find("country"); countryTag != nil {
-			courseData.Country = countryTag[1]
-		}
-
-		// website
-		if webTag := event.Tags.Find("website"); webTag != nil {
-			courseData.Website = webTag[1]
-		}
-
-		// architect
-		if archTag := event.Tags.Find("architect"); archTag != nil {
-			courseData.Architect = archTag[1]
-		}
-
-		// established
-		if estTag := event.Tags.Find("established"); estTag != nil {
-			courseData.Established = estTag[1]
-		}
-
-		// image (hero image)
-		if imgTag := event.Tags.Find("image"); imgTag != nil {
-			courseData.ImageURL = imgTag[1]
-		}
-
-		// operator pubkey from p tag with "operator" role
-		for _, tag := range event.Tags {
-			if len(tag) >= 4 && tag[0] == "p" && tag[3] == "operator" {
-				courseData.OperatorPubkey = tag[1]
-				break
-			}
-		}
-
-		// holes
-		for _, tag := range event.Tags {
-			if len(tag) >= 4 && tag[0] == "hole" {
-				num, _ := strconv.Atoi(tag[1])
-				par, _ := strconv.Atoi(tag[2])
-				hcp, _ := strconv.Atoi(tag[3])
-				courseData.Holes = append(courseData.Holes, Course33501Hole{
-					Number:   num,
-					Par:      par,
-					Handicap: hcp,
-				})
-			}
-		}
-
-		// tees
-		for _, tag := range event.Tags {
-			if len(tag) >= 4 && tag[0] == "tee" {
-				rating, _ := strconv.ParseFloat(tag[2], 64)
-				slope, _ := strconv.Atoi(tag[3])
-				courseData.Tees = append(courseData.Tees, Course33501Tee{
-					Name:   tag[1],
-					Rating: rating,
-					Slope:  slope,
-				})
-			}
-		}
-
-		// yardages
-		for _, tag := range event.Tags {
-			if len(tag) >= 4 && tag[0] == "yardage" {
-				hole, _ := strconv.Atoi(tag[1])
-				yards, _ := strconv.Atoi(tag[3])
-				courseData.Yardages = append(courseData.Yardages, Course33501Yardage{
-					Hole:  hole,
-					Tee:   tag[2],
-					Yards: yards,
-				})
-			}
-		}
-
-		// calculate total par
-		totalPar := 0
-		for _, h := range courseData.Holes {
-			totalPar += h.Par
-		}
-		courseData.TotalPar = totalPar
-
-		data.Kind33501Metadata = courseData
+		course, problems := nip101g.ParseCourse(*event)
+		data.Kind33501Metadata = &Kind33501Metadata{course}
+		data.GolfProblems = problems
 
 	case 30501:
 		data.templateId = LiveScorecard
 		data.content = event.Content
 
-		liveData := &Kind30501Metadata{}
-
-		// d tag
-		if dTag := event.Tags.Find("d"); dTag != nil {
-			liveData.DTag = dTag[1]
-		}
-
-		// course reference
-		if courseTag := event.Tags.Find("course"); courseTag != nil {
-			liveData.CourseRef = courseTag[1]
-		}
-
-		// date
-		if dateTag := event.Tags.Find("date"); dateTag != nil {
-			liveData.Date = dateTag[1]
-		}
-
-		// tee set
-		if teeTag := event.Tags.Find("tee"); teeTag != nil {
-			liveData.TeeSet = teeTag[1]
+		live, problems := nip101g.ParseLiveScorecard(*event)
+		liveData := &Kind30501Metadata{
+			DTag:       live.DTag,
+			CourseRef:  live.CourseRef,
+			Date:       live.Date,
+			TeeSet:     live.TeeSet,
+			Status:     live.Status,
+			TotalScore: live.Sum(),
 		}
-
-		// status
-		if statusTag := event.Tags.Find("status"); statusTag != nil {
-			liveData.Status = statusTag[1]
+		for _, player := range live.Players {
+			liveData.Players = append(liveData.Players, player.PubKey)
 		}
-
-		// players (p tags)
-		for _, tag := range event.Tags {
-			if len(tag) >= 2 && tag[0] == "p" {
-				liveData.Players = append(liveData.Players, tag[1])
-			}
-		}
-
-		// hole scores
-		for _, tag := range event.Tags {
-			if len(tag) >= 3 && tag[0] == "score" {
-				hole, _ := strconv.Atoi(tag[1])
-				score, _ := strconv.Atoi(tag[2])
-				liveData.HoleScores = append(liveData.HoleScores, HoleScore{
-					Hole:    hole,
-					Score:   score,
-					Strokes: score,
-				})
-			}
-		}
-
-		// calculate total score
-		total := 0
-		for _, hs := range liveData.HoleScores {
-			total += hs.Score
+		for _, hs := range live.Scores {
+			liveData.HoleScores = append(liveData.HoleScores, HoleScore{
+				Hole:    hs.Hole,
+				Score:   hs.Strokes,
+				Strokes: hs.Strokes,
+			})
 		}
-		liveData.TotalScore = total
 
 		data.Kind30501Metadata = liveData
+		data.GolfProblems = problems
 
 	case 9802:
 		data.templateId = Highlight
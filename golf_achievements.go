@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// Achievement is a milestone a player reached in one of their rounds.
+type Achievement struct {
+	Badge
+	Detail  string // what exactly was achieved, e.g. the course or the score
+	Date    time.Time
+	Nevent  string // the round it was earned in
+	roundID string
+}
+
+// Badge is a kind of achievement. It is what gets published as a NIP-58
+// badge definition.
+type Badge struct {
+	ID          string // the badge definition's d tag
+	Name        string
+	Description string
+	Icon        string
+}
+
+// RoundAchievements are the achievements a player earned in a round.
+type RoundAchievements struct {
+	Player       PlayerData
+	Achievements []Achievement
+}
+
+var (
+	badgeFirstBirdie   = Badge{"first-birdie", "First birdie", "Made a first birdie", "🐦"}
+	badgeFirstEagle    = Badge{"first-eagle", "First eagle", "Made a first eagle or better", "🦅"}
+	badgeHoleInOne     = Badge{"hole-in-one", "Hole in one", "Holed a tee shot", "⛳"}
+	badgeBreak100      = Badge{"break-100", "Broke 100", "Shot under 100 over 18 holes", "💯"}
+	badgeBreak90       = Badge{"break-90", "Broke 90", "Shot under 90 over 18 holes", "🎯"}
+	badgeBreak80       = Badge{"break-80", "Broke 80", "Shot under 80 over 18 holes", "🚀"}
+	badgeUnderPar      = Badge{"under-par", "Under par", "Finished 18 holes under par", "🔥"}
+	badgeCourseRegular = Badge{"course-regular", "Regular", "Played " + strconv.Itoa(achievementCourseRounds) + " rounds at one course", "🏠"}
+)
+
+// achievementBadges lists every badge, for publishing their definitions.
+var achievementBadges = []Badge{
+	badgeFirstBirdie, badgeFirstEagle, badgeHoleInOne,
+	badgeBreak100, badgeBreak90, badgeBreak80, badgeUnderPar,
+	badgeCourseRegular,
+}
+
+// achievementCourseRounds is how many rounds at one course make a regular.
+const achievementCourseRounds = 10
+
+// golferAchievements goes through a player's rounds in the order they were
+// played and returns every milestone the first time it was reached. Courses
+// each give their own regular badge.
+func golferAchievements(rounds []golferRound) []Achievement {
+	rounds = slices.Clone(rounds)
+	slices.SortStableFunc(rounds, func(a, b golferRound) int { return a.round.Date.Compare(b.round.Date) })
+
+	var achievements []Achievement
+	earned := make(map[string]bool)
+	award := func(gr golferRound, badge Badge, key, detail string) {
+		if earned[key] {
+			return
+		}
+		earned[key] = true
+		achievements = append(achievements, Achievement{
+			Badge:   badge,
+			Detail:  detail,
+			Date:    gr.round.Date,
+			Nevent:  gr.round.Nevent,
+			roundID: gr.roundID,
+		})
+	}
+
+	courseRounds := make(map[string]int)
+	for _, gr := range rounds {
+		played := 0
+		for h, strokes := range gr.holes {
+			if strokes == 0 {
+				continue
+			}
+			played++
+			hole := "hole " + strconv.Itoa(h+1)
+			if strokes == 1 {
+				award(gr, badgeHoleInOne, badgeHoleInOne.ID, hole+courseDetail(gr))
+			}
+			if h >= len(gr.pars) || gr.pars[h] == 0 {
+				continue
+			}
+			switch par := gr.pars[h]; {
+			case strokes == par-1:
+				award(gr, badgeFirstBirdie, badgeFirstBirdie.ID, hole+courseDetail(gr))
+			case strokes <= par-2:
+				award(gr, badgeFirstEagle, badgeFirstEagle.ID, hole+courseDetail(gr))
+			}
+		}
+
+		if played == 18 && gr.round.Total > 0 {
+			score := strconv.Itoa(gr.round.Total) + courseDetail(gr)
+			for _, b := range []struct {
+				limit int
+				badge Badge
+			}{{100, badgeBreak100}, {90, badgeBreak90}, {80, badgeBreak80}} {
+				if gr.round.Total < b.limit {
+					award(gr, b.badge, b.badge.ID, score)
+				}
+			}
+			if gr.round.HasPar && gr.round.ScoreToPar < 0 {
+				award(gr, badgeUnderPar, badgeUnderPar.ID, formatScoreToPar(gr.round.ScoreToPar)+courseDetail(gr))
+			}
+		}
+
+		if gr.course != "" {
+			courseRounds[gr.course]++
+			if courseRounds[gr.course] == achievementCourseRounds {
+				award(gr, badgeCourseRegular, badgeCourseRegular.ID+":"+gr.course, gr.round.CourseName)
+			}
+		}
+	}
+	return achievements
+}
+
+func courseDetail(gr golferRound) string {
+	if gr.round.CourseName == "" {
+		return ""
+	}
+	return " at " + gr.round.CourseName
+}
+
+// buildRoundAchievements looks through the history of every player who
+// finished the round for the achievements they earned in it. Histories come
+// from golferHistories, so a page view doesn't load them all again.
+func buildRoundAchievements(ctx context.Context, rpd RoundPageData) []RoundAchievements {
+	var players []PlayerData
+	for _, ps := range rpd.PlayerScores {
+		if ps.IsFinal {
+			players = append(players, ps.Player)
+		}
+	}
+
+	results := make([]RoundAchievements, len(players))
+	var wg sync.WaitGroup
+	for i, player := range players {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			history := golferHistories.getWithRound(ctx, player.PubkeyHex, rpd.EventID)
+
+			results[i].Player = player
+			for _, a := range history.stats.Achievements {
+				if a.roundID == rpd.EventID {
+					results[i].Achievements = append(results[i].Achievements, a)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return slices.DeleteFunc(results, func(ra RoundAchievements) bool { return len(ra.Achievements) == 0 })
+}
+
+// badgeDefinition is the NIP-58 badge definition (kind 30009) for a badge
+// issued by pubkey, unsigned.
+func badgeDefinition(badge Badge, issuer string) nostr.Event {
+	return nostr.Event{
+		Kind:      30009,
+		PubKey:    issuer,
+		CreatedAt: nostr.Now(),
+		Tags: nostr.Tags{
+			{"d", "gambit-" + badge.ID},
+			{"name", badge.Icon + " " + badge.Name},
+			{"description", badge.Description},
+		},
+	}
+}
+
+// badgeAward is the NIP-58 badge award (kind 8) for an achievement, dated to
+// the round it was earned in and unsigned.
+func badgeAward(a Achievement, player string, issuer string) nostr.Event {
+	tags := nostr.Tags{
+		{"a", "30009:" + issuer + ":gambit-" + a.ID},
+		{"p", player, gambitRelay},
+	}
+	if a.roundID != "" {
+		tags = append(tags, nostr.Tag{"e", a.roundID, gambitRelay})
+	}
+	return nostr.Event{
+		Kind:      8,
+		PubKey:    issuer,
+		CreatedAt: nostr.Timestamp(a.Date.Unix()),
+		Tags:      tags,
+		Content:   a.Detail,
+	}
+}
+
+// Achievement template helper functions
+
+func achievementTitle(a Achievement) string {
+	title := a.Description + ", " + a.Date.Format("2006-01-02")
+	if a.Detail != "" {
+		title += " (" + a.Detail + ")"
+	}
+	return title
+}
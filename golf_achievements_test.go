@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfAchievements(t *testing.T) {
+	pars := []int{4, 4, 3, 5, 4, 4, 3, 5, 4, 4, 4, 3, 5, 4, 4, 3, 5, 4} // par 72
+	round := func(day int, id string, bump map[int]int) golferRound {
+		holes := make([]int, 18)
+		for i, p := range pars {
+			holes[i] = p + 1 // bogey golf, 90
+		}
+		for h, strokes := range bump {
+			holes[h] = strokes
+		}
+		return golferRound{
+			round:   GolferRound{Date: time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC), CourseName: "Pines", Total: sumSlice(holes, 0, 18)},
+			roundID: id,
+			course:  "33501:abc:pines",
+			holes:   holes,
+			pars:    pars,
+		}
+	}
+
+	rounds := []golferRound{
+		round(3, "r3", map[int]int{2: 1}),       // an ace on the par 3 3rd: eagle too, and 88
+		round(1, "r1", map[int]int{0: 7}),       // 92: broke 100
+		round(2, "r2", map[int]int{0: 3, 1: 3}), // 88: a birdie and broke 90
+	}
+	for i := range 7 {
+		rounds = append(rounds, round(10+i, "", nil))
+	}
+	for i := range rounds {
+		rounds[i].round.HasPar = true
+		rounds[i].round.ScoreToPar = rounds[i].round.Total - 72
+	}
+
+	achievements := golferAchievements(rounds)
+	var ids []string
+	for _, a := range achievements {
+		ids = append(ids, a.ID)
+	}
+	assert.Equal(t, []string{"break-100", "first-birdie", "break-90", "hole-in-one", "first-eagle", "course-regular"}, ids)
+
+	require.Len(t, achievements, 6)
+	assert.Equal(t, "r1", achievements[0].roundID)
+	assert.Equal(t, "92 at Pines", achievements[0].Detail)
+	assert.Equal(t, "hole 1 at Pines", achievements[1].Detail)
+	assert.Equal(t, "hole 3 at Pines", achievements[3].Detail)
+	assert.Equal(t, "Pines", achievements[5].Detail)
+	assert.Equal(t, 16, achievements[5].Date.Day())
+
+	// an under par round breaks every barrier at once
+	low := round(20, "r20", nil)
+	for i, p := range pars {
+		low.holes[i] = p
+	}
+	low.holes[0] = 3
+	low.round.Total, low.round.HasPar, low.round.ScoreToPar = 71, true, -1
+	ids = nil
+	for _, a := range golferAchievements([]golferRound{low}) {
+		ids = append(ids, a.ID)
+	}
+	assert.Equal(t, []string{"first-birdie", "break-100", "break-90", "break-80", "under-par"}, ids)
+
+	award := badgeAward(achievements[3], "player", "issuer")
+	assert.Equal(t, 8, award.Kind)
+	assert.Equal(t, "30009:issuer:gambit-hole-in-one", award.Tags.GetFirst([]string{"a"}).Value())
+	assert.Equal(t, "r3", award.Tags.GetFirst([]string{"e"}).Value())
+	assert.Equal(t, "gambit-hole-in-one", badgeDefinition(badgeHoleInOne, "issuer").Tags.GetD())
+}
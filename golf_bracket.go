@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// BracketData is a match play knockout laid out for drawing, as SVG on the
+// tournament page and as the OG image.
+type BracketData struct {
+	Rounds   []BracketRound
+	Champion *PlayerData
+	Width    float64
+	Height   float64
+}
+
+// BracketRound is one column of the bracket.
+type BracketRound struct {
+	Name    string // "Round of 16", "Quarterfinals", "Semifinals", "Final"
+	X       float64
+	Matches []BracketMatchData
+}
+
+// BracketMatchData is one match box of the bracket.
+type BracketMatchData struct {
+	Round    int
+	Position int
+	A        BracketSlot
+	B        BracketSlot
+	Status   string // "Alice 2 UP thru 14", "Bye", "" before the match is set up
+	Winner   int    // 1 when A won, 2 when B won, 0 otherwise
+	Nevent   string // link to the round the match is played in
+	X        float64
+	Y        float64 // top left corner of the box
+}
+
+// BracketSlot is one side of a match.
+type BracketSlot struct {
+	Player *PlayerData // nil while waiting for the winner of an earlier match, or for a bye
+	Seed   int         // 0 when unseeded
+}
+
+// bracketLine is an elbow connector from a match to the one its winner plays next.
+type bracketLine struct {
+	X1, Y1, X2, Y2 float64
+}
+
+const (
+	bracketBoxWidth  = 220.0
+	bracketBoxHeight = 64.0
+	bracketColumnGap = 48.0
+	bracketRowGap    = 16.0
+	bracketHeader    = 28.0 // room for the round names
+)
+
+// buildBracketData fetches the scores of every match round and the players'
+// profiles, and works out the bracket.
+func buildBracketData(ctx context.Context, bracket *nip101g.Bracket) BracketData {
+	var roundIDs []string
+	pubkeys := make(map[string]bool)
+	for _, pk := range bracket.Seeds {
+		if pk != "" {
+			pubkeys[pk] = true
+		}
+	}
+	for _, m := range bracket.Matches {
+		if m.RoundID != "" {
+			roundIDs = append(roundIDs, m.RoundID)
+		}
+		for _, pk := range []string{m.PlayerA, m.PlayerB} {
+			if pk != "" {
+				pubkeys[pk] = true
+			}
+		}
+	}
+
+	// final records win over live scorecards for the same player and round
+	cards := make(map[string]map[string]nip101g.Scorecard)
+	holeCounts := make(map[string]int)
+	records, livecards := fetchTournamentScores(ctx, roundIDs)
+	for _, evt := range livecards {
+		live, _ := nip101g.ParseLiveScorecard(*evt)
+		if cards[live.RoundID] == nil {
+			cards[live.RoundID] = make(map[string]nip101g.Scorecard)
+		}
+		cards[live.RoundID][evt.PubKey] = live.Scorecard
+	}
+	for _, evt := range records {
+		record, _ := nip101g.ParseRoundRecord(*evt)
+		if cards[record.RoundID] == nil {
+			cards[record.RoundID] = make(map[string]nip101g.Scorecard)
+		}
+		cards[record.RoundID][evt.PubKey] = record.Scorecard
+		holeCounts[record.RoundID] = record.HoleCount()
+	}
+
+	list := make([]string, 0, len(pubkeys))
+	for pk := range pubkeys {
+		list = append(list, pk)
+	}
+	return computeBracket(bracket, fetchPlayerProfiles(ctx, list), cards, holeCounts)
+}
+
+// computeBracket pairs the first round by seed (1 v 8, 4 v 5, 2 v 7, 3 v 6 for
+// eight players), plays every match from its scorecards and moves the winners
+// on. cards maps a match's round ID to the scorecards by player.
+func computeBracket(bracket *nip101g.Bracket, profiles map[string]PlayerData, cards map[string]map[string]nip101g.Scorecard, holeCounts map[string]int) BracketData {
+	size := bracketSize(bracket)
+	rounds := bits.TrailingZeros(uint(size))
+
+	tags := make(map[[2]int]nip101g.BracketMatch, len(bracket.Matches))
+	for _, m := range bracket.Matches {
+		tags[[2]int{m.Round, m.Position}] = m
+	}
+	seeds := make(map[string]int, len(bracket.Seeds))
+	for i, pk := range bracket.Seeds {
+		if pk != "" {
+			seeds[pk] = i + 1
+		}
+	}
+	slot := func(pk string) BracketSlot {
+		if pk == "" {
+			return BracketSlot{}
+		}
+		pd, ok := profiles[pk]
+		if !ok {
+			npub, _ := nip19.EncodePublicKey(pk)
+			pd = PlayerData{PubkeyHex: pk, Npub: npub, DisplayName: shortenString(npub, 8, 4)}
+		}
+		return BracketSlot{Player: &pd, Seed: seeds[pk]}
+	}
+	seeded := func(seed int) string {
+		if seed <= len(bracket.Seeds) {
+			return bracket.Seeds[seed-1]
+		}
+		return ""
+	}
+
+	data := BracketData{
+		Width:  float64(rounds)*bracketBoxWidth + float64(rounds-1)*bracketColumnGap,
+		Height: bracketHeader + float64(size/2)*(bracketBoxHeight+bracketRowGap) - bracketRowGap,
+	}
+	order := seedOrder(size)
+	for r := 1; r <= rounds; r++ {
+		round := BracketRound{
+			Name: bracketRoundName(r, rounds, size),
+			X:    float64(r-1) * (bracketBoxWidth + bracketColumnGap),
+		}
+		span := float64(int(1)<<(r-1)) * (bracketBoxHeight + bracketRowGap) // height of the first round boxes this match gathers
+		for p := 1; p <= size>>r; p++ {
+			m := BracketMatchData{
+				Round:    r,
+				Position: p,
+				X:        round.X,
+				Y:        bracketHeader + float64(p-1)*span + (span-bracketRowGap-bracketBoxHeight)/2,
+			}
+			if r == 1 {
+				m.A, m.B = slot(seeded(order[2*p-2])), slot(seeded(order[2*p-1]))
+			} else {
+				prev := data.Rounds[r-2].Matches
+				m.A, m.B = prev[2*p-2].winner(), prev[2*p-1].winner()
+			}
+
+			tag, ok := tags[[2]int{r, p}]
+			if ok && tag.PlayerA != "" && tag.PlayerB != "" {
+				m.A, m.B = slot(tag.PlayerA), slot(tag.PlayerB)
+			}
+			if ok && tag.RoundID != "" {
+				m.Nevent, _ = nip19.EncodeEvent(tag.RoundID, []string{gambitRelay}, "")
+			}
+
+			switch {
+			case r == 1 && (m.A.Player == nil) != (m.B.Player == nil):
+				m.Status = "Bye"
+				m.Winner = 1
+				if m.A.Player == nil {
+					m.Winner = 2
+				}
+			case m.A.Player != nil && m.B.Player != nil && ok && tag.RoundID != "":
+				holeCount := holeCounts[tag.RoundID]
+				if holeCount == 0 {
+					holeCount = nip101g.DefaultHoleCount
+				}
+				m.play(cards[tag.RoundID], holeCount)
+			}
+			round.Matches = append(round.Matches, m)
+		}
+		data.Rounds = append(data.Rounds, round)
+	}
+
+	final := data.Rounds[len(data.Rounds)-1].Matches[0]
+	data.Champion = final.winner().Player
+	return data
+}
+
+// play works out the match from both players' scorecards. A halved match is
+// decided on the first playoff hole one of them wins.
+func (m *BracketMatchData) play(cards map[string]nip101g.Scorecard, holeCount int) {
+	a, b := cards[m.A.Player.PubkeyHex], cards[m.B.Player.PubkeyHex]
+	res := matchResult("Match", *m.A.Player, *m.B.Player, scorecardHoles(a, holeCount), scorecardHoles(b, holeCount), 0, holeCount)
+	m.Status, m.Winner = res.Status, res.Winner
+	if !res.Finished || res.Winner != 0 {
+		return
+	}
+	for i := 0; i < len(a.Playoff) && i < len(b.Playoff); i++ {
+		if a.Playoff[i].Strokes == b.Playoff[i].Strokes {
+			continue
+		}
+		m.Winner = 1
+		if b.Playoff[i].Strokes < a.Playoff[i].Strokes {
+			m.Winner = 2
+		}
+		m.Status = fmt.Sprintf("%s wins at the %s", m.slot(m.Winner).Player.DisplayName, ordinal(holeCount+i+1))
+		return
+	}
+}
+
+func (m BracketMatchData) slot(side int) BracketSlot {
+	if side == 2 {
+		return m.B
+	}
+	return m.A
+}
+
+// winner is the side that goes through, empty until the match is decided.
+func (m BracketMatchData) winner() BracketSlot {
+	if m.Winner == 0 {
+		return BracketSlot{}
+	}
+	return m.slot(m.Winner)
+}
+
+// bracketSize is the number of first round places: the seeds and the declared
+// matches rounded up to a power of two.
+func bracketSize(bracket *nip101g.Bracket) int {
+	n := max(2, len(bracket.Seeds))
+	for _, m := range bracket.Matches {
+		n = max(n, m.Position<<m.Round)
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// seedOrder lists the seeds top to bottom down the first round so that the
+// best seeds can only meet late: 1, 8, 4, 5, 2, 7, 3, 6 for eight players.
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, s := range order {
+			next = append(next, s, len(order)*2+1-s)
+		}
+		order = next
+	}
+	return order
+}
+
+func bracketRoundName(round, rounds, size int) string {
+	switch rounds - round {
+	case 0:
+		return "Final"
+	case 1:
+		return "Semifinals"
+	case 2:
+		return "Quarterfinals"
+	}
+	return "Round of " + strconv.Itoa(size>>(round-1))
+}
+
+// lines are the connectors from every match to the next one.
+func (bd BracketData) lines() []bracketLine {
+	var lines []bracketLine
+	for r := 0; r+1 < len(bd.Rounds); r++ {
+		for i, m := range bd.Rounds[r].Matches {
+			next := bd.Rounds[r+1].Matches[i/2]
+			lines = append(lines, bracketLine{
+				X1: m.X + bracketBoxWidth,
+				Y1: m.Y + bracketBoxHeight/2,
+				X2: next.X,
+				Y2: next.Y + bracketBoxHeight/2,
+			})
+		}
+	}
+	return lines
+}
+
+// svgPath draws the connector as a horizontal-vertical-horizontal elbow.
+func (l bracketLine) svgPath() string {
+	mid := (l.X1 + l.X2) / 2
+	return fmt.Sprintf("M%s %sH%sV%sH%s", svgNum(l.X1), svgNum(l.Y1), svgNum(mid), svgNum(l.Y2), svgNum(l.X2))
+}
+
+func svgNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// bracketSlotName is what a side of a match box shows.
+func bracketSlotName(s BracketSlot) string {
+	if s.Player == nil {
+		return "—"
+	}
+	name := s.Player.DisplayName
+	if r := []rune(name); len(r) > 22 {
+		name = string(r[:21]) + "…"
+	}
+	return name
+}
+
+func bracketSeedLabel(s BracketSlot) string {
+	if s.Seed == 0 {
+		return ""
+	}
+	return strconv.Itoa(s.Seed)
+}
+
+// bracketOGDescription names the champion, or the matches of the latest round
+// that has been set up.
+func bracketOGDescription(bd BracketData) string {
+	if bd.Champion != nil {
+		return "Champion: " + bd.Champion.DisplayName
+	}
+	for r := len(bd.Rounds) - 1; r >= 0; r-- {
+		var matches []string
+		for _, m := range bd.Rounds[r].Matches {
+			if m.A.Player != nil && m.B.Player != nil {
+				matches = append(matches, m.A.Player.DisplayName+" v "+m.B.Player.DisplayName)
+			}
+		}
+		if len(matches) > 0 {
+			return bd.Rounds[r].Name + ": " + strings.Join(matches, ", ")
+		}
+	}
+	return ""
+}
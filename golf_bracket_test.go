@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBracket(t *testing.T) {
+	assert.Equal(t, []int{1, 8, 4, 5, 2, 7, 3, 6}, seedOrder(8))
+
+	card := func(strokes int, holes int, playoff ...int) nip101g.Scorecard {
+		var sc nip101g.Scorecard
+		for h := 1; h <= 18; h++ {
+			s := 4
+			if h <= holes {
+				s = strokes
+			}
+			sc.Scores = append(sc.Scores, nip101g.HoleScore{Hole: h, Strokes: s})
+		}
+		for i, s := range playoff {
+			sc.Playoff = append(sc.Playoff, nip101g.HoleScore{Hole: i + 1, Strokes: s})
+		}
+		return sc
+	}
+
+	bracket := &nip101g.Bracket{
+		Seeds: []string{"aa", "bb", "cc"},
+		Matches: []nip101g.BracketMatch{
+			{Round: 1, Position: 2, RoundID: "semi"},
+			{Round: 2, Position: 1, RoundID: "final"},
+		},
+	}
+	profiles := map[string]PlayerData{
+		"aa": {PubkeyHex: "aa", DisplayName: "Ann"},
+		"bb": {PubkeyHex: "bb", DisplayName: "Bob"},
+		"cc": {PubkeyHex: "cc", DisplayName: "Cat"},
+	}
+	cards := map[string]map[string]nip101g.Scorecard{
+		"semi":  {"bb": card(4, 0), "cc": card(5, 10)},
+		"final": {"aa": card(4, 0, 4), "bb": card(4, 0, 3)},
+	}
+
+	bd := computeBracket(bracket, profiles, cards, nil)
+	require.Len(t, bd.Rounds, 2)
+	assert.Equal(t, "Semifinals", bd.Rounds[0].Name)
+	assert.Equal(t, "Bye", bd.Rounds[0].Matches[0].Status)
+	assert.Equal(t, 3, bd.Rounds[0].Matches[1].B.Seed)
+	assert.Equal(t, "Bob wins 10 & 8", bd.Rounds[0].Matches[1].Status)
+	assert.Equal(t, "Bob wins at the 19th", bd.Rounds[1].Matches[0].Status)
+	require.NotNil(t, bd.Champion)
+	assert.Equal(t, "Bob", bd.Champion.DisplayName)
+	assert.Equal(t, "M220 60H244V100H268", bd.lines()[0].svgPath())
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GolferCharts are the scoring charts on a golfer's profile. They are laid out
+// here once and drawn both as SVG on the page and as PNG under
+// /image/<npub>/<chart>.png so they can be shared.
+type GolferCharts struct {
+	Scoring       LineChart // score to par per complete round, with a rolling average
+	Differentials LineChart // WHS score differentials, with the index after each round
+	Distribution  BarChart  // holes by score to par
+}
+
+// LineChart is a value per round, oldest first, with a trend line over it.
+type LineChart struct {
+	Name       string // the chart's /image/<npub>/<name>.png
+	Title      string
+	ValueLabel string
+	LineLabel  string
+	Signed     bool // values are to par: +2, E, -1
+	Values     []float64
+	Line       []float64 // NaN where there is no trend yet
+	Dates      []time.Time
+	Min, Max   float64 // the y axis range, a multiple of Step
+	Step       float64 // between y axis ticks
+}
+
+// BarChart is a histogram of holes.
+type BarChart struct {
+	Name   string
+	Title  string
+	Labels []string
+	Counts []int
+	Total  int
+}
+
+// chartPoint is a value placed on a chart.
+type chartPoint struct {
+	X, Y  float64
+	Title string
+}
+
+// chartBar is a bar of a histogram placed on a chart.
+type chartBar struct {
+	X, Y, Width, Height float64
+	Label               string
+	Count               int
+	Share               string
+}
+
+// chart dimensions in SVG units; the PNGs scale them to fit their card.
+const (
+	chartWidth  = 640.0
+	chartHeight = 260.0
+	chartLeft   = 44.0 // room for the y axis labels
+	chartRight  = 12.0
+	chartTop    = 12.0
+	chartBottom = 28.0 // room for the x axis labels
+)
+
+const (
+	// chartRollingRounds is how many rounds the scoring average rolls over.
+	chartRollingRounds = 5
+	// chartMaxRounds is how many of the latest rounds the scoring chart shows.
+	chartMaxRounds = 40
+)
+
+var chartHoleResults = []string{"Eagle+", "Birdie", "Par", "Bogey", "Double+"}
+
+// buildGolferCharts lays out the charts from a player's stats and handicap.
+// A chart is left empty when there is nothing to draw on it.
+func buildGolferCharts(st GolferStats, h HandicapSummary) GolferCharts {
+	charts := GolferCharts{
+		Scoring: LineChart{
+			Name:       "scoring",
+			Title:      "Score to par",
+			ValueLabel: "Round",
+			LineLabel:  strconv.Itoa(chartRollingRounds) + " round average",
+			Signed:     true,
+		},
+		Differentials: LineChart{
+			Name:       "differentials",
+			Title:      "Handicap differentials",
+			ValueLabel: "Differential",
+			LineLabel:  "Index",
+		},
+		Distribution: BarChart{
+			Name:   "distribution",
+			Title:  "Scoring distribution",
+			Labels: chartHoleResults,
+		},
+	}
+
+	// the rolling average runs over every round, then the latest are shown
+	values := make([]float64, len(st.Trend))
+	rolling := make([]float64, len(st.Trend))
+	for i, r := range st.Trend {
+		values[i] = float64(r.ScoreToPar)
+		from := max(0, i+1-chartRollingRounds)
+		sum := 0.0
+		for _, v := range values[from : i+1] {
+			sum += v
+		}
+		rolling[i] = sum / float64(i+1-from)
+	}
+	from := max(0, len(st.Trend)-chartMaxRounds)
+	for _, r := range st.Trend[from:] {
+		charts.Scoring.Dates = append(charts.Scoring.Dates, r.Date)
+	}
+	charts.Scoring.Values = values[from:]
+	charts.Scoring.Line = rolling[from:]
+	charts.Scoring.fitRange()
+
+	// Rounds are the latest differentials, newest first, and the history has
+	// the index after every round from the one that first gave an index: both
+	// end with the latest round.
+	n := len(h.Rounds)
+	for i := range n {
+		r := h.Rounds[n-1-i]
+		charts.Differentials.Values = append(charts.Differentials.Values, r.Differential)
+		charts.Differentials.Dates = append(charts.Differentials.Dates, r.Date)
+		index := math.NaN()
+		if j := len(h.History) - n + i; j >= 0 {
+			index = h.History[j].Index
+		}
+		charts.Differentials.Line = append(charts.Differentials.Line, index)
+	}
+	charts.Differentials.fitRange()
+
+	charts.Distribution.Counts = st.HoleResults[:]
+	for _, c := range st.HoleResults {
+		charts.Distribution.Total += c
+	}
+
+	return charts
+}
+
+// chart returns the chart with the given name, as used in its image URL.
+func (gc GolferCharts) chart(name string) (line *LineChart, bar *BarChart) {
+	switch name {
+	case gc.Scoring.Name:
+		return &gc.Scoring, nil
+	case gc.Differentials.Name:
+		return &gc.Differentials, nil
+	case gc.Distribution.Name:
+		return nil, &gc.Distribution
+	}
+	return nil, nil
+}
+
+// fitRange picks a tick step that gives a handful of ticks and widens the
+// range to the steps around the values.
+func (c *LineChart) fitRange() {
+	if len(c.Values) == 0 {
+		return
+	}
+	lo, hi := c.Values[0], c.Values[0]
+	for _, vs := range [][]float64{c.Values, c.Line} {
+		for _, v := range vs {
+			if !math.IsNaN(v) {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+	}
+	c.Step = 1
+	for _, step := range []float64{1, 2, 5, 10, 20, 50} {
+		c.Step = step
+		if (hi-lo)/step <= 5 {
+			break
+		}
+	}
+	c.Min = math.Floor(lo/c.Step) * c.Step
+	c.Max = math.Ceil(hi/c.Step) * c.Step
+	if c.Max == c.Min {
+		c.Max += c.Step
+	}
+}
+
+func (c LineChart) x(i int) float64 {
+	if len(c.Values) < 2 {
+		return (chartLeft + chartWidth - chartRight) / 2
+	}
+	return chartLeft + float64(i)*(chartWidth-chartLeft-chartRight)/float64(len(c.Values)-1)
+}
+
+func (c LineChart) y(v float64) float64 {
+	return chartTop + (c.Max-v)/(c.Max-c.Min)*(chartHeight-chartTop-chartBottom)
+}
+
+// ticks are the values the y axis is marked at, bottom up.
+func (c LineChart) ticks() []float64 {
+	var ticks []float64
+	for v := c.Min; v <= c.Max; v += c.Step {
+		ticks = append(ticks, v)
+	}
+	return ticks
+}
+
+func (c LineChart) tickLabel(v float64) string {
+	if c.Signed {
+		return formatScoreToPar(int(v))
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (c LineChart) points() []chartPoint {
+	points := make([]chartPoint, len(c.Values))
+	for i, v := range c.Values {
+		label := strconv.FormatFloat(v, 'f', 1, 64)
+		if c.Signed {
+			label = formatScoreToPar(int(v))
+		}
+		points[i] = chartPoint{
+			X:     c.x(i),
+			Y:     c.y(v),
+			Title: c.Dates[i].Format("2006-01-02") + ": " + label,
+		}
+	}
+	return points
+}
+
+// segments are the trend line split where it has no value.
+func (c LineChart) segments() [][]chartPoint {
+	var segments [][]chartPoint
+	var current []chartPoint
+	for i, v := range c.Line {
+		if math.IsNaN(v) {
+			if len(current) > 0 {
+				segments = append(segments, current)
+			}
+			current = nil
+			continue
+		}
+		current = append(current, chartPoint{X: c.x(i), Y: c.y(v)})
+	}
+	if len(current) > 0 {
+		segments = append(segments, current)
+	}
+	return segments
+}
+
+// dateLabels are the dates of the first and the last round.
+func (c LineChart) dateLabels() (first, last string) {
+	if len(c.Dates) == 0 {
+		return "", ""
+	}
+	return c.Dates[0].Format("Jan 2, 2006"), c.Dates[len(c.Dates)-1].Format("Jan 2, 2006")
+}
+
+func (c LineChart) valuesPath() string {
+	return chartPath(c.points())
+}
+
+func (c LineChart) linePath() string {
+	var paths []string
+	for _, segment := range c.segments() {
+		paths = append(paths, chartPath(segment))
+	}
+	return strings.Join(paths, "")
+}
+
+// chartPath draws a polyline through the points.
+func chartPath(points []chartPoint) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i == 0 {
+			b.WriteString("M")
+		} else {
+			b.WriteString("L")
+		}
+		b.WriteString(svgNum(math.Round(p.X*10)/10) + " " + svgNum(math.Round(p.Y*10)/10))
+	}
+	return b.String()
+}
+
+func (c BarChart) bars() []chartBar {
+	highest := 1
+	for _, count := range c.Counts {
+		highest = max(highest, count)
+	}
+	slot := (chartWidth - chartLeft - chartRight) / float64(len(c.Counts))
+	plot := chartHeight - chartTop - chartBottom
+	bars := make([]chartBar, len(c.Counts))
+	for i, count := range c.Counts {
+		height := plot * float64(count) / float64(highest)
+		bars[i] = chartBar{
+			X:      chartLeft + float64(i)*slot + slot*0.15,
+			Y:      chartTop + plot - height,
+			Width:  slot * 0.7,
+			Height: height,
+			Label:  c.Labels[i],
+			Count:  count,
+		}
+		if c.Total > 0 {
+			bars[i].Share = strconv.FormatFloat(float64(count)/float64(c.Total)*100, 'f', 0, 64) + "%"
+		}
+	}
+	return bars
+}
+
+// Golfer charts template helper functions
+
+func chartViewBox() string {
+	return "0 0 " + svgNum(chartWidth) + " " + svgNum(chartHeight)
+}
+
+func chartImageURL(npub, name string) string {
+	return "/image/" + npub + "/" + name + ".png"
+}
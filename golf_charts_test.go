@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolferCharts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	var st GolferStats
+	for i, toPar := range []int{10, 6, 8, 2, 4, 0} {
+		st.Trend = append(st.Trend, GolferRound{Date: day(i + 1), ScoreToPar: toPar, HasPar: true})
+	}
+	st.HoleResults = [5]int{0, 4, 20, 10, 2}
+
+	// four rounds, the index only from the third on
+	h := HandicapSummary{
+		Rounds: []WHSRound{
+			{Date: day(4), Differential: 12.3},
+			{Date: day(3), Differential: 15.1},
+			{Date: day(2), Differential: 18.0},
+			{Date: day(1), Differential: 20.4},
+		},
+		History: []WHSHistoryPoint{{Date: day(3), Index: 13.1}, {Date: day(4), Index: 11.3}},
+	}
+
+	charts := buildGolferCharts(st, h)
+
+	scoring := charts.Scoring
+	require.Len(t, scoring.Line, 6)
+	assert.Equal(t, 8.0, scoring.Line[2])
+	assert.Equal(t, 4.0, scoring.Line[5]) // (6+8+2+4+0)/5
+	assert.Equal(t, 2.0, scoring.Step)
+	assert.Equal(t, []float64{0, 2, 4, 6, 8, 10}, scoring.ticks())
+	assert.Equal(t, "E", scoring.tickLabel(0))
+	assert.Equal(t, chartTop, scoring.y(10))
+	assert.Equal(t, chartHeight-chartBottom, scoring.y(0))
+	assert.Equal(t, chartLeft, scoring.x(0))
+	assert.Equal(t, chartWidth-chartRight, scoring.x(5))
+	assert.Equal(t, "2025-06-01: +10", scoring.points()[0].Title)
+
+	diffs := charts.Differentials
+	assert.Equal(t, []float64{20.4, 18.0, 15.1, 12.3}, diffs.Values)
+	assert.True(t, math.IsNaN(diffs.Line[1]))
+	assert.Equal(t, 13.1, diffs.Line[2])
+	require.Len(t, diffs.segments(), 1)
+	assert.Len(t, diffs.segments()[0], 2)
+	assert.Equal(t, 10.0, diffs.Min)
+	assert.Equal(t, 22.0, diffs.Max)
+
+	line, bar := charts.chart("distribution")
+	assert.Nil(t, line)
+	require.NotNil(t, bar)
+	bars := bar.bars()
+	require.Len(t, bars, 5)
+	assert.Equal(t, "56%", bars[2].Share)
+	assert.Equal(t, chartTop, bars[2].Y)
+	assert.Equal(t, 0.0, bars[0].Height)
+
+	line, bar = charts.chart("nope")
+	assert.Nil(t, line)
+	assert.Nil(t, bar)
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// ComparePageData puts two golfers side by side: their record in the rounds
+// they played together and their overall stats.
+type ComparePageData struct {
+	A, B          ComparePlayer
+	Rounds        []CompareRound // rounds both finished, newest first
+	WinsA, WinsB  int
+	Ties          int
+	StrokesGained float64 // A's average strokes per round better than B, negative when B is better
+	Courses       []CompareCourse
+	Partial       bool // only the latest of A's rounds could be searched
+}
+
+// ComparePlayer is one side of the comparison.
+type ComparePlayer struct {
+	Player   PlayerData
+	Stats    GolferStats
+	Handicap HandicapSummary
+}
+
+// CompareRound is a round both players finished. Totals are over the holes
+// both of them played.
+type CompareRound struct {
+	Nevent     string
+	Date       time.Time
+	CourseName string
+	Holes      int
+	TotalA     int
+	TotalB     int
+}
+
+// CompareCourse is the hole by hole record on a course the players shared.
+type CompareCourse struct {
+	Name     string
+	Rounds   int
+	HolePars []int // nil when the course is unknown
+	Holes    []CompareHole
+	WinsA    int
+	WinsB    int
+	Halved   int
+}
+
+// CompareHole counts how often each player won a hole of a course.
+type CompareHole struct {
+	WinsA, WinsB, Halved int
+}
+
+// CompareStatRow is a line of the side by side stats, Better being the side
+// (1 or 2) with the better value, 0 when even or not comparable.
+type CompareStatRow struct {
+	Label  string
+	A, B   string
+	Better int
+}
+
+// compareRound is a shared round with both players' hole scores.
+type compareRound struct {
+	round  CompareRound
+	course string // course coordinate, or the course name when the round has none
+	pars   []int
+	a, b   []int
+}
+
+// buildComparePageData finds the 1501s both players are tagged on and reads
+// their final records, then their overall stats.
+func buildComparePageData(ctx context.Context, a, b string) ComparePageData {
+	profiles := fetchPlayerProfiles(ctx, []string{a, b})
+	side := func(pk string) ComparePlayer {
+		history := golferHistories.get(ctx, pk)
+		return ComparePlayer{
+			Player:   profiles[pk],
+			Handicap: history.handicap,
+			Stats:    history.stats,
+		}
+	}
+
+	shared, partial := fetchSharedRounds(ctx, a, b)
+	ids := make([]string, len(shared))
+	for i, evt := range shared {
+		ids[i] = evt.ID
+	}
+	// the records of every shared round come in one query, by the round they close
+	byRound := make(map[string][]*nostr.Event)
+	var records []*nostr.Event
+	for chunk := range slices.Chunk(ids, roundIDsPerQuery) {
+		chunkRecords, _ := fetchTournamentScores(ctx, chunk)
+		records = append(records, chunkRecords...)
+	}
+	for _, evt := range records {
+		record, _ := nip101g.ParseRoundRecord(*evt)
+		byRound[record.RoundID] = append(byRound[record.RoundID], evt)
+	}
+
+	courses := make(courseCache)
+	var courseRefs []string
+	for _, evt := range shared {
+		if round, _ := nip101g.ParseRound(*evt); round.CourseRef != "" {
+			courseRefs = append(courseRefs, round.CourseRef)
+		}
+	}
+	courses.prefetch(ctx, courseRefs)
+
+	var rounds []compareRound
+	for _, evt := range shared {
+		records := byRound[evt.ID]
+		recordA, recordB := latestRecordBy(records, a), latestRecordBy(records, b)
+		if recordA == nil || recordB == nil {
+			continue // only rounds both finished count
+		}
+
+		round, _ := nip101g.ParseRound(*evt)
+		cr := compareRound{
+			round: CompareRound{
+				Date:       evt.CreatedAt.Time(),
+				CourseName: round.Snapshot.CourseName,
+			},
+			course: round.CourseRef,
+			pars:   round.Snapshot.HolePars,
+		}
+		if t, err := time.Parse("2006-01-02", formatDate(round.Date)); err == nil {
+			cr.round.Date = t
+		}
+		if round.CourseRef != "" {
+			if course := courses.get(ctx, round.CourseRef, roundTime(round.Date, evt.CreatedAt.Time())); course != nil {
+				if course.Title != "" {
+					cr.round.CourseName = course.Title
+				}
+				if pars := course.HolePars(); len(pars) > 0 {
+					cr.pars = pars
+				}
+			}
+		} else {
+			cr.course = cr.round.CourseName
+		}
+		cr.round.Nevent, _ = nip19.EncodeEvent(evt.ID, []string{gambitRelay}, evt.PubKey)
+
+		holeCount := round.HoleCount()
+		scoresA, _ := nip101g.ParseRoundRecord(*recordA)
+		scoresB, _ := nip101g.ParseRoundRecord(*recordB)
+		cr.a = scorecardHoles(scoresA.Scorecard, holeCount)
+		cr.b = scorecardHoles(scoresB.Scorecard, holeCount)
+		rounds = append(rounds, cr)
+	}
+
+	cd := computeComparison(side(a), side(b), rounds)
+	cd.Partial = partial
+	return cd
+}
+
+func computeComparison(a, b ComparePlayer, rounds []compareRound) ComparePageData {
+	cd := ComparePageData{A: a, B: b}
+	courses := make(map[string]*CompareCourse)
+	var order []string
+	margins := 0
+
+	for _, cr := range rounds {
+		course, ok := courses[cr.course]
+		if !ok {
+			course = &CompareCourse{Name: cr.round.CourseName}
+			courses[cr.course] = course
+			order = append(order, cr.course)
+		}
+		if len(cr.pars) > len(course.HolePars) {
+			course.HolePars = cr.pars
+		}
+		for len(course.Holes) < min(len(cr.a), len(cr.b)) {
+			course.Holes = append(course.Holes, CompareHole{})
+		}
+
+		r := cr.round
+		for i := range min(len(cr.a), len(cr.b)) {
+			sa, sb := cr.a[i], cr.b[i]
+			if sa == 0 || sb == 0 {
+				continue // the holes both played are the ones compared
+			}
+			r.Holes++
+			r.TotalA += sa
+			r.TotalB += sb
+			switch {
+			case sa < sb:
+				course.Holes[i].WinsA++
+				course.WinsA++
+			case sb < sa:
+				course.Holes[i].WinsB++
+				course.WinsB++
+			default:
+				course.Holes[i].Halved++
+				course.Halved++
+			}
+		}
+		if r.Holes == 0 {
+			continue
+		}
+		course.Rounds++
+
+		switch {
+		case r.TotalA < r.TotalB:
+			cd.WinsA++
+		case r.TotalB < r.TotalA:
+			cd.WinsB++
+		default:
+			cd.Ties++
+		}
+		margins += r.TotalB - r.TotalA
+		cd.Rounds = append(cd.Rounds, r)
+	}
+
+	if len(cd.Rounds) > 0 {
+		cd.StrokesGained = float64(margins) / float64(len(cd.Rounds))
+	}
+	slices.SortStableFunc(cd.Rounds, func(x, y CompareRound) int { return y.Date.Compare(x.Date) })
+
+	for _, key := range order {
+		if course := courses[key]; course.Rounds > 0 {
+			cd.Courses = append(cd.Courses, *course)
+		}
+	}
+	slices.SortStableFunc(cd.Courses, func(x, y CompareCourse) int { return cmp.Compare(y.Rounds, x.Rounds) })
+
+	return cd
+}
+
+// compareMaxRounds bounds how many of the first player's rounds are searched
+// for the ones played with the second.
+const compareMaxRounds = 2000
+
+// fetchSharedRounds pages through relay.gambit.golf for the 1501s both
+// pubkeys are tagged on as players. partial is true when it couldn't get to
+// the first player's oldest round.
+func fetchSharedRounds(ctx context.Context, a, b string) (rounds []*nostr.Event, partial bool) {
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for shared rounds")
+		return nil, true
+	}
+
+	// a filter can't ask for both p tags at once, so the second is checked here
+	filter := nostr.Filter{
+		Kinds: []int{1501},
+		Tags:  nostr.TagMap{"p": {a}},
+		Limit: 500,
+	}
+	err = pageEvents(ctx, relay.QueryEvents, filter, compareMaxRounds, func(evt *nostr.Event) {
+		round, _ := nip101g.ParseRound(*evt)
+		players := 0
+		for _, p := range round.Players {
+			if p.Role == "player" && (p.PubKey == a || p.PubKey == b) {
+				players++
+			}
+		}
+		if players == 2 {
+			rounds = append(rounds, evt)
+		}
+	})
+	if err != nil && !errors.Is(err, errPageLimit) {
+		log.Warn().Err(err).Msg("failed to query shared 1501s")
+	}
+	return rounds, err != nil
+}
+
+// latestRecordBy is the newest of the records published by pubkey.
+func latestRecordBy(records []*nostr.Event, pubkey string) *nostr.Event {
+	var latest *nostr.Event
+	for _, evt := range records {
+		if evt.PubKey == pubkey && (latest == nil || evt.CreatedAt > latest.CreatedAt) {
+			latest = evt
+		}
+	}
+	return latest
+}
+
+// Compare page template helper functions
+
+func compareStatRows(cd ComparePageData) []CompareStatRow {
+	a, b := cd.A, cd.B
+	lower := func(x, y float64) int {
+		switch {
+		case x < y:
+			return 1
+		case y < x:
+			return 2
+		}
+		return 0
+	}
+
+	rows := []CompareStatRow{
+		{Label: "Rounds", A: strconv.Itoa(a.Stats.Rounds), B: strconv.Itoa(b.Stats.Rounds)},
+	}
+
+	row := CompareStatRow{Label: "Handicap index", A: "-", B: "-"}
+	if a.Handicap.HasIndex {
+		row.A = formatHandicapIndex(a.Handicap.Index)
+	}
+	if b.Handicap.HasIndex {
+		row.B = formatHandicapIndex(b.Handicap.Index)
+	}
+	if a.Handicap.HasIndex && b.Handicap.HasIndex {
+		row.Better = lower(a.Handicap.Index, b.Handicap.Index)
+	}
+	rows = append(rows, row)
+
+	row = CompareStatRow{Label: "Scoring average", A: "-", B: "-"}
+	if a.Stats.AverageRounds > 0 {
+		row.A = strconv.FormatFloat(a.Stats.ScoringAverage, 'f', 1, 64)
+	}
+	if b.Stats.AverageRounds > 0 {
+		row.B = strconv.FormatFloat(b.Stats.ScoringAverage, 'f', 1, 64)
+	}
+	if a.Stats.AverageRounds > 0 && b.Stats.AverageRounds > 0 {
+		row.Better = lower(a.Stats.ScoringAverage, b.Stats.ScoringAverage)
+	}
+	rows = append(rows, row)
+
+	row = CompareStatRow{Label: "Best round", A: "-", B: "-"}
+	if a.Stats.Best != nil {
+		row.A = strconv.Itoa(a.Stats.Best.Total)
+	}
+	if b.Stats.Best != nil {
+		row.B = strconv.Itoa(b.Stats.Best.Total)
+	}
+	if a.Stats.Best != nil && b.Stats.Best != nil {
+		switch {
+		case golferRoundBetter(*a.Stats.Best, *b.Stats.Best):
+			row.Better = 1
+		case golferRoundBetter(*b.Stats.Best, *a.Stats.Best):
+			row.Better = 2
+		}
+	}
+	rows = append(rows, row)
+
+	rows = append(rows, CompareStatRow{
+		Label:  "Birdie rate",
+		A:      formatBirdieRate(a.Stats.BirdieRate),
+		B:      formatBirdieRate(b.Stats.BirdieRate),
+		Better: lower(b.Stats.BirdieRate, a.Stats.BirdieRate),
+	})
+
+	for _, par := range []int{3, 4, 5} {
+		pa, okA := findParAverage(a.Stats.ParAverages, par)
+		pb, okB := findParAverage(b.Stats.ParAverages, par)
+		if !okA && !okB {
+			continue
+		}
+		row := CompareStatRow{Label: fmt.Sprintf("Par %ds", par), A: "-", B: "-"}
+		if okA {
+			row.A = formatParAverage(pa)
+		}
+		if okB {
+			row.B = formatParAverage(pb)
+		}
+		if okA && okB {
+			row.Better = lower(pa.Average, pb.Average)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func findParAverage(averages []ParAverage, par int) (ParAverage, bool) {
+	for _, pa := range averages {
+		if pa.Par == par {
+			return pa, true
+		}
+	}
+	return ParAverage{}, false
+}
+
+func compareStatClass(row CompareStatRow, side int) string {
+	if row.Better == side {
+		return "px-3 py-2 text-center font-mono font-bold text-green-700"
+	}
+	return "px-3 py-2 text-center font-mono text-gray-900"
+}
+
+// compareStrokesGainedLabel says who beats whom by how much per round.
+func compareStrokesGainedLabel(cd ComparePageData) string {
+	gained := cd.StrokesGained
+	switch {
+	case gained > 0:
+		return fmt.Sprintf("%s gains %.1f strokes per round", cd.A.Player.DisplayName, gained)
+	case gained < 0:
+		return fmt.Sprintf("%s gains %.1f strokes per round", cd.B.Player.DisplayName, -gained)
+	}
+	return "Dead even per round"
+}
+
+func compareMarginLabel(cd ComparePageData, r CompareRound) string {
+	switch {
+	case r.TotalA < r.TotalB:
+		return cd.A.Player.DisplayName + " by " + strconv.Itoa(r.TotalB-r.TotalA)
+	case r.TotalB < r.TotalA:
+		return cd.B.Player.DisplayName + " by " + strconv.Itoa(r.TotalA-r.TotalB)
+	}
+	return "Tied"
+}
+
+// compareHoleClass highlights the side that won a hole more often.
+func compareHoleClass(h CompareHole, side int) string {
+	base := "border border-gray-800 px-1.5 py-1.5 text-sm font-mono"
+	if (side == 1 && h.WinsA > h.WinsB) || (side == 2 && h.WinsB > h.WinsA) {
+		return base + " font-bold text-green-700 bg-green-50"
+	}
+	return base + " text-gray-700"
+}
+
+func compareRecordLabel(cd ComparePageData) string {
+	label := fmt.Sprintf("%s %d – %d %s", cd.A.Player.DisplayName, cd.WinsA, cd.WinsB, cd.B.Player.DisplayName)
+	switch cd.Ties {
+	case 0:
+	case 1:
+		label += ", 1 tie"
+	default:
+		label += fmt.Sprintf(", %d ties", cd.Ties)
+	}
+	return label
+}
+
+func comparePartialLabel(cd ComparePageData) string {
+	return "Only the latest of " + cd.A.Player.DisplayName + "'s rounds could be searched, so older rounds together may be missing."
+}
+
+func compareOGDescription(cd ComparePageData) string {
+	if len(cd.Rounds) == 0 {
+		return "No finished rounds together yet"
+	}
+	return compareRecordLabel(cd) + ". " + compareStrokesGainedLabel(cd) + "."
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfCompare(t *testing.T) {
+	pars := []int{4, 3, 5, 4}
+	round := func(day int, course string, a, b []int) compareRound {
+		return compareRound{
+			round:  CompareRound{Date: time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC), CourseName: course},
+			course: course,
+			pars:   pars,
+			a:      a,
+			b:      b,
+		}
+	}
+	ann := ComparePlayer{Player: PlayerData{DisplayName: "ann"}}
+	bob := ComparePlayer{Player: PlayerData{DisplayName: "bob"}}
+
+	cd := computeComparison(ann, bob, []compareRound{
+		round(1, "Pines", []int{4, 3, 5, 4}, []int{5, 3, 6, 4}), // ann by 2
+		round(3, "Pines", []int{5, 4, 5, 0}, []int{4, 3, 5, 4}), // bob by 2 over the 3 holes both played
+		round(2, "Dunes", []int{4, 4, 4, 4}, []int{4, 4, 4, 4}), // tied
+		round(4, "Dunes", []int{0, 0, 0, 0}, []int{4, 4, 4, 4}), // nothing in common
+	})
+
+	assert.Equal(t, 1, cd.WinsA)
+	assert.Equal(t, 1, cd.WinsB)
+	assert.Equal(t, 1, cd.Ties)
+	assert.Equal(t, 0.0, cd.StrokesGained)
+	require.Len(t, cd.Rounds, 3)
+	assert.Equal(t, 3, cd.Rounds[0].Holes) // newest first
+	assert.Equal(t, "bob by 2", compareMarginLabel(cd, cd.Rounds[0]))
+	assert.Equal(t, "Tied", compareMarginLabel(cd, cd.Rounds[1]))
+	assert.Equal(t, "ann 1 – 1 bob, 1 tie", compareRecordLabel(cd))
+
+	require.Len(t, cd.Courses, 2)
+	pines := cd.Courses[0]
+	assert.Equal(t, "Pines", pines.Name)
+	assert.Equal(t, 2, pines.Rounds)
+	assert.Equal(t, CompareHole{WinsA: 1, WinsB: 1}, pines.Holes[0])
+	assert.Equal(t, CompareHole{WinsB: 1, Halved: 1}, pines.Holes[1])
+	assert.Equal(t, CompareHole{Halved: 1}, pines.Holes[3])
+	assert.Equal(t, 2, pines.WinsA)
+	assert.Equal(t, 2, pines.WinsB)
+	assert.Equal(t, 1, cd.Courses[1].Rounds)
+	assert.Equal(t, 4, cd.Courses[1].Halved)
+
+	cd = computeComparison(ann, bob, []compareRound{round(1, "Pines", []int{4, 3, 5, 4}, []int{5, 4, 6, 5})})
+	assert.Equal(t, "ann gains 4.0 strokes per round", compareStrokesGainedLabel(cd))
+
+	// better values are marked on the side that has them
+	ann.Stats = GolferStats{Rounds: 5, AverageRounds: 5, ScoringAverage: 84.2, BirdieRate: 0.05, ParAverages: []ParAverage{{Par: 3, Holes: 10, Average: 0.4}}}
+	bob.Stats = GolferStats{Rounds: 3, AverageRounds: 2, ScoringAverage: 88.5, BirdieRate: 0.08}
+	bob.Handicap = HandicapSummary{HasIndex: true, Index: 15.2}
+	rows := compareStatRows(ComparePageData{A: ann, B: bob})
+	require.Len(t, rows, 6)
+	assert.Equal(t, CompareStatRow{Label: "Handicap index", A: "-", B: "15.2"}, rows[1])
+	assert.Equal(t, 1, rows[2].Better)
+	assert.Equal(t, 2, rows[4].Better)
+	assert.Equal(t, CompareStatRow{Label: "Par 3s", A: "+0.40", B: "-"}, rows[5])
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// CourseActivity is what has been played on a course: its latest rounds, the
+// record from every tee, how it scores and who plays it most.
+type CourseActivity struct {
+	Rounds        int  // rounds found on the relay
+	Partial       bool // only the latest Rounds could be read, so the records are over those
+	Recent        []CourseRound
+	Records       []CourseRecord // the lowest complete round from each tee
+	AverageToPar  float64
+	AverageRounds int // complete rounds the average is taken over
+	Regulars      []CoursePlayer
+}
+
+// CourseRound is a round played on the course, with every player's score.
+type CourseRound struct {
+	Nevent string
+	Date   time.Time
+	TeeSet string
+	Scores []CourseScore // finished players first, complete rounds before partial ones, lowest score first
+}
+
+// CourseScore is a player's score in a round on the course. Total is 0 for a
+// player who hasn't published a final record.
+type CourseScore struct {
+	Player     PlayerData
+	Total      int
+	ScoreToPar int
+	Complete   bool // every hole of the course was played
+}
+
+// CourseRecord is the lowest complete round from a tee.
+type CourseRecord struct {
+	TeeSet string
+	CourseScore
+	Date   time.Time
+	Nevent string
+}
+
+// CoursePlayer is one of the players who play the course the most.
+type CoursePlayer struct {
+	Player PlayerData
+	Rounds int
+	Best   int // lowest complete round, 0 without one
+}
+
+// courseRound is a 1501 played on the course with the hole scores of the
+// players who finished it.
+type courseRound struct {
+	id      string
+	nevent  string
+	date    time.Time
+	teeSet  string
+	course  *nip101g.Course  // the revision the round was played on, nil to use the current one
+	players []string         // p tags with the player role
+	holes   map[string][]int // final hole scores by pubkey
+	teeSets map[string]string
+}
+
+const (
+	// courseRecentRounds is how many rounds the course page lists.
+	courseRecentRounds = 10
+	// courseRegulars is how many of the most frequent players are shown.
+	courseRegulars = 5
+	// courseMaxRounds bounds how many of a course's rounds are read.
+	courseMaxRounds = 5000
+)
+
+// buildCourseActivity loads the rounds played on the course at coord and the
+// profiles of the players it shows.
+func buildCourseActivity(ctx context.Context, course nip101g.Course, coord string) CourseActivity {
+	rounds, partial := loadCourseRounds(ctx, coord)
+	activity := computeCourseActivity(course, rounds)
+	activity.Partial = partial
+
+	var pubkeys []string
+	activity.eachPlayer(func(p *PlayerData) {
+		if !slices.Contains(pubkeys, p.PubkeyHex) {
+			pubkeys = append(pubkeys, p.PubkeyHex)
+		}
+	})
+	profiles := fetchPlayerProfiles(ctx, pubkeys)
+	activity.eachPlayer(func(p *PlayerData) { *p = profiles[p.PubkeyHex] })
+
+	return activity
+}
+
+// loadCourseRounds reads the 1501s played on the course at coord and the
+// final records published for them. partial is true when not all of the
+// rounds could be read.
+func loadCourseRounds(ctx context.Context, coord string) (rounds []courseRound, partial bool) {
+	events, partial := fetchCourse1501s(ctx, coord)
+	courses := make(courseCache)
+	rounds = make([]courseRound, 0, len(events))
+	byID := make(map[string]int, len(events))
+	for _, evt := range events {
+		round, _ := nip101g.ParseRound(*evt)
+		if round.CourseRef != coord {
+			continue
+		}
+		cr := courseRound{
+			id:      evt.ID,
+			date:    evt.CreatedAt.Time(),
+			teeSet:  round.TeeSet,
+			holes:   make(map[string][]int),
+			teeSets: make(map[string]string),
+		}
+		if t, err := time.Parse("2006-01-02", formatDate(round.Date)); err == nil {
+			cr.date = t
+		}
+		// a round is scored against the card as it was when it was played
+		cr.course = courses.get(ctx, coord, roundTime(round.Date, evt.CreatedAt.Time()))
+		cr.nevent, _ = nip19.EncodeEvent(evt.ID, []string{gambitRelay}, evt.PubKey)
+		for _, p := range round.Players {
+			if p.Role == "player" {
+				cr.players = append(cr.players, p.PubKey)
+			}
+		}
+		byID[evt.ID] = len(rounds)
+		rounds = append(rounds, cr)
+	}
+
+	ids := make([]string, 0, len(rounds))
+	for _, cr := range rounds {
+		ids = append(ids, cr.id)
+	}
+	var records []*nostr.Event
+	for chunk := range slices.Chunk(ids, roundIDsPerQuery) {
+		chunkRecords, _ := fetchTournamentScores(ctx, chunk)
+		records = append(records, chunkRecords...)
+	}
+	latest := make(map[[2]string]*nostr.Event)
+	for _, evt := range records {
+		record, _ := nip101g.ParseRoundRecord(*evt)
+		key := [2]string{record.RoundID, evt.PubKey}
+		if existing, ok := latest[key]; ok && existing.CreatedAt > evt.CreatedAt {
+			continue
+		}
+		latest[key] = evt
+		i, ok := byID[record.RoundID]
+		if !ok {
+			continue
+		}
+		rounds[i].holes[evt.PubKey] = scorecardHoles(record.Scorecard, record.HoleCount())
+		rounds[i].teeSets[evt.PubKey] = record.TeeSet
+	}
+
+	return rounds, partial
+}
+
+func computeCourseActivity(course nip101g.Course, rounds []courseRound) CourseActivity {
+	activity := CourseActivity{Rounds: len(rounds)}
+
+	slices.SortStableFunc(rounds, func(a, b courseRound) int { return b.date.Compare(a.date) })
+
+	records := make(map[string]*CourseRecord)
+	regulars := make(map[string]*CoursePlayer)
+	toPar := 0
+	for _, cr := range rounds {
+		pars := course.HolePars()
+		if cr.course != nil {
+			pars = cr.course.HolePars()
+		}
+		round := CourseRound{Nevent: cr.nevent, Date: cr.date, TeeSet: cr.teeSet}
+		for _, pk := range cr.players {
+			score := CourseScore{Player: PlayerData{PubkeyHex: pk}}
+			holes, finished := cr.holes[pk]
+			if finished {
+				score.Total, score.Complete = courseRoundTotal(holes, len(pars))
+				if score.Complete {
+					score.ScoreToPar = score.Total - sumSlice(pars, 0, len(pars))
+				}
+			}
+			round.Scores = append(round.Scores, score)
+			if !finished || score.Total == 0 {
+				continue
+			}
+
+			player, ok := regulars[pk]
+			if !ok {
+				player = &CoursePlayer{Player: score.Player}
+				regulars[pk] = player
+			}
+			player.Rounds++
+			if !score.Complete {
+				continue
+			}
+			if player.Best == 0 || score.Total < player.Best {
+				player.Best = score.Total
+			}
+			activity.AverageRounds++
+			toPar += score.ScoreToPar
+
+			tee := cr.teeSets[pk]
+			if tee == "" {
+				tee = cr.teeSet
+			}
+			// rounds go newest first, so an equalled record stays with the first to set it
+			if record, ok := records[tee]; !ok || score.Total <= record.Total {
+				records[tee] = &CourseRecord{TeeSet: tee, CourseScore: score, Date: cr.date, Nevent: cr.nevent}
+			}
+		}
+		slices.SortStableFunc(round.Scores, func(a, b CourseScore) int {
+			if (a.Total == 0) != (b.Total == 0) {
+				if a.Total == 0 {
+					return 1
+				}
+				return -1
+			}
+			if a.Complete != b.Complete {
+				if a.Complete {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Compare(a.Total, b.Total)
+		})
+		if len(activity.Recent) < courseRecentRounds {
+			activity.Recent = append(activity.Recent, round)
+		}
+	}
+
+	if activity.AverageRounds > 0 {
+		activity.AverageToPar = float64(toPar) / float64(activity.AverageRounds)
+	}
+
+	// records from the course's own tees first, in their order
+	for _, tee := range course.Tees {
+		if record, ok := records[tee.Name]; ok {
+			activity.Records = append(activity.Records, *record)
+			delete(records, tee.Name)
+		}
+	}
+	var others []CourseRecord
+	for _, record := range records {
+		others = append(others, *record)
+	}
+	slices.SortFunc(others, func(a, b CourseRecord) int { return cmp.Compare(a.TeeSet, b.TeeSet) })
+	activity.Records = append(activity.Records, others...)
+
+	for _, player := range regulars {
+		activity.Regulars = append(activity.Regulars, *player)
+	}
+	slices.SortFunc(activity.Regulars, func(a, b CoursePlayer) int {
+		if c := cmp.Compare(b.Rounds, a.Rounds); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Player.PubkeyHex, b.Player.PubkeyHex)
+	})
+	activity.Regulars = activity.Regulars[:min(len(activity.Regulars), courseRegulars)]
+
+	return activity
+}
+
+// courseRoundTotal adds up a player's strokes and reports whether they played
+// every one of the course's holes.
+func courseRoundTotal(holes []int, courseHoles int) (total int, complete bool) {
+	played := 0
+	for _, strokes := range holes {
+		if strokes > 0 {
+			total += strokes
+			played++
+		}
+	}
+	return total, courseHoles > 0 && played == courseHoles
+}
+
+// eachPlayer calls f on every player the activity shows.
+func (ca *CourseActivity) eachPlayer(f func(p *PlayerData)) {
+	for i := range ca.Recent {
+		for j := range ca.Recent[i].Scores {
+			f(&ca.Recent[i].Scores[j].Player)
+		}
+	}
+	for i := range ca.Records {
+		f(&ca.Records[i].Player)
+	}
+	for i := range ca.Regulars {
+		f(&ca.Regulars[i].Player)
+	}
+}
+
+// fetchCourse1501s pages through relay.gambit.golf for the 1501s played on
+// the course, newest first, up to courseMaxRounds. partial is true when it
+// couldn't get to the oldest one.
+func fetchCourse1501s(ctx context.Context, coord string) (events []*nostr.Event, partial bool) {
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for course 1501s")
+		return nil, true
+	}
+
+	filter := nostr.Filter{
+		Kinds: []int{1501},
+		Tags:  nostr.TagMap{"course": {coord}},
+		Limit: 500,
+	}
+	err = pageEvents(ctx, relay.QueryEvents, filter, courseMaxRounds, func(evt *nostr.Event) {
+		events = append(events, evt)
+	})
+	if err != nil && !errors.Is(err, errPageLimit) {
+		log.Warn().Err(err).Msg("failed to query course 1501s")
+	}
+	return events, err != nil
+}
+
+// Course page template helper functions
+
+func courseScoreDisplay(s CourseScore) string {
+	if s.Total == 0 {
+		return "-"
+	}
+	if !s.Complete {
+		return strconv.Itoa(s.Total) + "*"
+	}
+	return strconv.Itoa(s.Total)
+}
+
+func courseAverageToPar(ca CourseActivity) string {
+	return fmt.Sprintf("%+.1f", ca.AverageToPar)
+}
+
+func courseRecordTee(r CourseRecord) string {
+	if r.TeeSet == "" {
+		return "Unknown tee"
+	}
+	return r.TeeSet
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfCourseActivity(t *testing.T) {
+	course := nip101g.Course{
+		Holes: []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}, {Number: 3, Par: 5}}, // par 12
+		Tees:  []nip101g.CourseTee{{Name: "Blue"}, {Name: "White"}},
+	}
+	round := func(day int, id, tee string, holes map[string][]int, players ...string) courseRound {
+		cr := courseRound{
+			id:      id,
+			nevent:  "nevent-" + id,
+			date:    time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC),
+			teeSet:  tee,
+			players: players,
+			holes:   holes,
+			teeSets: make(map[string]string),
+		}
+		return cr
+	}
+
+	rounds := []courseRound{
+		round(1, "r1", "White", map[string][]int{"alice": {4, 3, 5}, "bob": {5, 4, 6}}, "alice", "bob"),
+		round(3, "r3", "White", map[string][]int{"alice": {4, 3, 5}, "bob": {5, 0, 6}}, "alice", "bob", "carol"),
+		round(2, "r2", "Blue", map[string][]int{"bob": {6, 4, 7}}, "bob"),
+		round(4, "r4", "Red", map[string][]int{"carol": {4, 2, 5}}, "carol"),
+	}
+	rounds[3].teeSets["carol"] = "Gold" // the record names the tee played
+
+	ca := computeCourseActivity(course, rounds)
+	assert.Equal(t, 4, ca.Rounds)
+
+	var recent []string
+	for _, r := range ca.Recent {
+		recent = append(recent, r.Nevent)
+	}
+	assert.Equal(t, []string{"nevent-r4", "nevent-r3", "nevent-r2", "nevent-r1"}, recent)
+
+	// finished players first, lowest first; an unfinished hole leaves the total incomplete
+	scores := ca.Recent[1].Scores
+	require.Len(t, scores, 3)
+	assert.Equal(t, "alice", scores[0].Player.PubkeyHex)
+	assert.Equal(t, "11*", courseScoreDisplay(scores[1]))
+	assert.Equal(t, "-", courseScoreDisplay(scores[2]))
+
+	// the course's tees in order, then the others; alice's equalled 12 stays with r1
+	require.Len(t, ca.Records, 3)
+	assert.Equal(t, "Blue", ca.Records[0].TeeSet)
+	assert.Equal(t, 17, ca.Records[0].Total)
+	assert.Equal(t, "White", ca.Records[1].TeeSet)
+	assert.Equal(t, "alice", ca.Records[1].Player.PubkeyHex)
+	assert.Equal(t, "nevent-r1", ca.Records[1].Nevent)
+	assert.Equal(t, 0, ca.Records[1].ScoreToPar)
+	assert.Equal(t, "Gold", ca.Records[2].TeeSet)
+	assert.Equal(t, -1, ca.Records[2].ScoreToPar)
+
+	// complete rounds only: 0, 3, 0, 5, -1
+	assert.Equal(t, 5, ca.AverageRounds)
+	assert.Equal(t, "+1.4", courseAverageToPar(ca))
+
+	require.Len(t, ca.Regulars, 3)
+	assert.Equal(t, CoursePlayer{Player: PlayerData{PubkeyHex: "bob"}, Rounds: 3, Best: 15}, ca.Regulars[0])
+	assert.Equal(t, "alice", ca.Regulars[1].Player.PubkeyHex)
+	assert.Equal(t, CoursePlayer{Player: PlayerData{PubkeyHex: "carol"}, Rounds: 1, Best: 11}, ca.Regulars[2])
+}
+
+func TestGolfCourseActivityRevisions(t *testing.T) {
+	// hole 3 was a par 4 before the course was re-rated to par 12
+	current := nip101g.Course{Holes: []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}, {Number: 3, Par: 5}}}
+	before := &nip101g.Course{Holes: []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}, {Number: 3, Par: 4}}}
+
+	rounds := []courseRound{
+		{id: "old", date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), teeSet: "White", course: before,
+			players: []string{"alice"}, holes: map[string][]int{"alice": {4, 3, 5}}},
+		{id: "new", date: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), teeSet: "White",
+			players: []string{"bob"}, holes: map[string][]int{"bob": {4, 3, 5}}},
+	}
+
+	ca := computeCourseActivity(current, rounds)
+	require.Len(t, ca.Recent, 2)
+	assert.Equal(t, 0, ca.Recent[0].Scores[0].ScoreToPar)
+	assert.Equal(t, 1, ca.Recent[1].Scores[0].ScoreToPar)
+	assert.Equal(t, "+0.5", courseAverageToPar(ca))
+}
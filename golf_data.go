@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fiatjaf/njump/nip101g"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
 )
@@ -43,6 +45,36 @@ type RoundPageData struct {
 	State           string // "live" | "final" | "waiting"
 	PlayersTotal    int
 	PlayersFinished int
+
+	// NIP-101g validation errors found on the 1501 and its score events
+	Problems []EventProblems
+
+	// Net scoring, when at least one player has a handicap and the tee is known
+	NetScoring bool
+
+	// Standings for the format declared by the 1501, empty for stroke play
+	Format RoundFormat
+
+	// Team leaderboard, when the 1501 groups players with team tags
+	Teams    []TeamScoreData
+	TeamRule string // how team scores are put together, e.g. "Best ball"
+
+	// Milestones the players who finished reached in this round
+	Achievements []RoundAchievements
+
+	// The tee played, from the course the 1501 references, nil when either
+	// isn't known. TeeUnknown is set when the course has no tee by that name.
+	Tee        *RoundTee
+	TeeUnknown bool
+}
+
+// RoundTee is the tee a round was played from, as its course defines it.
+type RoundTee struct {
+	Name       string
+	Rating     float64
+	Slope      int
+	HoleYards  []int // yards per hole (index 0 = hole 1), 0 when the course doesn't say
+	TotalYards int
 }
 
 type PlayerData struct {
@@ -51,6 +83,10 @@ type PlayerData struct {
 	Picture     string
 	Role        string // "player" | "bot"
 	Npub        string
+
+	// Handicap index from the kind 0 profile, if the player publishes one
+	HandicapIndex float64
+	HasHandicap   bool
 }
 
 type PlayerScoreData struct {
@@ -60,6 +96,30 @@ type PlayerScoreData struct {
 	ScoreToPar int
 	IsFinal    bool   // true if from 1502, false if from 31501
 	EventId    string // event ID for linking
+
+	// Net scoring, only when the player has a handicap index and the tee is known
+	HasHandicap    bool
+	HandicapIndex  float64
+	CourseHandicap int
+	NetHoleScores  []int // gross minus strokes received (index 0 = hole 1, 0 = not played)
+	NetTotal       int
+	NetToPar       int
+}
+
+// EventProblems groups the NIP-101g validation errors found on one event so the
+// debug section can say which event is malformed.
+type EventProblems struct {
+	Label   string // e.g. "Round", "Tournament" or the player's name
+	EventID string
+	Errors  nip101g.ValidationErrors
+}
+
+// appendProblems adds an EventProblems entry when errs is not empty.
+func appendProblems(list []EventProblems, label string, eventID string, errs nip101g.ValidationErrors) []EventProblems {
+	if len(errs) == 0 {
+		return list
+	}
+	return append(list, EventProblems{Label: label, EventID: eventID, Errors: errs})
 }
 
 // CommentData represents a kind 1111 comment on a round.
@@ -75,19 +135,36 @@ type CommentData struct {
 // buildRoundPageData constructs the full round page data from a 1501 event.
 // Fetches 1502s, 31501s, and profiles from relay.gambit.golf.
 func buildRoundPageData(ctx context.Context, event *nostr.Event, metadata *Kind1501Metadata) RoundPageData {
-	rpd := RoundPageData{
-		CourseName: metadata.CourseName,
-		TeeSet:     metadata.TeeSet,
-		Date:       metadata.Date,
-		HoleCount:  metadata.HoleCount,
-		HolePars:   metadata.HolePars,
-		TotalPar:   metadata.TotalPar,
-		Notes:      metadata.Notes,
+	return loadRoundBoard(ctx, event, metadata).pageData()
+}
+
+// roundBoard holds the events a round page is computed from. It is filled in
+// one go for a page load and then kept up to date event by event by the live
+// stream.
+type roundBoard struct {
+	event         *nostr.Event
+	metadata      *Kind1501Metadata
+	playerPubkeys []string          // p-tags, then comment authors
+	playerRoles   map[string]string // pubkey -> role
+	finalByAuthor map[string]*nostr.Event
+	liveByAuthor  map[string]*nostr.Event
+	comments      []CommentData
+	profiles      map[string]PlayerData
+	course        nip101g.Course // zero value when the course couldn't be fetched
+	hasCourse     bool
+}
+
+func loadRoundBoard(ctx context.Context, event *nostr.Event, metadata *Kind1501Metadata) *roundBoard {
+	b := &roundBoard{
+		event:         event,
+		metadata:      metadata,
+		playerRoles:   make(map[string]string),
+		finalByAuthor: make(map[string]*nostr.Event),
+		liveByAuthor:  make(map[string]*nostr.Event),
+		profiles:      make(map[string]PlayerData),
 	}
 
 	// Parse players from p-tags with roles
-	var playerPubkeys []string
-	playerRoles := make(map[string]string) // pubkey -> role
 	for _, tag := range event.Tags {
 		if len(tag) >= 2 && tag[0] == "p" {
 			pk := tag[1]
@@ -95,96 +172,199 @@ func buildRoundPageData(ctx context.Context, event *nostr.Event, metadata *Kind1
 			if len(tag) >= 4 {
 				role = tag[3]
 			}
-			playerPubkeys = append(playerPubkeys, pk)
-			playerRoles[pk] = role
-		}
-	}
-	rpd.PlayersTotal = 0
-	for _, role := range playerRoles {
-		if role == "player" {
-			rpd.PlayersTotal++
+			b.playerPubkeys = append(b.playerPubkeys, pk)
+			b.playerRoles[pk] = role
 		}
 	}
 
-	rpd.EventID = event.ID
-	rpd.AuthorPubkey = event.PubKey
+	// Fetch the course as it was when the round was played, for tee ratings
+	// and stroke indexes
+	if metadata.CourseRef != "" {
+		b.course, b.hasCourse = fetchCourseAt(ctx, metadata.CourseRef, roundTime(metadata.Date, event.CreatedAt.Time()))
+	}
 
 	// Fetch 1502s and 31501s from relay
 	records, livecards := fetchScores(ctx, event.ID)
+	for _, evt := range records {
+		b.addScore(evt)
+	}
+	for _, evt := range livecards {
+		b.addScore(evt)
+	}
 
 	// Fetch kind 1111 comments
-	rpd.Comments = fetchComments(ctx, event.ID)
+	b.comments = fetchComments(ctx, event.ID)
 
 	// Resolve player profiles (include comment authors)
-	commentPubkeys := make(map[string]bool)
-	for _, c := range rpd.Comments {
-		commentPubkeys[c.Author.PubkeyHex] = true
-	}
-	for pk := range commentPubkeys {
-		found := false
-		for _, existing := range playerPubkeys {
-			if existing == pk {
-				found = true
-				break
-			}
-		}
-		if !found {
-			playerPubkeys = append(playerPubkeys, pk)
+	for _, c := range b.comments {
+		b.addPubkey(c.Author.PubkeyHex)
+	}
+	b.fetchMissingProfiles(ctx)
+
+	// Enrich comment authors with resolved profiles
+	for i, c := range b.comments {
+		if pd, ok := b.profiles[c.Author.PubkeyHex]; ok {
+			b.comments[i].Author = pd
 		}
 	}
-	profiles := fetchPlayerProfiles(ctx, playerPubkeys)
 
-	// Build player list
-	for _, pk := range playerPubkeys {
-		pd := profiles[pk]
-		pd.Role = playerRoles[pk]
-		rpd.Players = append(rpd.Players, pd)
+	return b
+}
+
+// addPubkey adds someone who isn't tagged on the round (e.g. a commenter) to
+// the list of profiles to resolve.
+func (b *roundBoard) addPubkey(pk string) {
+	if !slices.Contains(b.playerPubkeys, pk) {
+		b.playerPubkeys = append(b.playerPubkeys, pk)
 	}
+}
 
-	// Enrich comment authors with resolved profiles
-	for i, c := range rpd.Comments {
-		if pd, ok := profiles[c.Author.PubkeyHex]; ok {
-			rpd.Comments[i].Author = pd
+// addScore records a 1502 or 31501 under its author, keeping the newest one.
+// It reports whether the event changed the board.
+func (b *roundBoard) addScore(evt *nostr.Event) bool {
+	byAuthor := b.liveByAuthor
+	if evt.Kind == 1502 {
+		byAuthor = b.finalByAuthor
+	}
+	if existing, ok := byAuthor[evt.PubKey]; ok && existing.CreatedAt > evt.CreatedAt {
+		return false
+	}
+	byAuthor[evt.PubKey] = evt
+	return true
+}
+
+// addComment records a kind 1111 comment and returns it with its author resolved.
+// It reports false for a comment that is already on the board.
+func (b *roundBoard) addComment(ctx context.Context, evt *nostr.Event) (CommentData, bool) {
+	for _, c := range b.comments {
+		if c.EventId == evt.ID {
+			return CommentData{}, false
 		}
 	}
 
-	// Build player scores: prefer 1502 (final) over 31501 (live)
-	finalByAuthor := make(map[string]*nostr.Event)
-	for _, rec := range records {
-		finalByAuthor[rec.PubKey] = rec
+	cd := parseComment(evt)
+	b.addPubkey(evt.PubKey)
+	b.fetchMissingProfiles(ctx)
+	cd.Author = b.profiles[evt.PubKey]
+	b.comments = append(b.comments, cd)
+	return cd, true
+}
+
+// fetchMissingProfiles loads profiles for players that don't have one yet.
+func (b *roundBoard) fetchMissingProfiles(ctx context.Context) {
+	var missing []string
+	for _, pk := range b.playerPubkeys {
+		if _, ok := b.profiles[pk]; !ok {
+			missing = append(missing, pk)
+		}
 	}
-	liveByAuthor := make(map[string]*nostr.Event)
-	for _, lc := range livecards {
-		liveByAuthor[lc.PubKey] = lc
+	if len(missing) == 0 {
+		return
 	}
+	for pk, pd := range fetchPlayerProfiles(ctx, missing) {
+		b.profiles[pk] = pd
+	}
+}
 
-	for _, pk := range playerPubkeys {
-		role := playerRoles[pk]
+// pageData assembles the round page from the events on the board.
+func (b *roundBoard) pageData() RoundPageData {
+	rpd := RoundPageData{
+		CourseName:   b.metadata.CourseName,
+		TeeSet:       b.metadata.TeeSet,
+		Date:         b.metadata.Date,
+		HoleCount:    b.metadata.HoleCount,
+		HolePars:     b.metadata.HolePars,
+		TotalPar:     b.metadata.TotalPar,
+		Notes:        b.metadata.Notes,
+		EventID:      b.event.ID,
+		AuthorPubkey: b.event.PubKey,
+		Comments:     b.comments,
+	}
+
+	for _, role := range b.playerRoles {
+		if role == "player" {
+			rpd.PlayersTotal++
+		}
+	}
+
+	// Build player list
+	for _, pk := range b.playerPubkeys {
+		pd := b.profiles[pk]
+		pd.Role = b.playerRoles[pk]
+		rpd.Players = append(rpd.Players, pd)
+	}
+
+	// Build player scores: prefer 1502 (final) over 31501 (live)
+	for _, pk := range b.playerPubkeys {
+		role := b.playerRoles[pk]
 		if role == "bot" {
 			continue // hide bot scores per resolved decision
 		}
 
-		pd := profiles[pk]
+		pd := b.profiles[pk]
 		pd.Role = role
 
-		if finalEvt, ok := finalByAuthor[pk]; ok {
+		if finalEvt, ok := b.finalByAuthor[pk]; ok {
 			// Final record
-			psd := parseScoreEvent(finalEvt, pd, rpd.HolePars, rpd.TotalPar)
+			psd, problems := parseScoreEvent(finalEvt, pd, rpd.HolePars, rpd.TotalPar)
 			psd.IsFinal = true
 			rpd.PlayerScores = append(rpd.PlayerScores, psd)
 			rpd.PlayersFinished++
-		} else if liveEvt, ok := liveByAuthor[pk]; ok {
+			rpd.Problems = appendProblems(rpd.Problems, pd.DisplayName+" (final record)", finalEvt.ID, problems)
+		} else if liveEvt, ok := b.liveByAuthor[pk]; ok {
 			// Live scorecard
-			psd := parseScoreEvent(liveEvt, pd, rpd.HolePars, rpd.TotalPar)
+			psd, problems := parseScoreEvent(liveEvt, pd, rpd.HolePars, rpd.TotalPar)
 			psd.IsFinal = false
 			rpd.PlayerScores = append(rpd.PlayerScores, psd)
+			rpd.Problems = appendProblems(rpd.Problems, pd.DisplayName+" (live scorecard)", liveEvt.ID, problems)
+		}
+	}
+
+	// Yardages and rating of the tee played
+	if b.hasCourse {
+		rpd.Tee, rpd.TeeUnknown = newRoundTee(b.course, rpd.TeeSet)
+	}
+
+	// Net scores from the handicap index on the 1501, falling back to the profile
+	if hc, ok := newHandicapCourse(b.course, rpd.TeeSet); ok {
+		for i, psd := range rpd.PlayerScores {
+			index, ok := b.metadata.Handicaps[psd.Player.PubkeyHex]
+			if !ok {
+				index, ok = psd.Player.HandicapIndex, psd.Player.HasHandicap
+			}
+			if !ok {
+				continue
+			}
+			ns := hc.netScoreFor(index, psd.HoleScores, psd.Total)
+			rpd.PlayerScores[i].HasHandicap = true
+			rpd.PlayerScores[i].HandicapIndex = index
+			rpd.PlayerScores[i].CourseHandicap = ns.CourseHandicap
+			rpd.PlayerScores[i].NetHoleScores = ns.HoleScores
+			rpd.PlayerScores[i].NetTotal = ns.Total
+			rpd.PlayerScores[i].NetToPar = psd.ScoreToPar - ns.Received
+			rpd.NetScoring = true
 		}
 	}
 
+	if len(b.metadata.Teams) > 0 {
+		holes := make(map[string][]int, len(rpd.PlayerScores))
+		for _, ps := range rpd.PlayerScores {
+			holes[ps.Player.PubkeyHex] = ps.HoleScores
+		}
+		holeCount := len(rpd.HolePars)
+		if holeCount == 0 {
+			holeCount = nip101g.DefaultHoleCount
+		}
+		rpd.Teams = computeTeamScores(b.metadata.Teams, b.metadata.Format, b.profiles, holes, rpd.HolePars, holeCount)
+		rpd.TeamRule = teamRuleLabel(b.metadata.Format)
+	}
+
+	rpd.Format = computeRoundFormat(b.metadata.Format, rpd)
+
 	// Determine round state
 	if rpd.PlayersFinished == rpd.PlayersTotal && rpd.PlayersTotal > 0 {
 		rpd.State = "final"
-	} else if rpd.PlayersFinished > 0 || len(livecards) > 0 {
+	} else if rpd.PlayersFinished > 0 || len(b.liveByAuthor) > 0 {
 		rpd.State = "live"
 	} else {
 		rpd.State = "waiting"
@@ -193,47 +373,381 @@ func buildRoundPageData(ctx context.Context, event *nostr.Event, metadata *Kind1
 	return rpd
 }
 
+// RoundFormat is the outcome of the scoring format a 1501 declares with a
+// "format" tag. Stroke play rounds leave it empty.
+type RoundFormat struct {
+	Format string // one of the nip101g.Format* values
+	Name   string // e.g. "Modified Stableford"
+	Net    bool   // computed from net hole scores, every player has a handicap
+	Note   string // why the format can't be computed, e.g. "Needs exactly two players"
+
+	Standings     []FormatStanding // Stableford points or skins won, in player order
+	Matches       []MatchResult    // match play: the match; Nassau: front 9, back 9 and overall
+	Skins         []SkinHole       // skins: one entry per hole
+	BestBall      []int            // best ball: the group's lowest score per hole (0 = not played)
+	BestBallTotal int
+	BestBallToPar int
+}
+
+// FormatStanding is one player's Stableford points or skins won.
+type FormatStanding struct {
+	Player  PlayerData
+	Points  int
+	Display string // "36 pts", "3 skins"
+	Rank    string // "1", "T2"
+}
+
+// MatchResult is the state of a two-player match over a stretch of holes.
+type MatchResult struct {
+	Label    string // "Match", "Front 9", "Back 9" or "Overall"
+	Status   string // "Alice 2 UP thru 14", "All square thru 3", "Alice wins 3 & 2"
+	Finished bool
+	Winner   int // 1 when a won, 2 when b won, 0 while going on or when halved
+}
+
+// SkinHole is how the skin on one hole went. Holes that can't be decided yet
+// (a score is missing here or on an earlier hole) have no Value.
+type SkinHole struct {
+	Hole    int
+	Value   int        // skins at stake, carryovers included
+	Winner  PlayerData // zero value when nobody won it
+	Carried bool       // tied, the skins carry over to the next hole
+}
+
+var formatNames = map[string]string{
+	nip101g.FormatStableford:         "Stableford",
+	nip101g.FormatModifiedStableford: "Modified Stableford",
+	nip101g.FormatMatchPlay:          "Match Play",
+	nip101g.FormatSkins:              "Skins",
+	nip101g.FormatNassau:             "Nassau",
+	nip101g.FormatBestBall:           "Best Ball",
+	nip101g.FormatScramble:           "Scramble",
+	nip101g.FormatAlternateShot:      "Alternate Shot",
+}
+
+// computeRoundFormat scores the round by its declared format. Net scores are
+// used when every player has one, otherwise everyone plays off gross.
+func computeRoundFormat(format string, rpd RoundPageData) RoundFormat {
+	if format == "" || format == nip101g.FormatStroke {
+		return RoundFormat{}
+	}
+	teamFormat := format == nip101g.FormatBestBall || format == nip101g.FormatScramble || format == nip101g.FormatAlternateShot
+	if teamFormat && len(rpd.Teams) > 0 {
+		return RoundFormat{} // the team leaderboard shows it
+	}
+	rf := RoundFormat{Format: format, Name: formatNames[format]}
+	if len(rpd.PlayerScores) == 0 {
+		return rf
+	}
+
+	rf.Net = true
+	for _, ps := range rpd.PlayerScores {
+		if !ps.HasHandicap {
+			rf.Net = false
+		}
+	}
+	holes := make([][]int, len(rpd.PlayerScores))
+	for i, ps := range rpd.PlayerScores {
+		holes[i] = ps.HoleScores
+		if rf.Net {
+			holes[i] = ps.NetHoleScores
+		}
+	}
+
+	switch format {
+	case nip101g.FormatStableford, nip101g.FormatModifiedStableford:
+		if len(rpd.HolePars) == 0 {
+			rf.Note = "Needs the hole pars"
+			break
+		}
+		points := make([]int, len(holes))
+		for i, hs := range holes {
+			points[i] = stablefordPoints(format, hs, rpd.HolePars)
+		}
+		rf.Standings = formatStandings(rpd.PlayerScores, points, func(p int) string {
+			return strconv.Itoa(p) + " pts"
+		})
+	case nip101g.FormatMatchPlay, nip101g.FormatNassau:
+		if len(holes) != 2 {
+			rf.Note = "Needs exactly two players"
+			break
+		}
+		a, b := rpd.PlayerScores[0].Player, rpd.PlayerScores[1].Player
+		if format == nip101g.FormatMatchPlay {
+			rf.Matches = []MatchResult{matchResult("Match", a, b, holes[0], holes[1], 0, len(holes[0]))}
+			break
+		}
+		if len(holes[0]) != 18 {
+			rf.Note = "Needs an 18 hole round"
+			break
+		}
+		rf.Matches = []MatchResult{
+			matchResult("Front 9", a, b, holes[0], holes[1], 0, 9),
+			matchResult("Back 9", a, b, holes[0], holes[1], 9, 18),
+			matchResult("Overall", a, b, holes[0], holes[1], 0, 18),
+		}
+	case nip101g.FormatSkins:
+		if len(holes) < 2 {
+			rf.Note = "Needs at least two players"
+			break
+		}
+		var won []int
+		rf.Skins, won = skinsResults(rpd.PlayerScores, holes)
+		rf.Standings = formatStandings(rpd.PlayerScores, won, func(n int) string {
+			if n == 1 {
+				return "1 skin"
+			}
+			return strconv.Itoa(n) + " skins"
+		})
+	case nip101g.FormatScramble, nip101g.FormatAlternateShot:
+		rf.Note = "Needs players grouped with team tags"
+	case nip101g.FormatBestBall:
+		rf.BestBall = bestBallScores(holes)
+		for i, s := range rf.BestBall {
+			if s == 0 {
+				continue
+			}
+			rf.BestBallTotal += s
+			if i < len(rpd.HolePars) {
+				rf.BestBallToPar += s - rpd.HolePars[i]
+			}
+		}
+	}
+	return rf
+}
+
+// stablefordPoints adds up the points for every hole played. Stableford gives
+// 2 points for par and one more for each stroke under it, down to 0; the
+// modified (PGA Tour) scale goes from +8 for an albatross to -3.
+func stablefordPoints(format string, holeScores []int, pars []int) int {
+	total := 0
+	for i, s := range holeScores {
+		if s == 0 || i >= len(pars) || pars[i] == 0 {
+			continue
+		}
+		diff := s - pars[i]
+		if format != nip101g.FormatModifiedStableford {
+			total += max(0, 2-diff)
+			continue
+		}
+		switch {
+		case diff <= -3:
+			total += 8
+		case diff == -2:
+			total += 5
+		case diff == -1:
+			total += 2
+		case diff == 1:
+			total -= 1
+		case diff >= 2:
+			total -= 3
+		}
+	}
+	return total
+}
+
+// formatStandings pairs points with players (in their scorecard order) and
+// ranks them, most points first.
+func formatStandings(scores []PlayerScoreData, points []int, display func(int) string) []FormatStanding {
+	standings := make([]FormatStanding, len(scores))
+	for i, ps := range scores {
+		better, tied := 0, false
+		for j, p := range points {
+			if p > points[i] {
+				better++
+			} else if p == points[i] && j != i {
+				tied = true
+			}
+		}
+		rank := strconv.Itoa(better + 1)
+		if tied {
+			rank = "T" + rank
+		}
+		standings[i] = FormatStanding{Player: ps.Player, Points: points[i], Display: display(points[i]), Rank: rank}
+	}
+	return standings
+}
+
+// matchResult plays a against b hole by hole over holes [start, end), up to
+// the first hole one of them hasn't scored or until the match is decided.
+func matchResult(label string, a, b PlayerData, as, bs []int, start, end int) MatchResult {
+	up, thru := 0, start
+	for i := start; i < end && i < len(as) && i < len(bs); i++ {
+		if as[i] == 0 || bs[i] == 0 {
+			break
+		}
+		if as[i] < bs[i] {
+			up++
+		} else if bs[i] < as[i] {
+			up--
+		}
+		thru = i + 1
+		if up > end-thru || -up > end-thru {
+			break // won with holes to spare
+		}
+	}
+
+	leader, lead := a.DisplayName, up
+	if up < 0 {
+		leader, lead = b.DisplayName, -up
+	}
+	remaining := end - thru
+
+	res := MatchResult{Label: label}
+	switch {
+	case thru == start:
+		res.Status = "Not started"
+	case lead > remaining:
+		res.Finished = true
+		res.Winner = 1
+		if up < 0 {
+			res.Winner = 2
+		}
+		if remaining == 0 {
+			res.Status = fmt.Sprintf("%s wins %d UP", leader, lead)
+		} else {
+			res.Status = fmt.Sprintf("%s wins %d & %d", leader, lead, remaining)
+		}
+	case remaining == 0:
+		res.Finished = true
+		res.Status = "Halved"
+	case lead == 0:
+		res.Status = fmt.Sprintf("All square thru %d", thru)
+	default:
+		res.Status = fmt.Sprintf("%s %d UP thru %d", leader, lead, thru)
+	}
+	return res
+}
+
+// skinsResults awards each hole to the single lowest score; ties carry the
+// skin over to the next hole. It also returns the skins won per player.
+func skinsResults(scores []PlayerScoreData, holes [][]int) ([]SkinHole, []int) {
+	won := make([]int, len(holes))
+	skins := make([]SkinHole, len(holes[0]))
+	carry := 0
+	decided := true
+	for h := range skins {
+		skins[h].Hole = h + 1
+		best, winner := 0, -1
+		for i := 0; decided && i < len(holes); i++ {
+			s := 0
+			if h < len(holes[i]) {
+				s = holes[i][h]
+			}
+			switch {
+			case s == 0:
+				decided = false // carryovers can't skip a hole
+			case best == 0 || s < best:
+				best, winner = s, i
+			case s == best:
+				winner = -1
+			}
+		}
+		if !decided {
+			continue
+		}
+
+		skins[h].Value = carry + 1
+		if winner < 0 {
+			skins[h].Carried = true
+			carry++
+			continue
+		}
+		skins[h].Winner = scores[winner].Player
+		won[winner] += skins[h].Value
+		carry = 0
+	}
+	return skins, won
+}
+
+// bestBallScores is the lowest score on each hole among all players.
+func bestBallScores(holes [][]int) []int {
+	best := make([]int, len(holes[0]))
+	for _, hs := range holes {
+		for i, s := range hs {
+			if i < len(best) && s > 0 && (best[i] == 0 || s < best[i]) {
+				best[i] = s
+			}
+		}
+	}
+	return best
+}
+
+// summary is the format's standings in one line, for the OG description.
+func (rf RoundFormat) summary() string {
+	name := rf.Name
+	if rf.Net {
+		name += " (net)"
+	}
+	switch {
+	case rf.Note != "":
+		return name + ": " + rf.Note
+	case len(rf.Matches) == 1:
+		return name + ": " + rf.Matches[0].Status
+	case len(rf.Matches) > 1:
+		parts := make([]string, len(rf.Matches))
+		for i, m := range rf.Matches {
+			parts[i] = m.Label + " " + m.Status
+		}
+		return name + ": " + strings.Join(parts, ", ")
+	case len(rf.Standings) > 0:
+		standings := slices.Clone(rf.Standings)
+		sort.SliceStable(standings, func(i, j int) bool { return standings[i].Points > standings[j].Points })
+		parts := make([]string, len(standings))
+		for i, st := range standings {
+			parts[i] = st.Player.DisplayName + " " + st.Display
+		}
+		return name + ": " + strings.Join(parts, ", ")
+	case rf.BestBallTotal > 0:
+		return fmt.Sprintf("%s: %d (%s)", name, rf.BestBallTotal, formatScoreToPar(rf.BestBallToPar))
+	}
+	return name
+}
+
 // parseScoreEvent extracts hole scores from a 1502 or 31501 event.
-func parseScoreEvent(evt *nostr.Event, player PlayerData, holePars []int, totalPar int) PlayerScoreData {
+func parseScoreEvent(evt *nostr.Event, player PlayerData, holePars []int, totalPar int) (PlayerScoreData, nip101g.ValidationErrors) {
 	psd := PlayerScoreData{
 		Player:  player,
 		EventId: evt.ID,
 	}
 
+	var scorecard nip101g.Scorecard
+	var problems nip101g.ValidationErrors
+	if evt.Kind == nip101g.KindRoundRecord {
+		var record nip101g.RoundRecord
+		record, problems = nip101g.ParseRoundRecord(*evt)
+		scorecard = record.Scorecard
+	} else {
+		var live nip101g.LiveScorecard
+		live, problems = nip101g.ParseLiveScorecard(*evt)
+		scorecard = live.Scorecard
+	}
+
 	// Initialize hole scores (0 = not played)
 	holeCount := len(holePars)
 	if holeCount == 0 {
-		holeCount = 18
+		holeCount = nip101g.DefaultHoleCount
 	}
 	psd.HoleScores = make([]int, holeCount)
 
-	for _, tag := range evt.Tags {
-		if len(tag) >= 3 && tag[0] == "score" {
-			hole, err := strconv.Atoi(tag[1])
-			if err != nil || hole < 1 || hole > holeCount {
-				continue
-			}
-			score, err := strconv.Atoi(tag[2])
-			if err != nil {
-				continue
-			}
-			psd.HoleScores[hole-1] = score
-			psd.Total += score
+	for _, hs := range scorecard.Scores {
+		if hs.Hole > holeCount {
+			continue
 		}
+		psd.HoleScores[hs.Hole-1] = hs.Strokes
+		psd.Total += hs.Strokes
 	}
 
-	// Check for explicit total tag (1502s have this)
-	if totalTag := evt.Tags.GetFirst([]string{"total", ""}); totalTag != nil && len(*totalTag) >= 2 {
-		if t, err := strconv.Atoi((*totalTag)[1]); err == nil {
-			psd.Total = t
-		}
+	// Explicit total tag (1502s have this) takes precedence
+	if scorecard.Total > 0 {
+		psd.Total = scorecard.Total
 	}
 
 	if totalPar > 0 && psd.Total > 0 {
 		psd.ScoreToPar = psd.Total - totalPar
 	}
 
-	return psd
+	return psd, problems
 }
 
 // fetchScores queries relay.gambit.golf for 1502s and 31501s referencing a 1501 event.
@@ -280,6 +794,167 @@ func fetchScores(ctx context.Context, initiationEventID string) (records []*nost
 	return
 }
 
+// fetchCourse fetches and parses the kind 33501 event at the course coordinate.
+// Returns false if unavailable.
+func fetchCourse(ctx context.Context, courseCoord string) (nip101g.Course, bool) {
+	courseEvt := fetchCourseEvent(ctx, courseCoord)
+	if courseEvt == nil {
+		return nip101g.Course{}, false
+	}
+
+	course, _ := nip101g.ParseCourse(*courseEvt)
+	return course, true
+}
+
+// fetchCourseEvent fetches the latest kind 33501 event at the course
+// coordinate and keeps it as one of the course's revisions. Returns nil if
+// unavailable.
+func fetchCourseEvent(ctx context.Context, courseCoord string) *nostr.Event {
+	// Parse "33501:<pubkey>:<d>"
+	parts := strings.Split(courseCoord, ":")
+	if len(parts) < 3 || parts[0] != "33501" {
+		return nil
+	}
+	authorPK := parts[1]
+	dTag := parts[2]
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		return nil
+	}
+
+	filter := nostr.Filter{
+		Kinds:   []int{33501},
+		Authors: []string{authorPK},
+		Tags:    nostr.TagMap{"d": {dTag}},
+		Limit:   1,
+	}
+
+	ch, err := relay.QueryEvents(ctx, filter)
+	if err != nil {
+		return nil
+	}
+
+	var courseEvt *nostr.Event
+	for evt := range ch {
+		courseEvt = evt
+		break
+	}
+	if courseEvt != nil {
+		storeCourseRevision(ctx, courseEvt)
+	}
+	return courseEvt
+}
+
+// newRoundTee looks up the tee a round names on its course, with the yardage
+// of every hole from it. It reports whether the round names a tee the course
+// doesn't have.
+func newRoundTee(course nip101g.Course, teeName string) (tee *RoundTee, unknown bool) {
+	t, ok := findCourseTee(course, teeName)
+	if !ok {
+		return nil, teeName != ""
+	}
+
+	tee = &RoundTee{
+		Name:      t.Name,
+		Rating:    t.Rating,
+		Slope:     t.Slope,
+		HoleYards: make([]int, len(course.HolePars())),
+	}
+	for _, y := range course.Yardages {
+		if y.Tee == t.Name && y.Hole >= 1 && y.Hole <= len(tee.HoleYards) {
+			tee.HoleYards[y.Hole-1] = y.Yards
+			tee.TotalYards += y.Yards
+		}
+	}
+	return tee, false
+}
+
+// courseCache keeps the revisions of the courses fetched while going through
+// a player's rounds, oldest first, nil for the ones that couldn't be found.
+type courseCache map[string][]*nostr.Event
+
+// get returns the course at the coordinate as it was at the given time, nil
+// when it couldn't be found.
+func (cc courseCache) get(ctx context.Context, courseCoord string, at time.Time) *nip101g.Course {
+	revisions, ok := cc[courseCoord]
+	if !ok {
+		revisions = fetchCourseRevisions(ctx, courseCoord)
+		cc[courseCoord] = revisions
+	}
+	evt := courseRevisionAt(revisions, at)
+	if evt == nil {
+		return nil
+	}
+	course, _ := nip101g.ParseCourse(*evt)
+	return &course
+}
+
+// prefetch fetches the courses at the coordinates that aren't in the cache
+// yet in a single query, instead of one query per course in get.
+func (cc courseCache) prefetch(ctx context.Context, courseCoords []string) {
+	var authors, dTags []string
+	missing := make(map[string]bool)
+	for _, coord := range courseCoords {
+		parts := strings.Split(coord, ":")
+		if _, ok := cc[coord]; ok || missing[coord] || len(parts) < 3 || parts[0] != "33501" {
+			continue
+		}
+		missing[coord] = true
+		if !slices.Contains(authors, parts[1]) {
+			authors = append(authors, parts[1])
+		}
+		if !slices.Contains(dTags, parts[2]) {
+			dTags = append(dTags, parts[2])
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for courses")
+		return
+	}
+	ch, err := relay.QueryEvents(ctx, nostr.Filter{
+		Kinds:   []int{nip101g.KindCourse},
+		Authors: authors,
+		Tags:    nostr.TagMap{"d": dTags},
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to query courses")
+		return
+	}
+
+	// every author is matched with every d tag, so only the coordinates
+	// asked for are kept
+	latest := make(map[string]*nostr.Event, len(missing))
+	for evt := range ch {
+		coord := courseCoordinate(evt)
+		if !missing[coord] {
+			continue
+		}
+		storeCourseRevision(ctx, evt)
+		if prev, ok := latest[coord]; !ok || evt.CreatedAt > prev.CreatedAt {
+			latest[coord] = evt
+		}
+	}
+	for coord := range missing {
+		revisions := courseRevisionEvents(ctx, coord)
+		if len(revisions) == 0 && latest[coord] != nil {
+			revisions = []*nostr.Event{latest[coord]}
+		}
+		cc[coord] = revisions
+	}
+}
+
 // gambitBotPubkey is the Gambit Bot's hex pubkey for identifying pinned comments.
 const gambitBotPubkey = "c8322d575eaeebe322e61704ed2fbc33dc40a59536fede2261d805e6070bd6dd"
 
@@ -316,40 +991,7 @@ func fetchComments(ctx context.Context, initiationEventID string) []CommentData
 		}
 		seen[evt.ID] = true
 
-		cd := CommentData{
-			Author: PlayerData{
-				PubkeyHex: evt.PubKey,
-			},
-			Content:   evt.Content,
-			CreatedAt: evt.CreatedAt.Time(),
-			EventId:   evt.ID,
-			Type:      "banter",
-		}
-
-		// Check for tags: pinned, label, type
-		for _, tag := range evt.Tags {
-			if len(tag) >= 2 {
-				switch tag[0] {
-				case "pinned":
-					if tag[1] == "true" {
-						cd.IsPinned = true
-					}
-				case "label":
-					// "Settlement" -> "settlement" for consistency
-					cd.Type = strings.ToLower(tag[1])
-				case "type":
-					// Legacy tag support
-					cd.Type = tag[1]
-				}
-			}
-		}
-
-		// Also pin settlement/summary by type (fallback for old events)
-		if cd.Type == "settlement" || cd.Type == "summary" {
-			cd.IsPinned = true
-		}
-
-		comments = append(comments, cd)
+		comments = append(comments, parseComment(evt))
 	}
 
 	// Sort: pinned first, then by time ascending
@@ -363,6 +1005,45 @@ func fetchComments(ctx context.Context, initiationEventID string) []CommentData
 	return comments
 }
 
+// parseComment reads a kind 1111 comment. The author is only identified by
+// pubkey, callers fill in the profile.
+func parseComment(evt *nostr.Event) CommentData {
+	cd := CommentData{
+		Author: PlayerData{
+			PubkeyHex: evt.PubKey,
+		},
+		Content:   evt.Content,
+		CreatedAt: evt.CreatedAt.Time(),
+		EventId:   evt.ID,
+		Type:      "banter",
+	}
+
+	// Check for tags: pinned, label, type
+	for _, tag := range evt.Tags {
+		if len(tag) >= 2 {
+			switch tag[0] {
+			case "pinned":
+				if tag[1] == "true" {
+					cd.IsPinned = true
+				}
+			case "label":
+				// "Settlement" -> "settlement" for consistency
+				cd.Type = strings.ToLower(tag[1])
+			case "type":
+				// Legacy tag support
+				cd.Type = tag[1]
+			}
+		}
+	}
+
+	// Also pin settlement/summary by type (fallback for old events)
+	if cd.Type == "settlement" || cd.Type == "summary" {
+		cd.IsPinned = true
+	}
+
+	return cd
+}
+
 // fetchPlayerProfiles resolves kind 0 profiles for a list of pubkeys.
 func fetchPlayerProfiles(ctx context.Context, pubkeys []string) map[string]PlayerData {
 	profiles := make(map[string]PlayerData, len(pubkeys))
@@ -385,6 +1066,7 @@ func fetchPlayerProfiles(ctx context.Context, pubkeys []string) map[string]Playe
 			pd.DisplayName = shortenString(npub, 8, 4)
 		}
 		pd.Picture = meta.Picture
+		pd.HandicapIndex, pd.HasHandicap = profileHandicapIndex(meta.Event)
 
 		profiles[pk] = pd
 	}
@@ -436,6 +1118,29 @@ func formatScoreToPar(scoreToPar int) string {
 	return "E"
 }
 
+func skinDisplay(s SkinHole) string {
+	switch {
+	case s.Winner.PubkeyHex != "" && s.Value > 1:
+		return fmt.Sprintf("%s ×%d", s.Winner.DisplayName, s.Value)
+	case s.Winner.PubkeyHex != "":
+		return s.Winner.DisplayName
+	case s.Carried:
+		return "carry"
+	}
+	return "-"
+}
+
+func skinCellClass(s SkinHole) string {
+	base := "border border-gray-800 px-1.5 py-2 text-xs font-bold truncate"
+	switch {
+	case s.Winner.PubkeyHex != "":
+		return base + " text-green-700 bg-green-50"
+	case s.Carried:
+		return base + " text-amber-700 bg-amber-50"
+	}
+	return base + " text-gray-400"
+}
+
 func nineTotal(scores []int, start, end int) string {
 	total := 0
 	count := 0
@@ -459,6 +1164,27 @@ func sumSlice(vals []int, start, end int) int {
 	return total
 }
 
+// roundTeeLabel names the tee with its rating and slope when the course has them.
+func roundTeeLabel(tee *RoundTee) string {
+	if tee.Rating <= 0 || tee.Slope <= 0 {
+		return tee.Name + " tees"
+	}
+	return fmt.Sprintf("%s tees · %.1f / %d", tee.Name, tee.Rating, tee.Slope)
+}
+
+// roundTeeHasYards reports whether the course gives the yardage of any hole
+// of the nine ending at hole end.
+func roundTeeHasYards(tee *RoundTee, end int) bool {
+	return tee != nil && len(tee.HoleYards) >= end && sumSlice(tee.HoleYards, end-9, end) > 0
+}
+
+func roundTeeYards(tee *RoundTee, i int) string {
+	if i >= len(tee.HoleYards) || tee.HoleYards[i] == 0 {
+		return "-"
+	}
+	return strconv.Itoa(tee.HoleYards[i])
+}
+
 func formatDate(dateStr string) string {
 	if len(dateStr) >= 10 {
 		return dateStr[:10]
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundFormats(t *testing.T) {
+	alice := PlayerData{PubkeyHex: "aa", DisplayName: "Alice"}
+	bob := PlayerData{PubkeyHex: "bb", DisplayName: "Bob"}
+	rpd := RoundPageData{
+		HolePars: []int{4, 4, 3, 5},
+		PlayerScores: []PlayerScoreData{
+			{Player: alice, HoleScores: []int{4, 3, 3, 0}},
+			{Player: bob, HoleScores: []int{4, 5, 3, 0}},
+		},
+	}
+
+	// par 2 + birdie 3 + par 2
+	rf := computeRoundFormat(nip101g.FormatStableford, rpd)
+	require.Len(t, rf.Standings, 2)
+	assert.Equal(t, "7 pts", rf.Standings[0].Display)
+	assert.Equal(t, "1", rf.Standings[0].Rank)
+	assert.Equal(t, "Stableford: Alice 7 pts, Bob 5 pts", rf.summary())
+
+	rf = computeRoundFormat(nip101g.FormatModifiedStableford, rpd)
+	assert.Equal(t, 2, rf.Standings[0].Points)
+	assert.Equal(t, -1, rf.Standings[1].Points)
+
+	rf = computeRoundFormat(nip101g.FormatMatchPlay, rpd)
+	assert.Equal(t, "Alice 1 UP thru 3", rf.Matches[0].Status)
+
+	// hole 1 and 3 tie and carry, hole 2 goes to Alice with the carryover
+	rf = computeRoundFormat(nip101g.FormatSkins, rpd)
+	assert.True(t, rf.Skins[0].Carried)
+	assert.Equal(t, 2, rf.Skins[1].Value)
+	assert.Equal(t, "Alice", rf.Skins[1].Winner.DisplayName)
+	assert.True(t, rf.Skins[2].Carried)
+	assert.Zero(t, rf.Skins[3].Value)
+	assert.Equal(t, "2 skins", rf.Standings[0].Display)
+
+	rf = computeRoundFormat(nip101g.FormatBestBall, rpd)
+	assert.Equal(t, []int{4, 3, 3, 0}, rf.BestBall)
+	assert.Equal(t, -1, rf.BestBallToPar)
+
+	assert.Equal(t, "Alice wins 3 & 2", matchResult("Match", alice, bob,
+		[]int{3, 3, 3, 4, 4}, []int{4, 4, 4, 4, 4}, 0, 5).Status)
+	assert.Equal(t, "Halved", matchResult("Match", alice, bob,
+		[]int{3, 5}, []int{4, 4}, 0, 2).Status)
+}
+
+func TestGolfRoundTee(t *testing.T) {
+	course := nip101g.Course{
+		Holes: []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}, {Number: 3, Par: 5}},
+		Tees:  []nip101g.CourseTee{{Name: "Blue", Rating: 71.2, Slope: 128}, {Name: "Red"}},
+		Yardages: []nip101g.CourseYardage{
+			{Hole: 1, Tee: "Blue", Yards: 380},
+			{Hole: 3, Tee: "Blue", Yards: 512},
+			{Hole: 1, Tee: "Red", Yards: 310},
+		},
+	}
+
+	tee, unknown := newRoundTee(course, "blue")
+	require.NotNil(t, tee)
+	assert.False(t, unknown)
+	assert.Equal(t, []int{380, 0, 512}, tee.HoleYards)
+	assert.Equal(t, 892, tee.TotalYards)
+	assert.Equal(t, "Blue tees · 71.2 / 128", roundTeeLabel(tee))
+	assert.Equal(t, "-", roundTeeYards(tee, 1))
+	assert.False(t, roundTeeHasYards(tee, 9), "a three hole course has no front nine to show")
+
+	tee, _ = newRoundTee(course, "Red")
+	assert.Equal(t, "Red tees", roundTeeLabel(tee))
+
+	tee, unknown = newRoundTee(course, "Gold")
+	assert.Nil(t, tee)
+	assert.True(t, unknown)
+
+	// no tee named on a course with several is not a problem
+	tee, unknown = newRoundTee(course, "")
+	assert.Nil(t, tee)
+	assert.False(t, unknown)
+}
+
+func TestGolfRoundCells(t *testing.T) {
+	ps := PlayerScoreData{Player: PlayerData{PubkeyHex: "aa"}, HoleScores: []int{4, 5, 3}, Total: 12}
+	ids := func(holeCount int) []string {
+		var ids []string
+		for _, c := range roundCells(RoundPageData{HoleCount: holeCount, PlayerScores: []PlayerScoreData{ps}}) {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	// a nine hole round has no back nine on the page to update
+	nine := ids(9)
+	assert.Len(t, nine, 9+4)
+	assert.Contains(t, nine, holeCellID("aa", 8))
+	assert.NotContains(t, nine, holeCellID("aa", 9))
+	assert.NotContains(t, nine, "in-aa")
+
+	eighteen := ids(18)
+	assert.Len(t, eighteen, 18+6)
+	assert.Contains(t, eighteen, holeCellID("aa", 17))
+	assert.Contains(t, eighteen, "tot-aa")
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// CourseListing is a course in the /courses directory.
+type CourseListing struct {
+	Naddr        string
+	Title        string
+	Location     string
+	Country      string
+	Holes        int
+	Par          int
+	Rounds       int             // 1501s played on the course
+	Position     *nip101g.LatLon // nil when the course has no geo tags
+	Verification CourseVerification
+	createdAt    nostr.Timestamp
+	author       string
+	operator     string
+	website      string
+	duplicateKey string
+}
+
+// CourseSearch is a query on the course directory, read from the /courses
+// query string.
+type CourseSearch struct {
+	Text    string // matched against the name, the location and the country
+	Country string
+	Holes   int // 0 for any
+	Par     int // 0 for any
+	Sort    string
+}
+
+const (
+	courseSortRounds = "rounds"
+	courseSortName   = "name"
+)
+
+// courseDirectoryRefresh is how often the directory is rebuilt from the relay.
+const courseDirectoryRefresh = 30 * time.Minute
+
+// courseDirectoryMaxEvents bounds how far back a refresh pages through the relay.
+const courseDirectoryMaxEvents = 20000
+
+// courseDirectory is the local index behind /courses: every 33501 seen on
+// relay.gambit.golf, by coordinate, with the rounds played on it and whether
+// it is verified.
+var courseDirectory = &CourseDirectory{courses: make(map[string]CourseListing)}
+
+type CourseDirectory struct {
+	mu      sync.RWMutex
+	courses map[string]CourseListing
+	rounds  roundCounts
+	updated time.Time
+}
+
+// roundCounts is the number of 1501s played on each course. It is kept
+// between refreshes, which only count the rounds published since.
+type roundCounts struct {
+	byCourse map[string]int
+	since    nostr.Timestamp // created_at of the newest round counted
+	edge     map[string]bool // rounds counted at since, which the next query returns again
+}
+
+func (rc roundCounts) clone() roundCounts {
+	return roundCounts{byCourse: maps.Clone(rc.byCourse), since: rc.since, edge: maps.Clone(rc.edge)}
+}
+
+// update counts the 1501s that query returns for a filter asking for the
+// rounds published since the last update, skipping the ones already counted.
+// The counts are left as they were unless query got through all of them, as
+// the rounds it missed would be before the next update's since.
+func (rc *roundCounts) update(query func(filter nostr.Filter, each func(evt *nostr.Event)) error) error {
+	filter := nostr.Filter{Kinds: []int{nip101g.KindRound}}
+	if rc.since > 0 {
+		since := rc.since
+		filter.Since = &since
+	}
+
+	next := rc.clone()
+	if next.byCourse == nil {
+		next.byCourse = make(map[string]int)
+	}
+	if next.edge == nil {
+		next.edge = make(map[string]bool)
+	}
+	err := query(filter, func(evt *nostr.Event) {
+		if evt.CreatedAt < rc.since || (evt.CreatedAt == rc.since && rc.edge[evt.ID]) {
+			return
+		}
+		switch {
+		case evt.CreatedAt > next.since:
+			next.since = evt.CreatedAt
+			next.edge = map[string]bool{evt.ID: true}
+		case evt.CreatedAt == next.since:
+			next.edge[evt.ID] = true
+		}
+
+		round, _ := nip101g.ParseRound(*evt)
+		if round.CourseRef != "" {
+			next.byCourse[round.CourseRef]++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*rc = next
+	return nil
+}
+
+// updateCourseDirectory rebuilds the course directory on start and then
+// periodically, so searches never wait on the relay.
+func updateCourseDirectory(ctx context.Context) {
+	for {
+		log.Debug().Msg("refreshing the course directory")
+		courseDirectory.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(courseDirectoryRefresh):
+		}
+	}
+}
+
+func (cd *CourseDirectory) refresh(ctx context.Context) {
+	courses := make(map[string]CourseListing)
+	queryGambitEvents(ctx, nostr.Filter{Kinds: []int{33501}}, func(evt *nostr.Event) {
+		storeCourseRevision(ctx, evt)
+		listing := newCourseListing(evt)
+		coord := courseCoordinate(evt)
+		if existing, ok := courses[coord]; !ok || existing.createdAt < listing.createdAt {
+			courses[coord] = listing
+		}
+	})
+
+	cd.mu.RLock()
+	rounds := cd.rounds.clone()
+	cd.mu.RUnlock()
+	// the counts stay as they were when the relay couldn't give every new round
+	rounds.update(func(filter nostr.Filter, each func(evt *nostr.Event)) error {
+		return queryGambitEvents(ctx, filter, each)
+	})
+
+	attested := make(map[string]string)
+	if len(s.TrustedPubKeys) > 0 {
+		queryGambitEvents(ctx, nostr.Filter{
+			Kinds:   []int{nostr.KindLabel},
+			Authors: s.TrustedPubKeys,
+			Tags:    nostr.TagMap{"L": {courseAttestationNamespace}},
+		}, func(evt *nostr.Event) {
+			for _, coord := range courseAttestations(evt) {
+				attested[coord] = evt.PubKey
+			}
+		})
+	}
+
+	// operators' domains are checked a few at a time, so slow ones don't
+	// hold up the rest of the refresh
+	verifications := make(map[string]CourseVerification, len(courses))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, courseNIP05Parallel)
+	for coord, listing := range courses {
+		if attester, ok := attested[coord]; ok {
+			verifications[coord] = CourseVerification{Method: courseVerifiedAttestation, Attester: attester}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			v := verifyCourseOperator(ctx, listing.author, listing.operator, listing.website)
+			mu.Lock()
+			verifications[coord] = v
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for coord, listing := range courses {
+		listing.Verification = verifications[coord]
+		courses[coord] = listing
+	}
+
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	// courses indexed before stay in when the relay didn't return them this time
+	for coord, listing := range cd.courses {
+		if existing, ok := courses[coord]; !ok || existing.createdAt < listing.createdAt {
+			courses[coord] = listing
+		}
+	}
+	cd.courses = courses
+	cd.rounds = rounds
+	cd.updated = time.Now()
+}
+
+// add indexes a course event that was seen outside of a refresh, and keeps
+// it among the course's revisions. A newer version keeps the verification of
+// the one it replaces until the next refresh checks it again.
+func (cd *CourseDirectory) add(ctx context.Context, evt *nostr.Event) {
+	storeCourseRevision(ctx, evt)
+	listing := newCourseListing(evt)
+	coord := courseCoordinate(evt)
+
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	existing, ok := cd.courses[coord]
+	if !ok || existing.createdAt < listing.createdAt {
+		listing.Verification = existing.Verification
+		cd.courses[coord] = listing
+	}
+}
+
+// attestedDuplicate returns the attested listing of the same course as the
+// one at the coordinate, if that one isn't attested itself.
+func (cd *CourseDirectory) attestedDuplicate(courseCoord string) (CourseListing, bool) {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+
+	listing, ok := cd.courses[courseCoord]
+	if !ok || listing.Verification.Attested() || listing.duplicateKey == "" {
+		return CourseListing{}, false
+	}
+	for coord, c := range cd.courses {
+		if coord != courseCoord && c.Verification.Attested() && c.duplicateKey == listing.duplicateKey {
+			return c, true
+		}
+	}
+	return CourseListing{}, false
+}
+
+// outranked reports whether a listing is hidden by an attested listing of
+// the same course. Only attestations hide other listings: a NIP-05 only
+// proves control of the domain in the event's own website tag, which anybody
+// can pick. cd.mu must be held.
+func (cd *CourseDirectory) outranked(c CourseListing, attestedKeys map[string]bool) bool {
+	return !c.Verification.Attested() && c.duplicateKey != "" && attestedKeys[c.duplicateKey]
+}
+
+// attestedKeys are the duplicate keys of the attested listings. cd.mu must
+// be held.
+func (cd *CourseDirectory) attestedKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, c := range cd.courses {
+		if c.Verification.Attested() && c.duplicateKey != "" {
+			keys[c.duplicateKey] = true
+		}
+	}
+	return keys
+}
+
+// ready reports whether the directory has been loaded from the relay yet.
+func (cd *CourseDirectory) ready() bool {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+	return !cd.updated.IsZero()
+}
+
+// search returns the courses matching q, verified ones first and then in
+// the order it asks for. Other listings of an attested course are left out.
+func (cd *CourseDirectory) search(q CourseSearch) []CourseListing {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+	attestedKeys := cd.attestedKeys()
+	var results []CourseListing
+	for coord, c := range cd.courses {
+		if cd.outranked(c, attestedKeys) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(c.Title), text) &&
+			!strings.Contains(strings.ToLower(c.Location), text) &&
+			!strings.Contains(strings.ToLower(c.Country), text) {
+			continue
+		}
+		if q.Country != "" && !strings.EqualFold(c.Country, q.Country) {
+			continue
+		}
+		if q.Holes != 0 && c.Holes != q.Holes {
+			continue
+		}
+		if q.Par != 0 && c.Par != q.Par {
+			continue
+		}
+		c.Rounds = cd.rounds.byCourse[coord]
+		results = append(results, c)
+	}
+
+	byName := func(a, b CourseListing) int {
+		if c := cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Naddr, b.Naddr)
+	}
+	slices.SortFunc(results, func(a, b CourseListing) int {
+		if a.Verification.Verified() != b.Verification.Verified() {
+			if a.Verification.Verified() {
+				return -1
+			}
+			return 1
+		}
+		if q.Sort != courseSortName {
+			if c := cmp.Compare(b.Rounds, a.Rounds); c != 0 {
+				return c
+			}
+		}
+		return byName(a, b)
+	})
+	return results
+}
+
+// countries are the countries of the indexed courses, for the search form.
+func (cd *CourseDirectory) countries() []string {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+
+	var countries []string
+	for _, c := range cd.courses {
+		if c.Country != "" && !slices.Contains(countries, c.Country) {
+			countries = append(countries, c.Country)
+		}
+	}
+	slices.Sort(countries)
+	return countries
+}
+
+func newCourseListing(evt *nostr.Event) CourseListing {
+	course, _ := nip101g.ParseCourse(*evt)
+	listing := CourseListing{
+		Title:        courseTitle(course.Title),
+		Location:     course.Location,
+		Country:      course.Country,
+		Holes:        len(course.Holes),
+		Par:          course.TotalPar,
+		Position:     course.Position,
+		createdAt:    evt.CreatedAt,
+		author:       evt.PubKey,
+		operator:     course.OperatorPubkey,
+		website:      course.Website,
+		duplicateKey: courseDuplicateKey(course.Title, course.Location),
+	}
+	listing.Naddr, _ = nip19.EncodeEntity(evt.PubKey, evt.Kind, evt.Tags.GetD(), []string{gambitRelay})
+	return listing
+}
+
+func courseCoordinate(evt *nostr.Event) string {
+	return "33501:" + evt.PubKey + ":" + evt.Tags.GetD()
+}
+
+func parseCourseSearch(query url.Values) CourseSearch {
+	q := CourseSearch{
+		Text:    query.Get("q"),
+		Country: query.Get("country"),
+		Sort:    query.Get("sort"),
+	}
+	q.Holes, _ = strconv.Atoi(query.Get("holes"))
+	q.Par, _ = strconv.Atoi(query.Get("par"))
+	if q.Sort != courseSortName {
+		q.Sort = courseSortRounds
+	}
+	return q
+}
+
+// queryGambitEvents pages backwards through everything relay.gambit.golf has
+// for the filter, up to courseDirectoryMaxEvents.
+func queryGambitEvents(ctx context.Context, filter nostr.Filter, each func(evt *nostr.Event)) error {
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for the course directory")
+		return err
+	}
+
+	filter.Limit = 500
+	if err := pageEvents(ctx, relay.QueryEvents, filter, courseDirectoryMaxEvents, each); err != nil {
+		log.Warn().Err(err).Ints("kinds", filter.Kinds).Msg("failed to query the course directory")
+		return err
+	}
+	return ctx.Err()
+}
+
+// errPageLimit is returned by pageEvents when it stops at maxEvents before
+// the relay ran out of events.
+var errPageLimit = errors.New("stopped at the maximum number of events")
+
+// pageEvents pages backwards through what query returns for the filter,
+// filter.Limit events at a time, until it runs out or has seen maxEvents.
+// It returns an error unless it got to the end: a page that timed out, the
+// context being done or errPageLimit.
+func pageEvents(
+	ctx context.Context,
+	query func(context.Context, nostr.Filter) (chan *nostr.Event, error),
+	filter nostr.Filter,
+	maxEvents int,
+	each func(evt *nostr.Event),
+) error {
+	seen := make(map[string]bool)
+	for len(seen) < maxEvents {
+		pageCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		ch, err := query(pageCtx, filter)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		n, fresh := 0, 0
+		oldest := nostr.Now()
+		for evt := range ch {
+			n++
+			oldest = min(oldest, evt.CreatedAt)
+			if seen[evt.ID] {
+				continue
+			}
+			seen[evt.ID] = true
+			fresh++
+			each(evt)
+		}
+		// a page cut short by its timeout looks like the last one
+		err = pageCtx.Err()
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		// the next page starts at the oldest second of this one, as more
+		// events may share it
+		if n < filter.Limit || fresh == 0 {
+			return nil
+		}
+		filter.Until = &oldest
+	}
+	return errPageLimit
+}
+
+// Course directory template helper functions
+
+func courseListingPlace(c CourseListing) string {
+	switch {
+	case c.Location != "" && c.Country != "":
+		return c.Location + ", " + c.Country
+	case c.Location != "":
+		return c.Location
+	}
+	return c.Country
+}
+
+func courseSearchHoles(q CourseSearch) string {
+	if q.Holes == 0 {
+		return ""
+	}
+	return strconv.Itoa(q.Holes)
+}
+
+func courseSearchPar(q CourseSearch) string {
+	if q.Par == 0 {
+		return ""
+	}
+	return strconv.Itoa(q.Par)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGolfCourseDirectory(t *testing.T) {
+	pk := strings.Repeat("ab", 32)
+	course := func(d, title, location, country string, holes int, at nostr.Timestamp) *nostr.Event {
+		tags := nostr.Tags{{"d", d}, {"title", title}, {"location", location}, {"country", country}}
+		for h := range holes {
+			tags = append(tags, nostr.Tag{"hole", strconv.Itoa(h + 1), "4", strconv.Itoa(h + 1)})
+		}
+		return &nostr.Event{PubKey: pk, Kind: 33501, CreatedAt: at, Tags: tags}
+	}
+
+	cd := &CourseDirectory{courses: make(map[string]CourseListing)}
+	cd.add(t.Context(), course("pines", "Pine Valley", "Clementon, NJ", "US", 18, 10))
+	cd.add(t.Context(), course("links", "St Andrews Old", "St Andrews", "GB", 18, 10))
+	cd.add(t.Context(), course("links", "Old Course", "St Andrews", "GB", 18, 5)) // an older version is ignored
+	cd.add(t.Context(), course("nine", "Pitch & Putt", "Dublin", "IE", 9, 10))
+	cd.rounds.byCourse = map[string]int{"33501:" + pk + ":nine": 4, "33501:" + pk + ":pines": 2}
+
+	titles := func(q CourseSearch) []string {
+		var titles []string
+		for _, c := range cd.search(q) {
+			titles = append(titles, c.Title)
+		}
+		return titles
+	}
+
+	assert.Equal(t, []string{"Pitch & Putt", "Pine Valley", "St Andrews Old"}, titles(parseCourseSearch(url.Values{})))
+	assert.Equal(t, []string{"Pine Valley", "Pitch & Putt", "St Andrews Old"}, titles(parseCourseSearch(url.Values{"sort": {"name"}})))
+	assert.Equal(t, []string{"St Andrews Old"}, titles(parseCourseSearch(url.Values{"q": {"andrews"}})))
+	assert.Equal(t, []string{"Pine Valley"}, titles(parseCourseSearch(url.Values{"q": {"us"}})))
+	assert.Equal(t, []string{"Pitch & Putt"}, titles(parseCourseSearch(url.Values{"holes": {"9"}})))
+	assert.Equal(t, []string{"Pine Valley", "St Andrews Old"}, titles(parseCourseSearch(url.Values{"par": {"72"}})))
+	assert.Equal(t, []string{"St Andrews Old"}, titles(parseCourseSearch(url.Values{"country": {"gb"}, "holes": {"18"}})))
+
+	assert.Equal(t, []string{"GB", "IE", "US"}, cd.countries())
+	assert.Equal(t, 4, cd.search(CourseSearch{Holes: 9})[0].Rounds)
+	assert.False(t, cd.ready())
+}
+
+func TestGolfCourseDirectoryRoundCounts(t *testing.T) {
+	round := func(id, course string, at nostr.Timestamp) *nostr.Event {
+		return &nostr.Event{ID: id, Kind: 1501, CreatedAt: at, Tags: nostr.Tags{{"course", course}}}
+	}
+	relay := func(events ...*nostr.Event) func(nostr.Filter, func(*nostr.Event)) error {
+		return func(filter nostr.Filter, each func(*nostr.Event)) error {
+			for _, evt := range events {
+				if filter.Since == nil || evt.CreatedAt >= *filter.Since {
+					each(evt)
+				}
+			}
+			return nil
+		}
+	}
+
+	// newest first, as the relay returns them
+	var rc roundCounts
+	rc.update(relay(
+		round("r3", "33501:abc:links", 20),
+		round("r2", "33501:abc:pines", 20),
+		round("r1", "33501:abc:pines", 10),
+	))
+	assert.Equal(t, map[string]int{"33501:abc:pines": 2, "33501:abc:links": 1}, rc.byCourse)
+
+	// the next refresh starts at the newest round and skips the ones already counted
+	next := rc.clone()
+	next.update(relay(
+		round("r5", "33501:abc:links", 30),
+		round("r4", "33501:abc:pines", 20),
+		round("r3", "33501:abc:links", 20),
+		round("r2", "33501:abc:pines", 20),
+		round("r1", "33501:abc:pines", 10),
+	))
+	assert.Equal(t, map[string]int{"33501:abc:pines": 3, "33501:abc:links": 2}, next.byCourse)
+	assert.Equal(t, nostr.Timestamp(30), next.since)
+	assert.Equal(t, map[string]int{"33501:abc:pines": 2, "33501:abc:links": 1}, rc.byCourse)
+
+	// a query that stops partway leaves the counts and since where they were
+	stopped := next.clone()
+	err := stopped.update(func(filter nostr.Filter, each func(*nostr.Event)) error {
+		each(round("r7", "33501:abc:pines", 50))
+		return context.DeadlineExceeded
+	})
+	assert.Error(t, err)
+	assert.Equal(t, next, stopped)
+}
+
+func TestGolfPageEvents(t *testing.T) {
+	events := make([]*nostr.Event, 5)
+	for i := range events {
+		events[i] = &nostr.Event{ID: strconv.Itoa(i), CreatedAt: nostr.Timestamp(100 - i)}
+	}
+	relay := func(ctx context.Context, filter nostr.Filter) (chan *nostr.Event, error) {
+		ch := make(chan *nostr.Event)
+		go func() {
+			defer close(ch)
+			n := 0
+			for _, evt := range events {
+				if n == filter.Limit {
+					return
+				}
+				if filter.Until == nil || evt.CreatedAt <= *filter.Until {
+					ch <- evt
+					n++
+				}
+			}
+		}()
+		return ch, nil
+	}
+
+	var seen int
+	err := pageEvents(t.Context(), relay, nostr.Filter{Limit: 2}, 10, func(*nostr.Event) { seen++ })
+	assert.NoError(t, err)
+	assert.Equal(t, 5, seen)
+
+	seen = 0
+	err = pageEvents(t.Context(), relay, nostr.Filter{Limit: 2}, 3, func(*nostr.Event) { seen++ })
+	assert.ErrorIs(t, err, errPageLimit)
+
+	// a page cut short by its timeout isn't taken for the last one
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err = pageEvents(ctx, relay, nostr.Filter{Limit: 2}, 10, func(*nostr.Event) {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/fiatjaf/njump/nip101g"
+)
+
+// FlightData is one flight's section of the tournament leaderboard.
+type FlightData struct {
+	Index    int    // position of the flight on the tournament, used for stable HTML ids
+	Name     string // "" when the tournament has no flights
+	Players  []LeaderboardEntry
+	CutAfter string // pubkey of the last player above the cut line, "" when no line is drawn
+}
+
+// unflightedName labels the players that fit none of the declared flights.
+const unflightedName = "Other players"
+
+// flightOf finds a player's flight: the one a division tag puts them in,
+// or else the first whose handicap band holds their index. Players without a
+// flight get len(b.flights).
+func (b *tournamentBoard) flightOf(pk string, entry LeaderboardEntry) int {
+	if name, ok := b.divisions[pk]; ok {
+		if i := slices.IndexFunc(b.flights, func(f nip101g.Flight) bool { return f.Name == name }); i >= 0 {
+			return i
+		}
+	}
+	if index, ok := b.flightIndex(pk, entry); ok {
+		for i, f := range b.flights {
+			if f.Contains(index) {
+				return i
+			}
+		}
+	}
+	return len(b.flights)
+}
+
+// flightIndex is the handicap index a player is flighted on: the one on their
+// first round's 1501, or else their profile's.
+func (b *tournamentBoard) flightIndex(pk string, entry LeaderboardEntry) (float64, bool) {
+	if initEvt, ok := b.initByRound[playerRound{pk, 1}]; ok {
+		round, _ := nip101g.ParseRound(*initEvt)
+		if index, ok := round.Handicaps[pk]; ok {
+			return index, true
+		}
+	}
+	return entry.Player.HandicapIndex, entry.Player.HasHandicap
+}
+
+// flightSections splits a sorted leaderboard into its flights, skipping those
+// with nobody in them.
+func (b *tournamentBoard) flightSections(entries []LeaderboardEntry) []FlightData {
+	var sections []FlightData
+	for _, e := range entries {
+		if len(sections) == 0 || sections[len(sections)-1].Index != e.Flight {
+			fd := FlightData{Index: e.Flight}
+			if e.Flight < len(b.flights) {
+				fd.Name = b.flights[e.Flight].Name
+			} else if len(b.flights) > 0 {
+				fd.Name = unflightedName
+			}
+			fd.CutAfter, _ = b.cutLine(entries, e.Flight)
+			sections = append(sections, fd)
+		}
+		sections[len(sections)-1].Players = append(sections[len(sections)-1].Players, e)
+	}
+	return sections
+}
+
+// flightWinners lists the leader of every named flight, for the OG description.
+func flightWinners(flights []FlightData) []string {
+	var winners []string
+	for _, f := range flights {
+		if f.Name == "" || len(f.Players) == 0 || f.Players[0].IsDNS {
+			continue
+		}
+		winners = append(winners, f.Name+": "+f.Players[0].Player.DisplayName+" ("+leaderboardScoreDisplay(f.Players[0])+")")
+	}
+	return winners
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/fiatjaf/njump/nip101g"
+)
+
+// CourseMap is a course layout drawn from its tee and green coordinates, with
+// no map tiles: every hole is a line from its tee to its green.
+type CourseMap struct {
+	Width, Height float64
+	Holes         []CourseMapHole
+}
+
+// CourseMapHole is a hole placed on a course map. Tee or Green is nil when
+// the course doesn't say where it is.
+type CourseMapHole struct {
+	Number int
+	Par    int
+	Tee    *courseMapPoint
+	Green  *courseMapPoint
+}
+
+type courseMapPoint struct {
+	X, Y float64
+}
+
+// course map dimensions in SVG units; the height follows the layout's shape.
+const (
+	courseMapWidth     = 640.0
+	courseMapMinHeight = 240.0
+	courseMapMaxHeight = 720.0
+	courseMapPadding   = 28.0
+)
+
+// buildCourseMap projects the hole coordinates of a course onto a map, or
+// returns nil when it has none.
+func buildCourseMap(course nip101g.Course) *CourseMap {
+	if len(course.HoleGeo) == 0 {
+		return nil
+	}
+
+	// an equirectangular projection is plenty at the scale of a golf course
+	var lat0 float64
+	var positions []nip101g.LatLon
+	for _, hg := range course.HoleGeo {
+		for _, p := range []*nip101g.LatLon{hg.Tee, hg.Green} {
+			if p != nil {
+				positions = append(positions, *p)
+				lat0 += p.Lat
+			}
+		}
+	}
+	lat0 /= float64(len(positions))
+	project := func(p nip101g.LatLon) (x, y float64) {
+		return p.Lon * math.Cos(lat0*math.Pi/180), -p.Lat
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range positions {
+		x, y := project(p)
+		minX, maxX = min(minX, x), max(maxX, x)
+		minY, maxY = min(minY, y), max(maxY, y)
+	}
+	dx, dy := maxX-minX, maxY-minY
+
+	cm := &CourseMap{Width: courseMapWidth, Height: courseMapMinHeight}
+	inner := courseMapWidth - 2*courseMapPadding
+	if dx > 0 {
+		cm.Height = min(max(dy/dx*inner+2*courseMapPadding, courseMapMinHeight), courseMapMaxHeight)
+	}
+	scale := 0.0
+	if dx > 0 || dy > 0 {
+		scale = min(inner/max(dx, 1e-12), (cm.Height-2*courseMapPadding)/max(dy, 1e-12))
+	}
+	// center the layout on the map
+	offX := (cm.Width - dx*scale) / 2
+	offY := (cm.Height - dy*scale) / 2
+	place := func(p *nip101g.LatLon) *courseMapPoint {
+		if p == nil {
+			return nil
+		}
+		x, y := project(*p)
+		return &courseMapPoint{
+			X: math.Round((offX+(x-minX)*scale)*10) / 10,
+			Y: math.Round((offY+(y-minY)*scale)*10) / 10,
+		}
+	}
+
+	for _, hg := range course.HoleGeo {
+		cm.Holes = append(cm.Holes, CourseMapHole{
+			Number: hg.Hole,
+			Par:    course.Par(hg.Hole),
+			Tee:    place(hg.Tee),
+			Green:  place(hg.Green),
+		})
+	}
+	return cm
+}
+
+// geoJSONFeatureCollection, geoJSONFeature and geoJSONGeometry are the parts
+// of RFC 7946 the course exports use.
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties map[string]any   `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string            `json:"type"`
+	Coordinates any               `json:"coordinates,omitempty"`
+	Geometries  []geoJSONGeometry `json:"geometries,omitempty"`
+}
+
+// geoJSONPosition is a position in GeoJSON order, longitude first.
+func geoJSONPosition(p nip101g.LatLon) [2]float64 {
+	return [2]float64{p.Lon, p.Lat}
+}
+
+// courseFeature is a course as a GeoJSON feature: its position, and a line
+// from tee to green for every hole that has both.
+func courseFeature(course nip101g.Course, naddr string) geoJSONFeature {
+	f := geoJSONFeature{
+		Type: "Feature",
+		Properties: map[string]any{
+			"name":     courseTitle(course.Title),
+			"location": course.Location,
+			"country":  course.Country,
+			"holes":    len(course.Holes),
+			"par":      course.TotalPar,
+			"url":      "https://" + s.Domain + "/" + naddr,
+		},
+	}
+	if course.Geohash != "" {
+		f.Properties["geohash"] = course.Geohash
+	}
+
+	var geometries []geoJSONGeometry
+	if course.Position != nil {
+		geometries = append(geometries, geoJSONGeometry{Type: "Point", Coordinates: geoJSONPosition(*course.Position)})
+	}
+	if len(course.HoleGeo) > 0 {
+		var holes []map[string]any
+		for _, hg := range course.HoleGeo {
+			hole := map[string]any{"hole": hg.Hole, "par": course.Par(hg.Hole)}
+			if hg.Tee != nil {
+				hole["tee"] = geoJSONPosition(*hg.Tee)
+			}
+			if hg.Green != nil {
+				hole["green"] = geoJSONPosition(*hg.Green)
+			}
+			holes = append(holes, hole)
+			if hg.Tee != nil && hg.Green != nil {
+				geometries = append(geometries, geoJSONGeometry{
+					Type:        "LineString",
+					Coordinates: [][2]float64{geoJSONPosition(*hg.Tee), geoJSONPosition(*hg.Green)},
+				})
+			}
+		}
+		f.Properties["hole_positions"] = holes
+	}
+
+	switch len(geometries) {
+	case 0:
+	case 1:
+		f.Geometry = &geometries[0]
+	default:
+		f.Geometry = &geoJSONGeometry{Type: "GeometryCollection", Geometries: geometries}
+	}
+	return f
+}
+
+// geoJSON is every indexed course with a known position, as points, leaving
+// out the unverified duplicates of verified courses.
+func (cd *CourseDirectory) geoJSON() geoJSONFeatureCollection {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	attestedKeys := cd.attestedKeys()
+	for coord, c := range cd.courses {
+		if c.Position == nil || cd.outranked(c, attestedKeys) {
+			continue
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: &geoJSONGeometry{Type: "Point", Coordinates: geoJSONPosition(*c.Position)},
+			Properties: map[string]any{
+				"name":     c.Title,
+				"location": c.Location,
+				"country":  c.Country,
+				"holes":    c.Holes,
+				"par":      c.Par,
+				"rounds":   cd.rounds.byCourse[coord],
+				"verified": c.Verification.Verified(),
+				"url":      "https://" + s.Domain + "/" + c.Naddr,
+			},
+		})
+	}
+	slices.SortFunc(fc.Features, func(a, b geoJSONFeature) int {
+		return cmp.Compare(a.Properties["url"].(string), b.Properties["url"].(string))
+	})
+	return fc
+}
+
+func courseTitle(title string) string {
+	if title == "" {
+		return "Golf Course"
+	}
+	return title
+}
+
+// Course map template helper functions
+
+func courseMapViewBox(cm *CourseMap) string {
+	return "0 0 " + svgNum(cm.Width) + " " + svgNum(cm.Height)
+}
+
+func courseMapHoleTitle(h CourseMapHole) string {
+	if h.Par == 0 {
+		return "Hole " + strconv.Itoa(h.Number)
+	}
+	return "Hole " + strconv.Itoa(h.Number) + " · Par " + strconv.Itoa(h.Par)
+}
+
+// courseMapLabel is where a hole's number goes: by its tee, or by its green
+// when the tee isn't known.
+func courseMapLabel(h CourseMapHole) courseMapPoint {
+	p := h.Tee
+	if p == nil {
+		p = h.Green
+	}
+	return courseMapPoint{X: p.X, Y: p.Y - 9}
+}
+
+func courseCoordinates(p nip101g.LatLon) string {
+	return strconv.FormatFloat(p.Lat, 'f', 5, 64) + ", " + strconv.FormatFloat(p.Lon, 'f', 5, 64)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfCourseGeo(t *testing.T) {
+	course := nip101g.Course{
+		Title:    "Links",
+		Holes:    []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}},
+		TotalPar: 7,
+		Position: &nip101g.LatLon{Lat: 0.001, Lon: 0.001},
+		HoleGeo: []nip101g.CourseHoleGeo{
+			{Hole: 1, Tee: &nip101g.LatLon{Lat: 0, Lon: 0}, Green: &nip101g.LatLon{Lat: 0, Lon: 0.004}},
+			{Hole: 2, Green: &nip101g.LatLon{Lat: 0.001, Lon: 0.002}},
+		},
+	}
+
+	assert.Nil(t, buildCourseMap(nip101g.Course{}))
+
+	// a wide layout fills the width and gets the minimum height, centered
+	cm := buildCourseMap(course)
+	require.NotNil(t, cm)
+	assert.Equal(t, courseMapMinHeight, cm.Height)
+	require.Len(t, cm.Holes, 2)
+	assert.Equal(t, courseMapPoint{X: courseMapPadding, Y: 193}, *cm.Holes[0].Tee)
+	assert.Equal(t, courseMapPoint{X: courseMapWidth - courseMapPadding, Y: 193}, *cm.Holes[0].Green)
+	assert.Nil(t, cm.Holes[1].Tee)
+	assert.Equal(t, courseMapPoint{X: 320, Y: 47}, *cm.Holes[1].Green)
+	assert.Equal(t, "Hole 2 · Par 3", courseMapHoleTitle(cm.Holes[1]))
+	assert.Equal(t, courseMapPoint{X: 320, Y: 38}, courseMapLabel(cm.Holes[1]))
+
+	f := courseFeature(course, "naddr1links")
+	b, err := json.Marshal(f)
+	require.NoError(t, err)
+	var decoded struct {
+		Geometry struct {
+			Type       string
+			Geometries []struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+		}
+		Properties map[string]any
+	}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, "GeometryCollection", decoded.Geometry.Type)
+	require.Len(t, decoded.Geometry.Geometries, 2)
+	assert.Equal(t, "Point", decoded.Geometry.Geometries[0].Type)
+	assert.JSONEq(t, `[0.001,0.001]`, string(decoded.Geometry.Geometries[0].Coordinates))
+	assert.Equal(t, "LineString", decoded.Geometry.Geometries[1].Type)
+	assert.JSONEq(t, `[[0,0],[0.004,0]]`, string(decoded.Geometry.Geometries[1].Coordinates))
+	assert.Equal(t, "Links", decoded.Properties["name"])
+	assert.Len(t, decoded.Properties["hole_positions"], 2)
+
+	// a course with no geo tags is a feature without a geometry
+	b, err = json.Marshal(courseFeature(nip101g.Course{}, "naddr1none"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"geometry":null`)
+	assert.Contains(t, string(b), `"name":"Golf Course"`)
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// GolferStats sums up a player's published rounds for their profile.
+type GolferStats struct {
+	Rounds         int          // final records
+	HolesPlayed    int          // over every final record
+	ScoringAverage float64      // strokes per complete 18 hole round
+	AverageRounds  int          // complete 18 hole rounds the average is taken over
+	Best           *GolferRound // lowest complete 18 hole round, nil without one
+	ParAverages    []ParAverage // par 3s, 4s and 5s that have been played
+	BirdieRate     float64      // share of the holes with a known par made in birdie or better
+	HoleResults    [5]int       // holes made in eagle or better, birdie, par, bogey and double bogey or worse
+	Recent         []GolferRound
+	Trend          []GolferRound // complete 18 hole rounds with a known par, oldest first
+	Achievements   []Achievement // in the order they were earned
+}
+
+// ParAverage is the average score to par on holes of one par.
+type ParAverage struct {
+	Par     int
+	Holes   int
+	Average float64 // strokes over par
+}
+
+// GolferRound is one of a player's final rounds.
+type GolferRound struct {
+	Nevent     string // the round page
+	Date       time.Time
+	CourseName string
+	TeeSet     string
+	HoleCount  int
+	Total      int
+	ScoreToPar int
+	HasPar     bool // every hole played has a known par
+}
+
+// golferRound is a round with the hole by hole scores the stats are computed from.
+type golferRound struct {
+	round   GolferRound
+	roundID string // the 1501, empty for a record without one
+	course  string // course coordinate, or the course name when the record has none
+	holes   []int  // strokes per hole (index 0 = hole 1), 0 when not played
+	pars    []int  // par per hole, nil when the course is unknown
+}
+
+// golferRecentRounds is how many rounds the recent rounds table shows.
+const golferRecentRounds = 10
+
+// golferHistory is a player's handicap and stats, computed from their final
+// records once for every page and image that shows them.
+type golferHistory struct {
+	handicap HandicapSummary
+	stats    GolferStats
+	roundIDs map[string]bool // the 1501s the records close
+	at       time.Time
+}
+
+const (
+	// golferHistoryTTL is how long a player's history is kept before their
+	// records are fetched again.
+	golferHistoryTTL = 10 * time.Minute
+
+	// golferHistoryMinAge is how long a history is kept even when it lacks a
+	// round being looked at, so a round page doesn't refetch it every time.
+	golferHistoryMinAge = time.Minute
+)
+
+var golferHistories = &golferHistoryCache{histories: make(map[string]golferHistory)}
+
+type golferHistoryCache struct {
+	mu        sync.Mutex
+	histories map[string]golferHistory
+}
+
+// get returns a player's history, from the cache when it is recent enough.
+func (hc *golferHistoryCache) get(ctx context.Context, pubkey string) golferHistory {
+	return hc.getFresh(ctx, pubkey, func(h golferHistory) bool {
+		return time.Since(h.at) < golferHistoryTTL
+	})
+}
+
+// getWithRound is get for the page of one of the player's rounds: a history
+// from before the round was finished is fetched again once it's a minute old.
+func (hc *golferHistoryCache) getWithRound(ctx context.Context, pubkey, roundID string) golferHistory {
+	return hc.getFresh(ctx, pubkey, func(h golferHistory) bool {
+		age := time.Since(h.at)
+		return age < golferHistoryMinAge || (age < golferHistoryTTL && h.roundIDs[roundID])
+	})
+}
+
+func (hc *golferHistoryCache) getFresh(ctx context.Context, pubkey string, fresh func(golferHistory) bool) golferHistory {
+	hc.mu.Lock()
+	cached, ok := hc.histories[pubkey]
+	hc.mu.Unlock()
+	if ok && fresh(cached) {
+		return cached
+	}
+
+	history := loadGolferHistory(ctx, pubkey)
+
+	hc.mu.Lock()
+	for pk, h := range hc.histories {
+		if time.Since(h.at) >= golferHistoryTTL {
+			delete(hc.histories, pk)
+		}
+	}
+	hc.histories[pubkey] = history
+	hc.mu.Unlock()
+	return history
+}
+
+// loadGolferHistory fetches a player's final records and computes their
+// handicap and stats from them.
+func loadGolferHistory(ctx context.Context, pubkey string) golferHistory {
+	records := fetchPlayerRecords(ctx, pubkey)
+	courses := make(courseCache)
+	history := golferHistory{
+		handicap: buildHandicapSummary(ctx, records, courses),
+		stats:    buildGolferStats(ctx, records, courses),
+		roundIDs: make(map[string]bool, len(records)),
+		at:       time.Now(),
+	}
+	for _, evt := range records {
+		if record, _ := nip101g.ParseRoundRecord(*evt); record.RoundID != "" {
+			history.roundIDs[record.RoundID] = true
+		}
+	}
+	return history
+}
+
+// buildGolferStats computes a player's stats from their final records.
+func buildGolferStats(ctx context.Context, records []*nostr.Event, courses courseCache) GolferStats {
+	return computeGolferStats(loadGolferRounds(ctx, records, courses))
+}
+
+// loadGolferRounds reads a player's final records, with the 1501s they close
+// and the courses they were played on for the hole pars.
+func loadGolferRounds(ctx context.Context, records []*nostr.Event, courses courseCache) []golferRound {
+	var roundIDs, courseRefs []string
+	parsed := make([]nip101g.RoundRecord, len(records))
+	for i, evt := range records {
+		parsed[i], _ = nip101g.ParseRoundRecord(*evt)
+		if parsed[i].RoundID != "" {
+			roundIDs = append(roundIDs, parsed[i].RoundID)
+		}
+		if parsed[i].CourseRef != "" {
+			courseRefs = append(courseRefs, parsed[i].CourseRef)
+		}
+	}
+	starts := fetchRoundsByID(ctx, roundIDs)
+	courses.prefetch(ctx, courseRefs)
+
+	rounds := make([]golferRound, 0, len(records))
+	for i, evt := range records {
+		record := parsed[i]
+		snapshot := record.Snapshot
+		if start, ok := starts[record.RoundID]; ok && len(snapshot.HolePars) == 0 {
+			round, _ := nip101g.ParseRound(*start)
+			snapshot = round.Snapshot
+		}
+
+		gr := golferRound{
+			round: GolferRound{
+				Date:       evt.CreatedAt.Time(),
+				CourseName: snapshot.CourseName,
+				TeeSet:     record.TeeSet,
+				HoleCount:  record.HoleCount(),
+				Total:      record.TotalOrSum(),
+			},
+			roundID: record.RoundID,
+			course:  record.CourseRef,
+			pars:    snapshot.HolePars,
+		}
+		if t, err := time.Parse("2006-01-02", formatDate(record.Date)); err == nil {
+			gr.round.Date = t
+		}
+		if record.CourseRef != "" {
+			if course := courses.get(ctx, record.CourseRef, roundTime(record.Date, evt.CreatedAt.Time())); course != nil {
+				if course.Title != "" {
+					gr.round.CourseName = course.Title
+				}
+				if pars := course.HolePars(); len(pars) > 0 {
+					gr.pars = pars
+				}
+			}
+		}
+		if gr.course == "" {
+			gr.course = gr.round.CourseName
+		}
+		if record.RoundID != "" {
+			gr.round.Nevent, _ = nip19.EncodeEvent(record.RoundID, []string{gambitRelay}, "")
+		} else {
+			gr.round.Nevent, _ = nip19.EncodeEvent(evt.ID, []string{gambitRelay}, evt.PubKey)
+		}
+		if gr.round.HoleCount < len(gr.pars) {
+			gr.pars = gr.pars[:gr.round.HoleCount]
+		}
+		gr.holes = scorecardHoles(record.Scorecard, gr.round.HoleCount)
+		rounds = append(rounds, gr)
+	}
+	return rounds
+}
+
+func computeGolferStats(rounds []golferRound) GolferStats {
+	var st GolferStats
+	byPar := make(map[int]*ParAverage)
+	parHoles, birdies := 0, 0
+	strokes := 0
+
+	for i := range rounds {
+		gr := &rounds[i]
+		st.Rounds++
+
+		played, par := 0, 0
+		gr.round.HasPar = true
+		for h, s := range gr.holes {
+			if s == 0 {
+				continue
+			}
+			played++
+			if h >= len(gr.pars) || gr.pars[h] == 0 {
+				gr.round.HasPar = false
+				continue
+			}
+			p := gr.pars[h]
+			par += p
+			parHoles++
+			if s < p {
+				birdies++
+			}
+			st.HoleResults[min(max(s-p+2, 0), len(st.HoleResults)-1)]++
+			pa, ok := byPar[p]
+			if !ok {
+				pa = &ParAverage{Par: p}
+				byPar[p] = pa
+			}
+			pa.Holes++
+			pa.Average += float64(s - p)
+		}
+		st.HolesPlayed += played
+		if gr.round.HasPar && played > 0 {
+			gr.round.ScoreToPar = gr.round.Total - par
+		} else {
+			gr.round.HasPar = false
+		}
+
+		if played != 18 || gr.round.Total == 0 {
+			continue // the average and the best round are over complete 18 hole rounds
+		}
+		st.AverageRounds++
+		strokes += gr.round.Total
+		if gr.round.HasPar {
+			st.Trend = append(st.Trend, gr.round)
+		}
+		if st.Best == nil || golferRoundBetter(gr.round, *st.Best) {
+			best := gr.round
+			st.Best = &best
+		}
+	}
+
+	if st.AverageRounds > 0 {
+		st.ScoringAverage = float64(strokes) / float64(st.AverageRounds)
+	}
+	if parHoles > 0 {
+		st.BirdieRate = float64(birdies) / float64(parHoles)
+	}
+	for _, p := range []int{3, 4, 5} {
+		if pa, ok := byPar[p]; ok {
+			pa.Average /= float64(pa.Holes)
+			st.ParAverages = append(st.ParAverages, *pa)
+		}
+	}
+
+	recent := make([]GolferRound, len(rounds))
+	for i, gr := range rounds {
+		recent[i] = gr.round
+	}
+	slices.SortStableFunc(recent, func(a, b GolferRound) int { return b.Date.Compare(a.Date) })
+	st.Recent = recent[:min(len(recent), golferRecentRounds)]
+	slices.SortStableFunc(st.Trend, func(a, b GolferRound) int { return a.Date.Compare(b.Date) })
+	st.Achievements = golferAchievements(rounds)
+	return st
+}
+
+// golferRoundBetter compares rounds by score to par when both courses are
+// known, or by strokes otherwise.
+func golferRoundBetter(a, b GolferRound) bool {
+	if a.HasPar && b.HasPar {
+		return a.ScoreToPar < b.ScoreToPar
+	}
+	return a.Total < b.Total
+}
+
+// roundIDsPerQuery bounds the IDs sent in one filter, as relays reject or
+// truncate very large ones.
+const roundIDsPerQuery = 250
+
+// fetchRoundsByID queries relay.gambit.golf for the 1501s with the given IDs,
+// a few hundred at a time.
+func fetchRoundsByID(ctx context.Context, ids []string) map[string]*nostr.Event {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for player rounds")
+		return nil
+	}
+
+	rounds := make(map[string]*nostr.Event, len(ids))
+	for chunk := range slices.Chunk(ids, roundIDsPerQuery) {
+		queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		ch, err := relay.QueryEvents(queryCtx, nostr.Filter{Kinds: []int{1501}, IDs: chunk})
+		if err != nil {
+			cancel()
+			log.Warn().Err(err).Msg("failed to query player 1501s")
+			return rounds
+		}
+		for evt := range ch {
+			rounds[evt.ID] = evt
+		}
+		cancel()
+	}
+	return rounds
+}
+
+// Golfer profile template helper functions
+
+func golferRoundScore(r GolferRound) string {
+	if !r.HasPar {
+		return "-"
+	}
+	return formatScoreToPar(r.ScoreToPar)
+}
+
+func formatParAverage(pa ParAverage) string {
+	return fmt.Sprintf("%+.2f", pa.Average)
+}
+
+func formatBirdieRate(rate float64) string {
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
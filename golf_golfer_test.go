@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolferStats(t *testing.T) {
+	pars := []int{4, 4, 3, 5, 4, 4, 3, 5, 4, 4, 4, 3, 5, 4, 4, 3, 5, 4} // par 72
+	round := func(day int, holes []int, pars []int) golferRound {
+		return golferRound{
+			round: GolferRound{Date: time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC), HoleCount: len(holes), Total: sumSlice(holes, 0, len(holes))},
+			holes: holes,
+			pars:  pars,
+		}
+	}
+	even := append([]int(nil), pars...)
+	under := append([]int(nil), pars...)
+	under[2], under[3] = 2, 4 // birdies on a par 3 and a par 5
+	unknown := make([]int, 18)
+	for i := range unknown {
+		unknown[i] = 5
+	}
+
+	st := computeGolferStats([]golferRound{
+		round(1, even, pars),
+		round(3, under, pars),
+		round(2, unknown, nil),
+		round(4, []int{4, 4, 4, 4, 4, 4, 4, 4, 4}, pars[:9]),
+	})
+	assert.Equal(t, 4, st.Rounds)
+	assert.Equal(t, 63, st.HolesPlayed)
+	assert.Equal(t, 3, st.AverageRounds)
+	assert.InDelta(t, (72+70+90)/3.0, st.ScoringAverage, 0.001)
+
+	require.NotNil(t, st.Best)
+	assert.Equal(t, 70, st.Best.Total)
+	assert.Equal(t, -2, st.Best.ScoreToPar)
+	assert.Equal(t, "-2", golferRoundScore(*st.Best))
+
+	// 4 birdies over the 45 holes with a known par, two of them on the 9 hole round's par 5s
+	assert.Equal(t, "8.9%", formatBirdieRate(st.BirdieRate))
+	require.Len(t, st.ParAverages, 3)
+	assert.Equal(t, 3, st.ParAverages[0].Par)
+	assert.Equal(t, "+0.10", formatParAverage(st.ParAverages[0])) // a birdie and two bogeys over 10 par 3s
+	assert.Equal(t, 4, st.ParAverages[1].Par)
+
+	require.Len(t, st.Recent, 4)
+	assert.Equal(t, 9, st.Recent[0].HoleCount)
+	assert.Equal(t, "E", golferRoundScore(st.Recent[0]))
+	assert.Equal(t, "-", golferRoundScore(st.Recent[2]))
+}
+
+func TestGolferHistoryCache(t *testing.T) {
+	hc := &golferHistoryCache{histories: map[string]golferHistory{
+		"fresh": {stats: GolferStats{Rounds: 3}, at: time.Now()},
+	}}
+	assert.Equal(t, 3, hc.get(t.Context(), "fresh").stats.Rounds)
+
+	// a round page keeps a history with its round, or one lacking it for a minute
+	hc.histories["older"] = golferHistory{stats: GolferStats{Rounds: 5}, roundIDs: map[string]bool{"r1": true}, at: time.Now().Add(-5 * time.Minute)}
+	assert.Equal(t, 5, hc.getWithRound(t.Context(), "older", "r1").stats.Rounds)
+	assert.Equal(t, 3, hc.getWithRound(t.Context(), "fresh", "r2").stats.Rounds)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/fiatjaf/njump/nip101g"
+)
+
+// ScoreGridData is the hole-by-hole view of one round of a tournament: every
+// player's score on every hole, with the field's scoring average per hole.
+type ScoreGridData struct {
+	Round     int // 1 = first round
+	HoleCount int
+	HolePars  []int // par per hole (index 0 = hole 1), nil when the course is unknown
+	Sections  []ScoreGridSection
+	Averages  []float64 // field average per hole, 0 where nobody has played it
+}
+
+// ScoreGridSection is the rows of one flight, in leaderboard order.
+type ScoreGridSection struct {
+	Name string
+	Rows []ScoreGridRow
+}
+
+// ScoreGridRow is one player's round.
+type ScoreGridRow struct {
+	Rank       string
+	Player     PlayerData
+	Holes      []int // strokes per hole, 0 when not played yet
+	Total      int
+	ScoreToPar int
+	Thru       string
+	IsPlaying  bool
+}
+
+// scoreGrid lays out a round of the board hole by hole, following the
+// leaderboard order of the flights. Players who haven't started the round are
+// left out.
+func (b *tournamentBoard) scoreGrid(flights []FlightData, round int) ScoreGridData {
+	grid := ScoreGridData{
+		Round:     round,
+		HoleCount: nip101g.DefaultHoleCount,
+		HolePars:  b.course.HolePars(),
+	}
+	if len(grid.HolePars) > 0 {
+		grid.HoleCount = len(grid.HolePars)
+	} else {
+		// without the course, the players' course snapshots tell the holes
+		grid.HoleCount = 0
+		for key, initEvt := range b.initByRound {
+			if key.number != round {
+				continue
+			}
+			r, _ := nip101g.ParseRound(*initEvt)
+			grid.HoleCount = max(grid.HoleCount, r.HoleCount())
+			if len(r.Snapshot.HolePars) > len(grid.HolePars) {
+				grid.HolePars = r.Snapshot.HolePars
+			}
+		}
+		if grid.HoleCount == 0 {
+			grid.HoleCount = nip101g.DefaultHoleCount
+		}
+	}
+
+	for _, f := range flights {
+		section := ScoreGridSection{Name: f.Name}
+		for _, e := range f.Players {
+			key := playerRound{e.Player.PubkeyHex, round}
+			rr := b.roundResult(key)
+			if !rr.Started {
+				continue
+			}
+			section.Rows = append(section.Rows, ScoreGridRow{
+				Rank:       e.Rank,
+				Player:     e.Player,
+				Holes:      scorecardHoles(b.scorecard(key), grid.HoleCount),
+				Total:      rr.Total,
+				ScoreToPar: rr.ScoreToPar,
+				Thru:       rr.Thru,
+				IsPlaying:  !rr.IsFinished,
+			})
+		}
+		if len(section.Rows) > 0 {
+			grid.Sections = append(grid.Sections, section)
+		}
+	}
+
+	grid.Averages = make([]float64, grid.HoleCount)
+	counts := make([]int, grid.HoleCount)
+	for _, s := range grid.Sections {
+		for _, row := range s.Rows {
+			for i, strokes := range row.Holes {
+				if strokes > 0 {
+					grid.Averages[i] += float64(strokes)
+					counts[i]++
+				}
+			}
+		}
+	}
+	for i, n := range counts {
+		if n > 0 {
+			grid.Averages[i] /= float64(n)
+		}
+	}
+	return grid
+}
+
+// latestRound is the last round anyone has started, 1 before the tournament begins.
+func (b *tournamentBoard) latestRound() int {
+	latest := 1
+	for key := range b.initByRound {
+		latest = max(latest, key.number)
+	}
+	return min(latest, b.rounds)
+}
+
+// gridHalves are the hole ranges [from, to) the grid is split in: the front
+// nine and the back nine, or all the holes of a round of nine or fewer.
+func gridHalves(holeCount int) [][2]int {
+	if holeCount <= 9 {
+		return [][2]int{{0, holeCount}}
+	}
+	return [][2]int{{0, 9}, {9, holeCount}}
+}
+
+func gridHalfLabel(half, halves int) string {
+	switch {
+	case halves == 1:
+		return "Tot"
+	case half == 0:
+		return "Out"
+	}
+	return "In"
+}
+
+func gridAverageDisplay(avg float64) string {
+	if avg == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(avg, 'f', 2, 64)
+}
+
+// gridAverageClass colors a hole's field average by how it played against
+// par: green when the field is under, red when over by a quarter stroke or more.
+func gridAverageClass(avg float64, pars []int, idx int) string {
+	base := "border border-gray-800 px-1.5 py-1.5 text-xs font-mono"
+	if avg == 0 || idx >= len(pars) || pars[idx] == 0 {
+		return base + " text-gray-600"
+	}
+	diff := avg - float64(pars[idx])
+	switch {
+	case diff < 0:
+		return base + " text-green-700 bg-green-50"
+	case diff >= 0.25:
+		return base + " text-red-700 bg-red-50"
+	}
+	return base + " text-gray-900"
+}
+
+func gridAverageSum(averages []float64, from, to int) string {
+	sum := 0.0
+	for _, avg := range averages[from:to] {
+		if avg == 0 {
+			return "-"
+		}
+		sum += avg
+	}
+	return strconv.FormatFloat(sum, 'f', 1, 64)
+}
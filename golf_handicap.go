@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// handicapCourse is what net scoring needs from a 33501 course: the rating and
+// slope of the tee being played, par, and the stroke index of every hole.
+type handicapCourse struct {
+	Rating        float64
+	Slope         int
+	Par           int
+	HoleCount     int   // holes on the course
+	HolePars      []int // par per hole (index 0 = hole 1)
+	StrokeIndexes []int // stroke index per hole (index 0 = hole 1), 0 when unknown
+}
+
+// newHandicapCourse picks the tee being played from the course. It returns false
+// when the tee or its rating/slope is missing, since there's no course handicap then.
+func newHandicapCourse(course nip101g.Course, teeName string) (handicapCourse, bool) {
+	tee, ok := findCourseTee(course, teeName)
+	if !ok || tee.Rating <= 0 || tee.Slope <= 0 || course.TotalPar <= 0 {
+		return handicapCourse{}, false
+	}
+
+	hc := handicapCourse{
+		Rating:        tee.Rating,
+		Slope:         tee.Slope,
+		Par:           course.TotalPar,
+		HoleCount:     len(course.Holes),
+		HolePars:      course.HolePars(),
+		StrokeIndexes: make([]int, len(course.HolePars())),
+	}
+	for _, h := range course.Holes {
+		hc.StrokeIndexes[h.Number-1] = h.Handicap
+	}
+	return hc, true
+}
+
+// findCourseTee looks up a tee ignoring case. With no tee name, a course with a
+// single tee uses that one.
+func findCourseTee(course nip101g.Course, teeName string) (nip101g.CourseTee, bool) {
+	if teeName == "" && len(course.Tees) == 1 {
+		return course.Tees[0], true
+	}
+	for _, t := range course.Tees {
+		if strings.EqualFold(t.Name, teeName) {
+			return t, true
+		}
+	}
+	return nip101g.CourseTee{}, false
+}
+
+// courseHandicap converts a handicap index into strokes for this tee:
+// Handicap Index × (Slope Rating ÷ 113) + (Course Rating − Par), rounded.
+// Rounds shorter than the course get a proportional share.
+func (hc handicapCourse) courseHandicap(index float64, holeCount int) int {
+	ch := index*float64(hc.Slope)/113 + (hc.Rating - float64(hc.Par))
+	if holeCount > 0 && hc.HoleCount > holeCount {
+		ch = ch * float64(holeCount) / float64(hc.HoleCount)
+	}
+	return int(math.Round(ch))
+}
+
+// strokesReceived allocates a course handicap over the holes of a round.
+// Strokes go to the hardest holes first (lowest stroke index) and wrap around
+// once every hole has one; plus handicaps give strokes back starting from the
+// easiest hole. Holes without a stroke index are treated as the easiest.
+func (hc handicapCourse) strokesReceived(courseHandicap int, holeCount int) []int {
+	strokes := make([]int, holeCount)
+	if holeCount == 0 || courseHandicap == 0 {
+		return strokes
+	}
+
+	strokeIndex := func(i int) int {
+		if i < len(hc.StrokeIndexes) && hc.StrokeIndexes[i] > 0 {
+			return hc.StrokeIndexes[i]
+		}
+		return math.MaxInt
+	}
+	order := make([]int, holeCount) // hole indexes, hardest first
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return strokeIndex(order[a]) < strokeIndex(order[b])
+	})
+
+	if courseHandicap > 0 {
+		for k := 0; k < courseHandicap; k++ {
+			strokes[order[k%holeCount]]++
+		}
+	} else {
+		for k := 0; k < -courseHandicap; k++ {
+			strokes[order[holeCount-1-k%holeCount]]--
+		}
+	}
+	return strokes
+}
+
+// netScore is a player's score after handicap strokes.
+type netScore struct {
+	CourseHandicap int
+	HoleScores     []int // gross minus strokes received (index 0 = hole 1, 0 = not played)
+	Total          int
+	Received       int // strokes received on the holes played so far
+}
+
+// netScoreFor applies a handicap index to gross hole scores. A round that only
+// has a total (no hole scores) receives the whole course handicap.
+func (hc handicapCourse) netScoreFor(index float64, holeScores []int, total int) netScore {
+	ns := netScore{
+		CourseHandicap: hc.courseHandicap(index, len(holeScores)),
+		HoleScores:     make([]int, len(holeScores)),
+	}
+	strokes := hc.strokesReceived(ns.CourseHandicap, len(holeScores))
+
+	played := false
+	for i, s := range holeScores {
+		if s == 0 {
+			continue
+		}
+		played = true
+		ns.HoleScores[i] = s - strokes[i]
+		ns.Received += strokes[i]
+	}
+	if !played && total > 0 {
+		ns.Received = ns.CourseHandicap
+	}
+	ns.Total = total - ns.Received
+	return ns
+}
+
+// scorecardHoles spreads a parsed scorecard into per-hole scores
+// (index 0 = hole 1, 0 = not played).
+func scorecardHoles(sc nip101g.Scorecard, holeCount int) []int {
+	holes := make([]int, holeCount)
+	for _, hs := range sc.Scores {
+		if hs.Hole <= holeCount {
+			holes[hs.Hole-1] = hs.Strokes
+		}
+	}
+	return holes
+}
+
+// profileHandicapIndex reads the optional "handicap" field of a kind 0 profile,
+// written either as a number or a string ("12.4", "+1.2").
+func profileHandicapIndex(evt *nostr.Event) (float64, bool) {
+	if evt == nil {
+		return 0, false
+	}
+	var content struct {
+		Handicap any `json:"handicap"`
+	}
+	if err := json.Unmarshal([]byte(evt.Content), &content); err != nil {
+		return 0, false
+	}
+
+	var index float64
+	switch v := content.Handicap.(type) {
+	case float64:
+		index = v
+	case string:
+		v = strings.TrimSpace(v)
+		plus := strings.HasPrefix(v, "+")
+		f, err := strconv.ParseFloat(strings.TrimPrefix(v, "+"), 64)
+		if err != nil {
+			return 0, false
+		}
+		index = f
+		if plus {
+			index = -f
+		}
+	default:
+		return 0, false
+	}
+
+	if index < nip101g.MinHandicapIndex || index > nip101g.MaxHandicapIndex {
+		return 0, false
+	}
+	return index, true
+}
+
+// formatHandicapIndex shows plus handicaps the way golfers write them ("+1.2").
+func formatHandicapIndex(index float64) string {
+	if index < 0 {
+		return "+" + strconv.FormatFloat(-index, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(index, 'f', 1, 64)
+}
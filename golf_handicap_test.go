@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetScoring(t *testing.T) {
+	course := nip101g.Course{
+		Holes: []nip101g.CourseHole{
+			{Number: 1, Par: 4, Handicap: 3},
+			{Number: 2, Par: 3, Handicap: 1},
+			{Number: 3, Par: 5, Handicap: 2},
+		},
+		Tees:     []nip101g.CourseTee{{Name: "Blue", Rating: 13.1, Slope: 113}},
+		TotalPar: 12,
+	}
+
+	_, ok := newHandicapCourse(course, "red")
+	assert.False(t, ok)
+
+	hc, ok := newHandicapCourse(course, "blue")
+	require.True(t, ok)
+
+	// 4.0 × 113/113 + (13.1 − 12) = 5.1
+	assert.Equal(t, 5, hc.courseHandicap(4.0, 3))
+	assert.Equal(t, []int{1, 2, 2}, hc.strokesReceived(5, 3))
+	assert.Equal(t, []int{-1, 0, 0}, hc.strokesReceived(-1, 3))
+
+	ns := hc.netScoreFor(4.0, []int{5, 4, 0}, 9)
+	assert.Equal(t, []int{4, 2, 0}, ns.HoleScores)
+	assert.Equal(t, 3, ns.Received)
+	assert.Equal(t, 6, ns.Total)
+}
+
+func TestWHSIndex(t *testing.T) {
+	// 3 rounds: lowest differential minus 2.0
+	rounds := []WHSRound{{Differential: 14.2}, {Differential: 11.0}, {Differential: 12.5}}
+	assert.Equal(t, []int{1}, countingRounds(rounds))
+	index, ok := whsIndex(rounds)
+	require.True(t, ok)
+	assert.Equal(t, 9.0, index)
+
+	_, ok = whsIndex(rounds[:2])
+	assert.False(t, ok)
+
+	// best 8 of the most recent 20
+	var posted []WHSRound
+	for i := 0; i < 25; i++ {
+		posted = append(posted, WHSRound{Differential: float64(i)})
+	}
+	index, _ = whsIndex(posted)
+	assert.Equal(t, 8.5, index) // average of 5..12
+
+	// 113/125 × (90 − 71.5) = 16.72
+	assert.Equal(t, 16.7, scoreDifferential(90, 71.5, 125))
+
+	// soft cap halves the rise beyond 3.0, hard cap stops it at 5.0
+	capped, ok := applyCaps(14.0, 10.0)
+	assert.True(t, ok)
+	assert.Equal(t, 13.5, capped)
+	capped, _ = applyCaps(20.0, 10.0)
+	assert.Equal(t, 15.0, capped)
+	capped, ok = applyCaps(12.0, 10.0)
+	assert.False(t, ok)
+	assert.Equal(t, 12.0, capped)
+
+	hc := handicapCourse{Rating: 72, Slope: 113, Par: 72, HoleCount: 18, HolePars: make([]int, 18), StrokeIndexes: make([]int, 18)}
+	holes := make([]int, 18)
+	for i := range holes {
+		hc.HolePars[i] = 4
+		hc.StrokeIndexes[i] = i + 1
+		holes[i] = 4
+	}
+	holes[0] = 10
+	holes[17] = 9
+	// without an index: par + 5; with 1.0 only hole 1 gets a stroke, so 7 and 6
+	assert.Equal(t, 72-8+9+9, adjustedGrossScore(hc, holes, 0, false))
+	assert.Equal(t, 72-8+7+6, adjustedGrossScore(hc, holes, 1.0, true))
+}
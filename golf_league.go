@@ -0,0 +1,380 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// LeaguePageData is the season standings of a kind 31924 league.
+type LeaguePageData struct {
+	Title     string
+	Season    string
+	Image     string
+	Naddr     string
+	Standings []LeagueStanding
+	Events    []LeagueEvent // under way or over, in schedule order
+	Upcoming  []LeagueEvent
+	Points    []int // points by place, as declared or the default table
+	Best      int   // results counted per player, 0 for all of them
+}
+
+// LeagueStanding is one player's row of the season standings.
+type LeagueStanding struct {
+	Rank       string // "1", "T2"...
+	Player     PlayerData
+	Points     float64 // over the counted results
+	Events     int     // finished events played
+	Wins       int
+	BestFinish string // rank at the player's best event, e.g. "T3", "MC"
+
+	bestPlace int // 0 when the player never finished in a ranked place
+}
+
+// LeagueEvent is one tournament of the league schedule.
+type LeagueEvent struct {
+	Title    string
+	Date     string
+	Location string
+	Status   string
+	Naddr    string
+	Winners  []PlayerData // winner of every flight, or the leaders while under way
+	Players  int
+}
+
+// leagueFinish is where a player finished in one event and the points it earned.
+type leagueFinish struct {
+	player PlayerData
+	rank   string
+	place  int // 0 after a missed cut
+	points float64
+}
+
+// leagueBoardsParallel is how many of a league's leaderboards are loaded at
+// the same time.
+const leagueBoardsParallel = 4
+
+// buildLeaguePageData fetches the league's tournaments and adds up the points
+// of the finished ones. Tournaments that haven't started go on the schedule.
+func buildLeaguePageData(ctx context.Context, league *nip101g.League, naddr string) LeaguePageData {
+	lpd := LeaguePageData{
+		Title:  league.Title,
+		Season: league.Season,
+		Image:  league.Image,
+		Naddr:  naddr,
+		Points: league.Points,
+		Best:   league.Best,
+	}
+
+	tournaments := fetchLeagueTournaments(ctx, league.Tournaments)
+
+	// every event's leaderboard is loaded at the same time
+	events := make([]LeagueEvent, len(league.Tournaments))
+	finishes := make([][]leagueFinish, len(league.Tournaments))
+	upcoming := make([]bool, len(league.Tournaments))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, leagueBoardsParallel)
+	for i, coord := range league.Tournaments {
+		evt, ok := tournaments[coord]
+		if !ok {
+			continue
+		}
+		t, _ := nip101g.ParseTournament(*evt)
+		meta := newTournamentMetadata(t)
+		events[i] = newLeagueEvent(evt, meta)
+		if upcoming[i] = leagueEventUpcoming(meta); upcoming[i] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			events[i].Winners, finishes[i] = leagueEventResults(ctx, evt, meta, league.Points)
+			events[i].Players = len(finishes[i])
+			if meta.TournamentStatus != "complete" {
+				finishes[i] = nil // points are only given once the event is over
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, coord := range league.Tournaments {
+		switch {
+		case tournaments[coord] == nil:
+			continue // not found on the relay
+		case upcoming[i]:
+			lpd.Upcoming = append(lpd.Upcoming, events[i])
+		default:
+			lpd.Events = append(lpd.Events, events[i])
+		}
+	}
+	lpd.Standings = leagueStandings(finishes, league.Best)
+	return lpd
+}
+
+func newLeagueEvent(evt *nostr.Event, meta *TournamentMetadata) LeagueEvent {
+	le := LeagueEvent{
+		Title:    meta.Title,
+		Location: meta.Location,
+		Status:   meta.TournamentStatus,
+	}
+	if le.Title == "" {
+		le.Title = "Tournament"
+	}
+	if meta.StartUnix > 0 {
+		le.Date = time.Unix(meta.StartUnix, 0).Format("2006-01-02")
+	}
+	le.Naddr, _ = nip19.EncodeEntity(evt.PubKey, evt.Kind, evt.Tags.GetD(), []string{gambitRelay})
+	return le
+}
+
+// leagueEventUpcoming reports whether a tournament hasn't started yet.
+func leagueEventUpcoming(meta *TournamentMetadata) bool {
+	switch meta.TournamentStatus {
+	case "registration_open", "registration_closed":
+		return true
+	case "":
+		return meta.StartUnix > time.Now().Unix()
+	}
+	return false
+}
+
+// leagueEventResults loads the leaderboard of a tournament and gives every
+// player who teed off the points of their place. Every flight is ranked on
+// its own, and a match play knockout is ranked by the round players went out in.
+func leagueEventResults(ctx context.Context, evt *nostr.Event, meta *TournamentMetadata, points []int) (winners []PlayerData, finishes []leagueFinish) {
+	var flights [][]LeaderboardEntry
+	if meta.Bracket != nil {
+		bd := buildBracketData(ctx, meta.Bracket)
+		flights = [][]LeaderboardEntry{bracketFinishes(bd)}
+	} else {
+		board, _ := loadTournamentBoard(ctx, evt, meta, false)
+		for _, f := range board.flightSections(board.entries()) {
+			flights = append(flights, f.Players)
+		}
+	}
+
+	for _, players := range flights {
+		if len(players) > 0 && !players[0].IsDNS && (players[0].IsFinished || players[0].Total > 0) {
+			winners = append(winners, players[0].Player)
+		}
+		finishes = append(finishes, leagueFinishes(players, points)...)
+	}
+	return winners, finishes
+}
+
+// leagueFinishes gives the players of a ranked leaderboard the points of their
+// place. Tied players share the points of the places they take up.
+func leagueFinishes(players []LeaderboardEntry, points []int) []leagueFinish {
+	shares := tiedPlaceShares(players, func(place int) float64 {
+		if place > len(points) {
+			return 0
+		}
+		return float64(points[place-1])
+	})
+
+	var finishes []leagueFinish
+	for i, e := range players {
+		if e.IsDNS {
+			continue
+		}
+		f := leagueFinish{player: e.Player, rank: e.Rank, points: shares[i]}
+		if !e.MissedCut {
+			f.place, _ = strconv.Atoi(strings.TrimPrefix(e.Rank, "T"))
+		}
+		finishes = append(finishes, f)
+	}
+	return finishes
+}
+
+// bracketFinishes ranks the players of a finished knockout: the champion
+// first, the loser of the final second, the losers of the semifinals tied
+// third and so on.
+func bracketFinishes(bd BracketData) []LeaderboardEntry {
+	if bd.Champion == nil {
+		return nil
+	}
+	size := 2 * len(bd.Rounds[0].Matches)
+	players := []LeaderboardEntry{{Rank: "1", Player: *bd.Champion, IsFinished: true}}
+	for r := len(bd.Rounds); r >= 1; r-- {
+		var losers []PlayerData
+		for _, m := range bd.Rounds[r-1].Matches {
+			if m.Winner == 0 || m.A.Player == nil || m.B.Player == nil {
+				continue
+			}
+			losers = append(losers, *m.slot(3 - m.Winner).Player)
+		}
+		rank := strconv.Itoa(size>>r + 1)
+		if len(losers) > 1 {
+			rank = "T" + rank
+		}
+		for _, p := range losers {
+			players = append(players, LeaderboardEntry{Rank: rank, Player: p, IsFinished: true})
+		}
+	}
+	return players
+}
+
+// leagueStandings adds up the points of every player over the events, only
+// counting their best results when the league says so.
+func leagueStandings(events [][]leagueFinish, best int) []LeagueStanding {
+	byPlayer := make(map[string]*LeagueStanding)
+	results := make(map[string][]float64)
+	var standings []*LeagueStanding
+	for _, finishes := range events {
+		for _, f := range finishes {
+			pk := f.player.PubkeyHex
+			s, ok := byPlayer[pk]
+			if !ok {
+				s = &LeagueStanding{Player: f.player}
+				byPlayer[pk] = s
+				standings = append(standings, s)
+			}
+			s.Events++
+			results[pk] = append(results[pk], f.points)
+			if f.place == 1 {
+				s.Wins++
+			}
+			switch {
+			case f.place > 0 && (s.bestPlace == 0 || f.place < s.bestPlace):
+				s.bestPlace = f.place
+				s.BestFinish = f.rank
+			case s.BestFinish == "":
+				s.BestFinish = f.rank
+			}
+		}
+	}
+
+	list := make([]LeagueStanding, len(standings))
+	for i, s := range standings {
+		points := results[s.Player.PubkeyHex]
+		slices.SortFunc(points, func(a, b float64) int { return cmp.Compare(b, a) })
+		if best > 0 && len(points) > best {
+			points = points[:best]
+		}
+		for _, p := range points {
+			s.Points += p
+		}
+		list[i] = *s
+	}
+
+	slices.SortStableFunc(list, func(a, b LeagueStanding) int {
+		if c := cmp.Compare(roundPoints(b.Points), roundPoints(a.Points)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Wins, a.Wins); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Player.DisplayName, b.Player.DisplayName)
+	})
+	for i := range list {
+		switch {
+		case i > 0 && roundPoints(list[i].Points) == roundPoints(list[i-1].Points):
+			list[i].Rank = list[i-1].Rank
+		case i+1 < len(list) && roundPoints(list[i].Points) == roundPoints(list[i+1].Points):
+			list[i].Rank = "T" + strconv.Itoa(i+1)
+		default:
+			list[i].Rank = strconv.Itoa(i + 1)
+		}
+	}
+	return list
+}
+
+// roundPoints rounds points to the hundredth shown on the page, so that shares
+// of a tie compare equal.
+func roundPoints(p float64) float64 {
+	return math.Round(p * 100)
+}
+
+func formatLeaguePoints(p float64) string {
+	return strconv.FormatFloat(roundPoints(p)/100, 'f', -1, 64)
+}
+
+// fetchLeagueTournaments queries relay.gambit.golf for the tournaments of a
+// league by coordinate, keeping the latest version of each.
+func fetchLeagueTournaments(ctx context.Context, coords []string) map[string]*nostr.Event {
+	var authors, dTags []string
+	for _, coord := range coords {
+		parts := strings.SplitN(coord, ":", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		authors = append(authors, parts[1])
+		dTags = append(dTags, parts[2])
+	}
+	if len(authors) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for league tournaments")
+		return nil
+	}
+
+	filter := nostr.Filter{
+		Kinds:   []int{31923},
+		Authors: authors,
+		Tags:    nostr.TagMap{"d": dTags},
+	}
+
+	ch, err := relay.QueryEvents(ctx, filter)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to query league tournaments")
+		return nil
+	}
+
+	tournaments := make(map[string]*nostr.Event, len(coords))
+	for evt := range ch {
+		coord := tournamentCoord(evt)
+		if prev, ok := tournaments[coord]; !ok || evt.CreatedAt > prev.CreatedAt {
+			tournaments[coord] = evt
+		}
+	}
+	return tournaments
+}
+
+// leagueOGDescription names the leader and how far into the season the league is.
+func leagueOGDescription(lpd LeaguePageData) string {
+	played := strconv.Itoa(len(lpd.Events)) + " of " + strconv.Itoa(len(lpd.Events)+len(lpd.Upcoming)) + " events played"
+	if len(lpd.Standings) == 0 {
+		return played
+	}
+	leader := lpd.Standings[0]
+	return "Leader: " + leader.Player.DisplayName + " (" + formatLeaguePoints(leader.Points) + " pts) · " + played
+}
+
+// leaguePointsLabel lists the points of the first places, e.g. "100, 60, 40"
+// or "500, 300, 190, 135, 110 … 1 for 50th".
+func leaguePointsLabel(points []int) string {
+	shown := points[:min(5, len(points))]
+	label := make([]string, len(shown))
+	for i, p := range shown {
+		label[i] = strconv.Itoa(p)
+	}
+	if len(points) > len(shown) {
+		return strings.Join(label, ", ") + " … " + strconv.Itoa(points[len(points)-1]) + " for " + ordinal(len(points))
+	}
+	return strings.Join(label, ", ")
+}
+
+func leagueEventWinnersLabel(le LeagueEvent) string {
+	names := make([]string, len(le.Winners))
+	for i, p := range le.Winners {
+		names[i] = p.DisplayName
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeagueStandings(t *testing.T) {
+	player := func(pk string) PlayerData { return PlayerData{PubkeyHex: pk, DisplayName: pk} }
+	points := []int{100, 60, 40, 20}
+
+	// T2 share 60 and 40, the missed cut and the player who didn't start get nothing
+	first := leagueFinishes([]LeaderboardEntry{
+		{Rank: "1", Player: player("ann"), IsFinished: true},
+		{Rank: "T2", Player: player("bob"), IsFinished: true},
+		{Rank: "T2", Player: player("cat"), IsFinished: true},
+		{Rank: "MC", Player: player("dan"), IsFinished: true, MissedCut: true},
+		{Rank: "-", Player: player("eve"), IsDNS: true},
+	}, points)
+	require.Len(t, first, 4)
+	assert.Equal(t, 50.0, first[1].points)
+	assert.Equal(t, 0, first[3].place)
+
+	second := leagueFinishes([]LeaderboardEntry{
+		{Rank: "1", Player: player("bob"), IsFinished: true},
+		{Rank: "2", Player: player("dan"), IsFinished: true},
+		{Rank: "3", Player: player("ann"), IsFinished: true},
+	}, points)
+	third := leagueFinishes([]LeaderboardEntry{
+		{Rank: "1", Player: player("dan"), IsFinished: true},
+	}, points)
+
+	standings := leagueStandings([][]leagueFinish{first, second, nil, third}, 0)
+	require.Len(t, standings, 4)
+	assert.Equal(t, "dan", standings[0].Player.PubkeyHex)
+	assert.Equal(t, 160.0, standings[0].Points)
+	assert.Equal(t, "1", standings[0].BestFinish)
+	assert.Equal(t, 3, standings[0].Events)
+	assert.Equal(t, "bob", standings[1].Player.PubkeyHex) // 150 with a win, ahead of ann on 140
+	assert.Equal(t, "2", standings[1].Rank)
+	assert.Equal(t, "cat", standings[3].Player.PubkeyHex)
+	assert.Equal(t, "T2", standings[3].BestFinish)
+
+	// with only the best result counting ann, bob and dan tie on 100
+	standings = leagueStandings([][]leagueFinish{first, second, third}, 1)
+	assert.Equal(t, []string{"T1", "T1", "T1", "4"}, []string{standings[0].Rank, standings[1].Rank, standings[2].Rank, standings[3].Rank})
+	assert.Equal(t, "50", formatLeaguePoints(standings[3].Points))
+
+	assert.Equal(t, "100, 60, 40, 20", leaguePointsLabel(points))
+	assert.Equal(t, "500, 300, 190, 135, 110 … 1 for 50th", leaguePointsLabel(nip101g.DefaultLeaguePoints))
+}
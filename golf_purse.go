@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// PurseData is the prize money section of a tournament page.
+type PurseData struct {
+	Total    string // "" when the purse only has fixed payouts
+	Unit     string
+	Places   []PayoutPlace
+	SidePots []SidePotData
+}
+
+// PayoutPlace is one row of the payout table.
+type PayoutPlace struct {
+	Place   string // "1st", "2nd"...
+	Flight  string // "" when the place pays in every flight
+	Percent string // "50%", "" for fixed amounts
+	Amount  string
+}
+
+// SidePotData is a side pot and, once decided, its winner.
+type SidePotData struct {
+	Name   string
+	Amount string
+	Winner *PlayerData
+}
+
+// currencySymbols are written before fiat amounts instead of the currency code.
+var currencySymbols = map[string]string{"USD": "$", "EUR": "€", "GBP": "£"}
+
+// buildPurseData prepares the payout table and side pots for display.
+func buildPurseData(purse *nip101g.Purse, profiles map[string]PlayerData) *PurseData {
+	pd := &PurseData{Unit: purse.Unit}
+	if purse.Amount > 0 {
+		pd.Total = formatPurseAmount(purse.Amount, purse.Unit)
+	}
+	for _, p := range purse.Payouts {
+		place := PayoutPlace{
+			Place:  ordinal(p.Place),
+			Flight: p.Flight,
+			Amount: formatPurseAmount(p.Value(purse.Amount), purse.Unit),
+		}
+		if p.Percent > 0 {
+			place.Percent = strconv.FormatFloat(p.Percent, 'f', -1, 64) + "%"
+		}
+		pd.Places = append(pd.Places, place)
+	}
+	for _, sp := range purse.SidePots {
+		pot := SidePotData{Name: sp.Name, Amount: formatPurseAmount(sp.Amount, purse.Unit)}
+		if sp.Winner != "" {
+			winner, ok := profiles[sp.Winner]
+			if !ok {
+				npub, _ := nip19.EncodePublicKey(sp.Winner)
+				winner = PlayerData{PubkeyHex: sp.Winner, Npub: npub, DisplayName: shortenString(npub, 8, 4)}
+			}
+			pot.Winner = &winner
+		}
+		pd.SidePots = append(pd.SidePots, pot)
+	}
+	return pd
+}
+
+// applyPayouts pays out the places of a flight's final leaderboard. Payouts
+// declared for the flight by name take precedence over those for every flight.
+// Tied players pool the payouts of the places they share and split them evenly.
+func applyPayouts(flight *FlightData, purse *nip101g.Purse) {
+	payouts := make(map[int]float64)
+	for _, p := range purse.Payouts {
+		if p.Flight == flight.Name && flight.Name != "" {
+			payouts[p.Place] = p.Value(purse.Amount)
+		}
+	}
+	if len(payouts) == 0 {
+		for _, p := range purse.Payouts {
+			if p.Flight == "" {
+				payouts[p.Place] = p.Value(purse.Amount)
+			}
+		}
+	}
+
+	shares := tiedPlaceShares(flight.Players, func(place int) float64 { return payouts[place] })
+	for i, share := range shares {
+		if purse.Unit == "sats" {
+			share = math.Floor(share)
+		}
+		flight.Players[i].Payout = share
+	}
+}
+
+// tiedPlaceShares gives every player of a ranked leaderboard (best first) what
+// their place is worth. Tied players pool the values of the places they take
+// up and split them evenly. Players who missed the cut or didn't start get
+// nothing.
+func tiedPlaceShares(players []LeaderboardEntry, value func(place int) float64) []float64 {
+	shares := make([]float64, len(players))
+	for start := 0; start < len(players); {
+		if players[start].IsDNS || players[start].MissedCut {
+			break // these sort last
+		}
+		end := start + 1
+		for end < len(players) && players[end].Rank == players[start].Rank {
+			end++
+		}
+		pool := 0.0
+		for place := start + 1; place <= end; place++ {
+			pool += value(place)
+		}
+		for i := start; i < end; i++ {
+			shares[i] = pool / float64(end-start)
+		}
+		start = end
+	}
+	return shares
+}
+
+// formatPurseAmount writes an amount in sats with thousands separators, or in
+// a currency with two decimals.
+func formatPurseAmount(amount float64, unit string) string {
+	if unit == "sats" {
+		return groupThousands(int64(math.Floor(amount))) + " sats"
+	}
+	// rounded once, so 99.996 carries over to 100.00
+	c := int64(math.Round(amount * 100))
+	value := fmt.Sprintf("%s.%02d", groupThousands(c/100), c%100)
+	code := strings.ToUpper(unit)
+	if symbol, ok := currencySymbols[code]; ok {
+		return symbol + value
+	}
+	return value + " " + code
+}
+
+func groupThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// ordinal writes a place as "1st", "2nd", "3rd", "11th"...
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func leaderboardPayoutDisplay(e LeaderboardEntry, unit string) string {
+	if e.Payout == 0 {
+		return ""
+	}
+	return formatPurseAmount(e.Payout, unit)
+}
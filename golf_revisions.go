@@ -0,0 +1,294 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiatjaf/eventstore"
+	"github.com/fiatjaf/eventstore/badger"
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// CourseRevision is one version of a 33501 and what changed from the one
+// before it.
+type CourseRevision struct {
+	Nevent  string
+	Date    time.Time
+	Course  nip101g.Course
+	Changes []CourseChange // empty for the first revision we have
+}
+
+// CourseChange is a value that differs between two revisions of a course.
+// From is empty for something added and To for something removed.
+type CourseChange struct {
+	Label string
+	From  string
+	To    string
+}
+
+// courseMaxRevisions bounds how many events sharing a course's d tag are read
+// when looking for its revisions.
+const courseMaxRevisions = 10000
+
+// courseStore keeps every revision of the courses we see. It is apart from
+// the event cache, where a newer version of an addressable event replaces the
+// older ones, and only ever has events added to it.
+var courseStore *badger.BadgerBackend
+
+func initCourseStore() func() {
+	courseStore = &badger.BadgerBackend{
+		Path:     s.CourseStorePath,
+		MaxLimit: DB_MAX_LIMIT,
+	}
+	if err := courseStore.Init(); err != nil {
+		panic(err)
+	}
+	return courseStore.Close
+}
+
+// storeCourseRevision keeps a 33501 we've seen among its course's revisions.
+func storeCourseRevision(ctx context.Context, evt *nostr.Event) {
+	if courseStore == nil || evt.Kind != nip101g.KindCourse {
+		return
+	}
+	if err := courseStore.SaveEvent(ctx, evt); err != nil && !errors.Is(err, eventstore.ErrDupEvent) {
+		log.Warn().Err(err).Str("id", evt.ID).Msg("failed to store course revision")
+	}
+}
+
+// courseRevisionEvents returns the revisions we have of the course at the
+// coordinate, oldest first.
+func courseRevisionEvents(ctx context.Context, courseCoord string) []*nostr.Event {
+	parts := strings.Split(courseCoord, ":")
+	if courseStore == nil || len(parts) < 3 || parts[0] != "33501" {
+		return nil
+	}
+
+	// revisions are found by d tag and then by author, as a filter with both
+	// is taken to match a single addressable event and gets only the latest
+	var events []*nostr.Event
+	err := pageEvents(ctx, courseStore.QueryEvents, nostr.Filter{
+		Kinds: []int{nip101g.KindCourse},
+		Tags:  nostr.TagMap{"d": {parts[2]}},
+		Limit: DB_MAX_LIMIT,
+	}, courseMaxRevisions, func(evt *nostr.Event) {
+		if evt.PubKey == parts[1] {
+			events = append(events, evt)
+		}
+	})
+	if err != nil && !errors.Is(err, errPageLimit) {
+		log.Warn().Err(err).Str("course", courseCoord).Msg("failed to query course revisions")
+	}
+	slices.SortFunc(events, func(a, b *nostr.Event) int { return cmp.Compare(a.CreatedAt, b.CreatedAt) })
+	return events
+}
+
+// fetchCourseAt returns the course at the coordinate as it was at the given
+// time: the last revision published before it, or the oldest one we have
+// when the time is before all of them. Returns false if unavailable.
+func fetchCourseAt(ctx context.Context, courseCoord string, at time.Time) (nip101g.Course, bool) {
+	evt := courseRevisionAt(fetchCourseRevisions(ctx, courseCoord), at)
+	if evt == nil {
+		return nip101g.Course{}, false
+	}
+
+	course, _ := nip101g.ParseCourse(*evt)
+	return course, true
+}
+
+// fetchCourseRevisions fetches the latest version of the course at the
+// coordinate and returns every revision we have of it, oldest first.
+func fetchCourseRevisions(ctx context.Context, courseCoord string) []*nostr.Event {
+	latest := fetchCourseEvent(ctx, courseCoord)
+	revisions := courseRevisionEvents(ctx, courseCoord)
+	if len(revisions) == 0 && latest != nil {
+		// without the store we only know the latest version
+		revisions = []*nostr.Event{latest}
+	}
+	return revisions
+}
+
+// courseRevisionAt picks the revision that was current at the given time out
+// of revisions sorted oldest first.
+func courseRevisionAt(revisions []*nostr.Event, at time.Time) *nostr.Event {
+	if len(revisions) == 0 {
+		return nil
+	}
+	current := revisions[0]
+	for _, evt := range revisions[1:] {
+		if evt.CreatedAt.Time().After(at) {
+			break
+		}
+		current = evt
+	}
+	return current
+}
+
+// roundTime is when a round was played: the end of its date when it has one,
+// or when the 1501 was published.
+func roundTime(date string, published time.Time) time.Time {
+	if t, err := time.Parse("2006-01-02", formatDate(date)); err == nil {
+		return t.Add(24*time.Hour - time.Second)
+	}
+	return published
+}
+
+// buildCourseRevisions diffs every revision with the one before it, newest
+// first.
+func buildCourseRevisions(events []*nostr.Event) []CourseRevision {
+	revisions := make([]CourseRevision, len(events))
+	for i, evt := range events {
+		course, _ := nip101g.ParseCourse(*evt)
+		revisions[i] = CourseRevision{Date: evt.CreatedAt.Time(), Course: course}
+		revisions[i].Nevent, _ = nip19.EncodeEvent(evt.ID, []string{gambitRelay}, evt.PubKey)
+		if i > 0 {
+			revisions[i].Changes = diffCourses(revisions[i-1].Course, course)
+		}
+	}
+	slices.Reverse(revisions)
+	return revisions
+}
+
+// diffCourses lists what changed from one revision of a course to the next:
+// the name, the par and handicap of every hole, the tees and the yardages.
+func diffCourses(prev, next nip101g.Course) []CourseChange {
+	var changes []CourseChange
+	change := func(label, from, to string) {
+		if from != to {
+			changes = append(changes, CourseChange{Label: label, From: from, To: to})
+		}
+	}
+
+	change("Name", prev.Title, next.Title)
+	change("Location", prev.Location, next.Location)
+
+	holes := func(c nip101g.Course) map[int]nip101g.CourseHole {
+		m := make(map[int]nip101g.CourseHole, len(c.Holes))
+		for _, h := range c.Holes {
+			m[h.Number] = h
+		}
+		return m
+	}
+	prevHoles, nextHoles := holes(prev), holes(next)
+	for _, n := range sortedKeys(prevHoles, nextHoles) {
+		p, inPrev := prevHoles[n]
+		h, inNext := nextHoles[n]
+		label := "Hole " + strconv.Itoa(n)
+		switch {
+		case !inPrev:
+			change(label, "", fmt.Sprintf("par %d, handicap %d", h.Par, h.Handicap))
+		case !inNext:
+			change(label, fmt.Sprintf("par %d, handicap %d", p.Par, p.Handicap), "")
+		default:
+			change(label+" par", strconv.Itoa(p.Par), strconv.Itoa(h.Par))
+			change(label+" handicap", strconv.Itoa(p.Handicap), strconv.Itoa(h.Handicap))
+		}
+	}
+	change("Total par", strconv.Itoa(prev.TotalPar), strconv.Itoa(next.TotalPar))
+
+	tees := func(c nip101g.Course) map[string]nip101g.CourseTee {
+		m := make(map[string]nip101g.CourseTee, len(c.Tees))
+		for _, t := range c.Tees {
+			m[t.Name] = t
+		}
+		return m
+	}
+	prevTees, nextTees := tees(prev), tees(next)
+	for _, name := range sortedKeys(prevTees, nextTees) {
+		p, inPrev := prevTees[name]
+		t, inNext := nextTees[name]
+		label := name + " tee"
+		switch {
+		case !inPrev:
+			change(label, "", courseTeeSummary(t))
+		case !inNext:
+			change(label, courseTeeSummary(p), "")
+		default:
+			change(label+" rating", strconv.FormatFloat(p.Rating, 'f', 1, 64), strconv.FormatFloat(t.Rating, 'f', 1, 64))
+			change(label+" slope", strconv.Itoa(p.Slope), strconv.Itoa(t.Slope))
+		}
+	}
+
+	type yardageKey struct {
+		tee  string
+		hole int
+	}
+	yardages := func(c nip101g.Course) map[yardageKey]int {
+		m := make(map[yardageKey]int, len(c.Yardages))
+		for _, y := range c.Yardages {
+			m[yardageKey{y.Tee, y.Hole}] = y.Yards
+		}
+		return m
+	}
+	prevYards, nextYards := yardages(prev), yardages(next)
+	keys := sortedKeysFunc(prevYards, nextYards, func(a, b yardageKey) int {
+		if c := cmp.Compare(a.tee, b.tee); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.hole, b.hole)
+	})
+	for _, k := range keys {
+		from, to := "", ""
+		if y, ok := prevYards[k]; ok {
+			from = strconv.Itoa(y) + " yds"
+		}
+		if y, ok := nextYards[k]; ok {
+			to = strconv.Itoa(y) + " yds"
+		}
+		change("Hole "+strconv.Itoa(k.hole)+" from the "+k.tee+" tee", from, to)
+	}
+
+	return changes
+}
+
+func courseTeeSummary(t nip101g.CourseTee) string {
+	return strconv.FormatFloat(t.Rating, 'f', 1, 64) + " / " + strconv.Itoa(t.Slope)
+}
+
+// sortedKeys returns the keys found in either map, in order.
+func sortedKeys[K cmp.Ordered, V any](a, b map[K]V) []K {
+	return sortedKeysFunc(a, b, cmp.Compare[K])
+}
+
+func sortedKeysFunc[K comparable, V any](a, b map[K]V, compare func(K, K) int) []K {
+	keys := make([]K, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, compare)
+	return keys
+}
+
+// Course history template helper functions
+
+func courseRevisionSummary(c nip101g.Course) string {
+	summary := strconv.Itoa(len(c.Holes)) + " holes, par " + strconv.Itoa(c.TotalPar)
+	if len(c.Tees) > 0 {
+		var names []string
+		for _, t := range c.Tees {
+			names = append(names, t.Name)
+		}
+		summary += ", tees " + strings.Join(names, ", ")
+	}
+	return summary
+}
+
+func courseChangeValue(v string) string {
+	if v == "" {
+		return "—"
+	}
+	return v
+}
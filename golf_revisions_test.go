@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/eventstore/badger"
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfCourseRevisions(t *testing.T) {
+	prev := nip101g.Course{
+		Title:    "Pines",
+		Holes:    []nip101g.CourseHole{{Number: 1, Par: 4, Handicap: 1}, {Number: 2, Par: 3, Handicap: 2}, {Number: 3, Par: 5, Handicap: 3}},
+		TotalPar: 12,
+		Tees:     []nip101g.CourseTee{{Name: "Blue", Rating: 70.1, Slope: 125}, {Name: "Red", Rating: 68, Slope: 118}},
+		Yardages: []nip101g.CourseYardage{{Hole: 1, Tee: "Blue", Yards: 380}, {Hole: 2, Tee: "Blue", Yards: 150}},
+	}
+	next := nip101g.Course{
+		Title:    "Pines",
+		Holes:    []nip101g.CourseHole{{Number: 1, Par: 4, Handicap: 2}, {Number: 2, Par: 4, Handicap: 1}},
+		TotalPar: 8,
+		Tees:     []nip101g.CourseTee{{Name: "Blue", Rating: 70.8, Slope: 125}, {Name: "Gold", Rating: 72, Slope: 131}},
+		Yardages: []nip101g.CourseYardage{{Hole: 1, Tee: "Blue", Yards: 380}, {Hole: 2, Tee: "Blue", Yards: 320}},
+	}
+	assert.Equal(t, []CourseChange{
+		{"Hole 1 handicap", "1", "2"},
+		{"Hole 2 par", "3", "4"},
+		{"Hole 2 handicap", "2", "1"},
+		{"Hole 3", "par 5, handicap 3", ""},
+		{"Total par", "12", "8"},
+		{"Blue tee rating", "70.1", "70.8"},
+		{"Gold tee", "", "72.0 / 131"},
+		{"Red tee", "68.0 / 118", ""},
+		{"Hole 2 from the Blue tee", "150 yds", "320 yds"},
+	}, diffCourses(prev, next))
+	assert.Empty(t, diffCourses(next, next))
+
+	// a round is matched against the course as it was at the end of its date
+	published := time.Date(2026, 5, 3, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 5, 1, 23, 59, 59, 0, time.UTC), roundTime("2026-05-01", published))
+	assert.Equal(t, published, roundTime("", published))
+
+	pk := strings.Repeat("cd", 32)
+	revision := func(at time.Time, par string) *nostr.Event {
+		evt := &nostr.Event{PubKey: pk, Kind: nip101g.KindCourse, CreatedAt: nostr.Timestamp(at.Unix()), Tags: nostr.Tags{
+			{"d", "pines"}, {"title", "Pines"}, {"hole", "1", par, "1"},
+		}}
+		evt.ID = evt.GetID()
+		return evt
+	}
+	first := revision(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "4")
+	second := revision(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "5")
+
+	assert.Nil(t, courseRevisionAt(nil, published))
+	assert.Equal(t, first, courseRevisionAt([]*nostr.Event{first, second}, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, first, courseRevisionAt([]*nostr.Event{first, second}, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, second, courseRevisionAt([]*nostr.Event{first, second}, published))
+
+	// the store keeps every revision, where the event cache would replace them
+	courseStore = &badger.BadgerBackend{Path: t.TempDir(), MaxLimit: 100}
+	require.NoError(t, courseStore.Init())
+	defer func() {
+		courseStore.Close()
+		courseStore = nil
+	}()
+	storeCourseRevision(t.Context(), second)
+	storeCourseRevision(t.Context(), first)
+	storeCourseRevision(t.Context(), second)
+
+	// another author's course with the same d tag is not a revision of this one
+	other := revision(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "3")
+	other.PubKey = strings.Repeat("ef", 32)
+	other.ID = other.GetID()
+	storeCourseRevision(t.Context(), other)
+
+	events := courseRevisionEvents(t.Context(), "33501:"+pk+":pines")
+	require.Len(t, events, 2)
+	assert.Equal(t, first.ID, events[0].ID)
+	assert.Equal(t, second.ID, events[1].ID)
+
+	revisions := buildCourseRevisions(events)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, second.CreatedAt.Time(), revisions[0].Date)
+	assert.Equal(t, []CourseChange{{"Hole 1 par", "4", "5"}, {"Total par", "4", "5"}}, revisions[0].Changes)
+	assert.Empty(t, revisions[1].Changes)
+}
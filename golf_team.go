@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// TeamScoreData is one team's gross score, aggregated hole by hole from its
+// members' scorecards according to the format being played.
+type TeamScoreData struct {
+	Index      int // position of the team tag, used for stable HTML ids
+	Name       string
+	Members    []PlayerData
+	HoleScores []int // team score per hole (index 0 = hole 1, 0 = not counted yet)
+	Total      int
+	Par        int // par of the holes counted so far, 0 when hole pars are unknown
+	ScoreToPar int
+	Thru       int // holes counted
+	HoleCount  int
+	Rank       string // "1", "T2", "-" before the team has a score
+}
+
+// teamRuleLabel says how team scores are put together for a format.
+func teamRuleLabel(format string) string {
+	switch format {
+	case nip101g.FormatBestBall:
+		return "Best ball"
+	case nip101g.FormatScramble:
+		return "Scramble"
+	case nip101g.FormatAlternateShot:
+		return "Alternate shot"
+	}
+	return "Aggregate"
+}
+
+// teamHoleScore combines the members' scores on one hole (0 = not played):
+// the lowest for best ball, the team's single ball for scramble and alternate
+// shot (whichever member recorded it first in team order), and the sum once
+// everyone has played the hole for any other format.
+func teamHoleScore(format string, scores []int) int {
+	team := 0
+	for _, s := range scores {
+		switch format {
+		case nip101g.FormatBestBall:
+			if s > 0 && (team == 0 || s < team) {
+				team = s
+			}
+		case nip101g.FormatScramble, nip101g.FormatAlternateShot:
+			if s > 0 {
+				return s
+			}
+		default:
+			if s == 0 {
+				return 0
+			}
+			team += s
+		}
+	}
+	return team
+}
+
+// computeTeamScores aggregates the hole scores of every team's members
+// (holes maps a member pubkey to their per-hole scores) and ranks the teams.
+// The result is in leaderboard order; Index keeps the declared order.
+func computeTeamScores(teams []nip101g.Team, format string, profiles map[string]PlayerData, holes map[string][]int, pars []int, holeCount int) []TeamScoreData {
+	result := make([]TeamScoreData, len(teams))
+	for i, team := range teams {
+		ts := TeamScoreData{
+			Index:      i,
+			Name:       team.Name,
+			HoleScores: make([]int, holeCount),
+			HoleCount:  holeCount,
+		}
+		for _, pk := range team.Members {
+			pd, ok := profiles[pk]
+			if !ok {
+				npub, _ := nip19.EncodePublicKey(pk)
+				pd = PlayerData{PubkeyHex: pk, Npub: npub, DisplayName: shortenString(npub, 8, 4)}
+			}
+			ts.Members = append(ts.Members, pd)
+		}
+
+		multiplier := 1 // an aggregate plays against par once per member
+		if teamRuleLabel(format) == "Aggregate" {
+			multiplier = len(team.Members)
+		}
+		hasPars := len(pars) >= holeCount
+		for h := 0; h < holeCount; h++ {
+			scores := make([]int, len(team.Members))
+			for m, pk := range team.Members {
+				if h < len(holes[pk]) {
+					scores[m] = holes[pk][h]
+				}
+			}
+			s := teamHoleScore(format, scores)
+			if s == 0 {
+				continue
+			}
+			ts.HoleScores[h] = s
+			ts.Total += s
+			ts.Thru++
+			if hasPars {
+				ts.Par += pars[h] * multiplier
+			}
+		}
+		if ts.Par > 0 {
+			ts.ScoreToPar = ts.Total - ts.Par
+		}
+		result[i] = ts
+	}
+
+	rankTeams(result)
+	return result
+}
+
+// addTeamRounds adds another round's team scores to the running totals of a
+// multi-round event, matching teams by Index, and ranks them again. Hole
+// scores are those of the latest round a team has played.
+func addTeamRounds(totals []TeamScoreData, round []TeamScoreData) []TeamScoreData {
+	byIndex := make(map[int]TeamScoreData, len(round))
+	for _, t := range round {
+		byIndex[t.Index] = t
+	}
+	for i, t := range totals {
+		r := byIndex[t.Index]
+		if r.Thru == 0 {
+			continue
+		}
+		t.HoleScores = r.HoleScores
+		t.Total += r.Total
+		t.Par += r.Par
+		t.ScoreToPar += r.ScoreToPar
+		t.Thru = r.Thru
+		t.HoleCount = r.HoleCount
+		totals[i] = t
+	}
+	rankTeams(totals)
+	return totals
+}
+
+// teamRankScore is what teams are ranked by: to par when hole pars are known,
+// otherwise the raw total.
+func teamRankScore(t TeamScoreData) int {
+	if t.Par > 0 {
+		return t.ScoreToPar
+	}
+	return t.Total
+}
+
+// rankTeams sorts teams with a score first, lowest score on top, and gives
+// tied teams a shared "T" rank.
+func rankTeams(teams []TeamScoreData) {
+	sort.SliceStable(teams, func(i, j int) bool {
+		si, sj := teams[i].Thru > 0, teams[j].Thru > 0
+		if si != sj {
+			return si
+		}
+		if teamRankScore(teams[i]) != teamRankScore(teams[j]) {
+			return teamRankScore(teams[i]) < teamRankScore(teams[j])
+		}
+		return teams[i].Index < teams[j].Index
+	})
+
+	for i := range teams {
+		if teams[i].Thru == 0 {
+			teams[i].Rank = "-"
+			continue
+		}
+		if i > 0 && teams[i-1].Thru > 0 && teamRankScore(teams[i]) == teamRankScore(teams[i-1]) {
+			teams[i].Rank = teams[i-1].Rank
+			continue
+		}
+		teams[i].Rank = strconv.Itoa(i + 1)
+	}
+	for i := range teams {
+		tied := (i > 0 && teams[i-1].Rank == teams[i].Rank) || (i+1 < len(teams) && teams[i+1].Rank == teams[i].Rank)
+		if teams[i].Rank != "-" && tied {
+			teams[i].Rank = "T" + teams[i].Rank
+		}
+	}
+}
+
+func teamScoreDisplay(t TeamScoreData) string {
+	if t.Thru == 0 {
+		return "-"
+	}
+	if t.Par == 0 {
+		return strconv.Itoa(t.Total)
+	}
+	return formatScoreToPar(t.ScoreToPar)
+}
+
+func teamScoreClass(t TeamScoreData) string {
+	if t.Thru == 0 || t.Par == 0 {
+		return "font-bold font-mono text-gray-900"
+	}
+	return "font-bold font-mono " + totalScoreClass(t.ScoreToPar)
+}
+
+func teamThruDisplay(t TeamScoreData) string {
+	switch {
+	case t.Thru == 0:
+		return "-"
+	case t.Thru == t.HoleCount:
+		return "F"
+	}
+	return strconv.Itoa(t.Thru)
+}
+
+// teamCellID is the HTML id of one field of a team row; i is the team's Index.
+func teamCellID(i int, field string) string {
+	return fmt.Sprintf("team-%d-%s", i, field)
+}
+
+// teamCells lists the values of the team leaderboard the live streams keep
+// up to date.
+func teamCells(teams []TeamScoreData) []roundCell {
+	var cells []roundCell
+	for _, t := range teams {
+		cells = append(cells,
+			roundCell{ID: teamCellID(t.Index, "rank"), Text: t.Rank},
+			roundCell{ID: teamCellID(t.Index, "score"), Text: teamScoreDisplay(t), Class: teamScoreClass(t)},
+			roundCell{ID: teamCellID(t.Index, "total"), Text: strconv.Itoa(t.Total)},
+			roundCell{ID: teamCellID(t.Index, "thru"), Text: teamThruDisplay(t)},
+		)
+	}
+	return cells
+}
+
+// diffCells returns the cells of next that differ from prev.
+func diffCells(prev, next []roundCell) []roundCell {
+	old := make(map[string]roundCell, len(prev))
+	for _, c := range prev {
+		old[c.ID] = c
+	}
+	var changed []roundCell
+	for _, c := range next {
+		if old[c.ID] != c {
+			changed = append(changed, c)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamScores(t *testing.T) {
+	teams := []nip101g.Team{
+		{Name: "Eagles", Members: []string{"aa", "bb"}},
+		{Name: "Hawks", Members: []string{"cc", "dd"}},
+	}
+	holes := map[string][]int{
+		"aa": {4, 5, 0},
+		"bb": {5, 3, 0},
+		"cc": {4, 4, 4},
+		"dd": {0, 0, 0},
+	}
+	pars := []int{4, 4, 4}
+
+	best := computeTeamScores(teams, nip101g.FormatBestBall, nil, holes, pars, 3)
+	assert.Equal(t, "Eagles", best[0].Name)
+	assert.Equal(t, []int{4, 3, 0}, best[0].HoleScores)
+	assert.Equal(t, "-1", teamScoreDisplay(best[0]))
+	assert.Equal(t, "2", teamThruDisplay(best[0]))
+	assert.Equal(t, "F", teamThruDisplay(best[1]))
+	assert.Equal(t, "2", best[1].Rank)
+
+	// scramble: the first member with a score carries the team ball
+	scramble := computeTeamScores(teams, nip101g.FormatScramble, nil, holes, pars, 3)
+	assert.Equal(t, []int{4, 5, 0}, scramble[1].HoleScores)
+	assert.Equal(t, "Hawks", scramble[0].Name)
+
+	// aggregate: a hole counts once every member has played it
+	agg := computeTeamScores(teams, "", nil, holes, pars, 3)
+	assert.Equal(t, []int{9, 8, 0}, agg[0].HoleScores)
+	assert.Equal(t, 1, agg[0].ScoreToPar)
+	assert.Equal(t, "-", agg[1].Rank)
+}
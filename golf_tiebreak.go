@@ -0,0 +1,141 @@
+package main
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+
+	"github.com/fiatjaf/njump/nip101g"
+)
+
+// tiebreakKeys is what a player's tie with others on the leaderboard can be
+// broken on.
+type tiebreakKeys struct {
+	holes   []int // final round hole by hole, net of strokes received on the net leaderboard
+	playoff []int // strokes on each playoff hole, in order
+}
+
+// countbackSteps are compared in order: the last 9, 6, 3 holes, then the last hole.
+var countbackSteps = []struct {
+	holes int
+	label string
+}{
+	{9, "Back 9"},
+	{6, "Back 6"},
+	{3, "Back 3"},
+	{1, "Last hole"},
+}
+
+// tiebreakKeys collects a player's final round scores and playoff holes.
+func (b *tournamentBoard) tiebreakKeys(pk string, entry LeaderboardEntry) tiebreakKeys {
+	key := playerRound{pk, b.rounds}
+	sc := b.scorecard(key)
+
+	holeCount := nip101g.DefaultHoleCount
+	var round nip101g.Round
+	if initEvt, ok := b.initByRound[key]; ok {
+		round, _ = nip101g.ParseRound(*initEvt)
+		holeCount = round.HoleCount()
+	}
+
+	keys := tiebreakKeys{holes: scorecardHoles(sc, holeCount)}
+	if b.net && entry.HasHandicap {
+		if hc, ok := b.handicapCourse(round); ok {
+			for i, s := range hc.strokesReceived(entry.CourseHandicap, holeCount) {
+				keys.holes[i] -= s
+			}
+		}
+	}
+	for _, hs := range sc.Playoff {
+		keys.playoff = append(keys.playoff, hs.Strokes)
+	}
+	return keys
+}
+
+// compareTiebreak applies the rules in order until one separates the two
+// players. It returns -1 when a wins, 1 when b wins and 0 when they are still
+// tied, together with the label of the deciding rule.
+func compareTiebreak(a, b LeaderboardEntry, rules []string, net bool) (int, string) {
+	for _, rule := range rules {
+		switch rule {
+		case nip101g.TiebreakCountback:
+			for _, step := range countbackSteps {
+				if step.holes >= len(a.tiebreak.holes) || len(a.tiebreak.holes) != len(b.tiebreak.holes) {
+					continue
+				}
+				if c := cmp.Compare(backHoles(a.tiebreak.holes, step.holes), backHoles(b.tiebreak.holes, step.holes)); c != 0 {
+					return c, step.label
+				}
+			}
+		case nip101g.TiebreakNet:
+			if net {
+				continue // the net leaderboard is already ranked on it
+			}
+			if c := cmp.Compare(a.NetToPar, b.NetToPar); c != 0 {
+				return c, "Lowest net"
+			}
+		case nip101g.TiebreakPlayoff:
+			for i := 0; i < len(a.tiebreak.playoff) && i < len(b.tiebreak.playoff); i++ {
+				if c := cmp.Compare(a.tiebreak.playoff[i], b.tiebreak.playoff[i]); c != 0 {
+					return c, "Playoff"
+				}
+			}
+		}
+	}
+	return 0, ""
+}
+
+// backHoles adds up the last n holes.
+func backHoles(holes []int, n int) int {
+	sum := 0
+	for _, s := range holes[len(holes)-n:] {
+		sum += s
+	}
+	return sum
+}
+
+// breakTies reorders players who finished on the same score using the
+// tournament's tiebreak rules. A player separated from the one above by a rule
+// gets its label, and so does the top player of the tie.
+func breakTies(entries []LeaderboardEntry, rules []string, net bool) {
+	if len(rules) == 0 {
+		return
+	}
+	tied := func(a, b LeaderboardEntry) bool {
+		return a.IsFinished && b.IsFinished && !a.IsDNS && !b.IsDNS && !a.MissedCut && !b.MissedCut &&
+			a.RankScore == b.RankScore
+	}
+
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && tied(entries[start], entries[end]) {
+			end++
+		}
+		group := entries[start:end]
+		start = end
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			c, _ := compareTiebreak(group[i], group[j], rules, net)
+			return c < 0
+		})
+		for i := 1; i < len(group); i++ {
+			c, label := compareTiebreak(group[i-1], group[i], rules, net)
+			if c == 0 {
+				continue
+			}
+			group[i].separated = true
+			group[i].Tiebreak = label
+			if i == 1 {
+				group[0].Tiebreak = label
+			}
+		}
+	}
+}
+
+// tiebreakRulesLabel describes the declared rules, e.g. "countback, then playoff".
+func tiebreakRulesLabel(rules []string) string {
+	return strings.Join(rules, ", then ")
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fiatjaf/njump/nip101g"
 	"github.com/nbd-wtf/go-nostr"
 )
 
@@ -22,6 +25,37 @@ type TournamentMetadata struct {
 	TeeSet           string
 	RosterPubkeys    []string
 	Image            string
+	Format           string           // scoring format from the "format" tag, "" for stroke play
+	Teams            []nip101g.Team   // from "team" tags
+	Rounds           int              // number of rounds, 1 for a single round event
+	Cut              *nip101g.CutRule // nil when the field isn't cut
+	Tiebreaks        []string         // tiebreak rules in the order they are applied
+	Flights          []nip101g.Flight
+	Divisions        map[string]string // flight name by player pubkey
+	Purse            *nip101g.Purse    // nil when there is no prize money
+	Bracket          *nip101g.Bracket  // nil unless the tournament is a match play knockout
+}
+
+func newTournamentMetadata(t nip101g.Tournament) *TournamentMetadata {
+	return &TournamentMetadata{
+		Title:            t.Title,
+		Location:         t.Location,
+		StartUnix:        t.Start,
+		TournamentStatus: t.Status,
+		CourseCoord:      t.CourseRef,
+		TeeSet:           t.TeeSet,
+		RosterPubkeys:    t.Roster,
+		Image:            t.Image,
+		Format:           t.Format,
+		Teams:            t.Teams,
+		Rounds:           t.Rounds,
+		Cut:              t.Cut,
+		Tiebreaks:        t.Tiebreaks,
+		Flights:          t.Flights,
+		Divisions:        t.Divisions,
+		Purse:            t.Purse,
+		Bracket:          t.Bracket,
+	}
 }
 
 // TournamentPageData is the assembled leaderboard data for rendering.
@@ -33,24 +67,67 @@ type TournamentPageData struct {
 	Image            string
 	TeeSet           string
 	CoursePar        int
-	Players          []LeaderboardEntry
+	Players          []LeaderboardEntry // every player, flight by flight
+	Flights          []FlightData       // one section per flight, a single unnamed one when there are none
 	Naddr            string
+	Problems         []EventProblems
+	Net              bool // ranked by net score
+	NetAvailable     bool // at least one player has a course handicap
+	Teams            []TeamScoreData
+	TeamRule         string // how team scores are put together, e.g. "Best ball"
+	Rounds           int    // R1…Rn columns are shown when there is more than one
+	CutLabel         string // "Cut +3" or "Projected cut +3"
+	Tiebreaks        []string
+	Purse            *PurseData       // nil when there is no prize money
+	ShowPayouts      bool             // the tournament is over and the leaderboard has a payout column
+	Bracket          *BracketData     // set for match play knockouts, which have no leaderboard
+	Grid             *ScoreGridData   // set when the hole-by-hole view is asked for instead of the leaderboard
+	Stats            *TournamentStats // set when the stats view is asked for instead of the leaderboard
 }
 
 // LeaderboardEntry represents one player row on the leaderboard.
 type LeaderboardEntry struct {
-	Rank       string // "1", "T2", "T2", "4"...
+	Rank       string // "1", "T2", "T2", "4"..., "MC" after a missed cut
 	Player     PlayerData
-	ScoreToPar int
+	ScoreToPar int    // over all the rounds played so far
+	Total      int    // strokes over all the rounds played so far
+	Thru       string // current round: "F", "9", "18", "-"
+	IsFinished bool   // finished the last round, or the cut round after missing the cut
+	IsDNS      bool   // on roster but no 1501
+	IsPlaying  bool   // current round not finished yet
+	MissedCut  bool
+	Tiebreak   string // the rule that decided the player's place in a tie, e.g. "Back 9"
+	Flight     int    // index of the player's flight, len(flights) when in none of them
+	Payout     float64
+
+	Rounds []LeaderboardRound // one per tournament round
+
+	// Net scoring, players without a handicap index play off scratch
+	HasHandicap    bool
+	HandicapIndex  float64
+	CourseHandicap int
+	NetToPar       int
+
+	RankScore int // ScoreToPar, or NetToPar on the net leaderboard
+
+	tiebreak  tiebreakKeys
+	separated bool // a tiebreak put the player below the one ranked above
+}
+
+// LeaderboardRound is a player's score in one round of the tournament.
+type LeaderboardRound struct {
+	Started    bool // the player has a 1501 for this round
+	IsFinished bool // the round has a 1502
 	Total      int
-	Thru       string // "F", "9", "18", "-"
-	IsFinished bool
-	IsDNS      bool // on roster but no 1501
-	IsPlaying  bool // in_progress 31501
+	ScoreToPar int
+	Thru       string // "F", holes played, "-"
 }
 
 // buildTournamentPageData constructs the full leaderboard from a kind 31923 tournament event.
-func buildTournamentPageData(ctx context.Context, tournamentEvent *nostr.Event, meta *TournamentMetadata, naddr string) TournamentPageData {
+// With net set, players are ranked by net score. The "grid" view adds the
+// hole-by-hole grid of gridRound (the latest round when 0), the "stats" view
+// the field statistics.
+func buildTournamentPageData(ctx context.Context, tournamentEvent *nostr.Event, meta *TournamentMetadata, naddr string, net bool, view string, gridRound int) TournamentPageData {
 	tpd := TournamentPageData{
 		Title:            meta.Title,
 		Location:         meta.Location,
@@ -58,7 +135,8 @@ func buildTournamentPageData(ctx context.Context, tournamentEvent *nostr.Event,
 		Image:            meta.Image,
 		TeeSet:           meta.TeeSet,
 		Naddr:            naddr,
-		CoursePar:        72, // default fallback
+		Net:              net,
+		Tiebreaks:        meta.Tiebreaks,
 	}
 
 	// Format date from start unix timestamp
@@ -66,125 +144,290 @@ func buildTournamentPageData(ctx context.Context, tournamentEvent *nostr.Event,
 		tpd.Date = time.Unix(meta.StartUnix, 0).Format("2006-01-02")
 	}
 
-	// Build the "a" tag coordinate for this tournament
-	dTag := tournamentEvent.Tags.GetD()
-	aCoord := fmt.Sprintf("31923:%s:%s", tournamentEvent.PubKey, dTag)
+	if meta.Bracket != nil {
+		bd := buildBracketData(ctx, meta.Bracket)
+		tpd.Bracket = &bd
+		return tpd
+	}
 
-	// Try to fetch course par from 33501
-	if meta.CourseCoord != "" {
-		coursePar := fetchCoursePar(ctx, meta.CourseCoord)
-		if coursePar > 0 {
-			tpd.CoursePar = coursePar
+	board, problems := loadTournamentBoard(ctx, tournamentEvent, meta, net)
+	tpd.CoursePar = board.coursePar
+	tpd.Problems = problems
+	tpd.Rounds = board.rounds
+	tpd.Players = board.entries()
+	tpd.Flights = board.flightSections(tpd.Players)
+	for _, f := range tpd.Flights {
+		if _, label := board.cutLine(tpd.Players, f.Index); label != "" {
+			tpd.CutLabel = label
 		}
 	}
-
-	// Query 1: kind 1501s linked to this tournament via #a tag
-	initiations := fetchTournament1501s(ctx, aCoord)
-
-	// Dedup 1501s by author (prefer the one with scores, else latest)
-	initByAuthor := make(map[string]*nostr.Event)
-	for _, evt := range initiations {
-		existing, ok := initByAuthor[evt.PubKey]
-		if !ok || evt.CreatedAt > existing.CreatedAt {
-			initByAuthor[evt.PubKey] = evt
+	if meta.Purse != nil {
+		tpd.Purse = buildPurseData(meta.Purse, board.profiles)
+		tpd.ShowPayouts = meta.TournamentStatus == "complete" && len(meta.Purse.Payouts) > 0
+		for i := range tpd.Flights {
+			applyPayouts(&tpd.Flights[i], meta.Purse)
+		}
+	}
+	switch view {
+	case "grid":
+		if gridRound < 1 || gridRound > board.rounds {
+			gridRound = board.latestRound()
 		}
+		g := board.scoreGrid(tpd.Flights, gridRound)
+		tpd.Grid = &g
+	case "stats":
+		st := board.stats()
+		tpd.Stats = &st
 	}
+	if len(meta.Teams) > 0 {
+		tpd.Teams = board.teamScores()
+		tpd.TeamRule = teamRuleLabel(meta.Format)
+	}
+	for _, e := range tpd.Players {
+		if e.HasHandicap {
+			tpd.NetAvailable = true
+			break
+		}
+	}
+	return tpd
+}
+
+// tournamentCoord is the "a" tag coordinate 1501s use to reference a tournament.
+func tournamentCoord(tournamentEvent *nostr.Event) string {
+	return fmt.Sprintf("31923:%s:%s", tournamentEvent.PubKey, tournamentEvent.Tags.GetD())
+}
+
+// playerRound identifies one player's round of the tournament.
+type playerRound struct {
+	pubkey string
+	number int // 1 = first round
+}
 
-	// Collect 1501 event IDs for querying 1502s and 31501s
-	var initIDs []string
-	initIDToAuthor := make(map[string]string) // 1501 ID → author pubkey
-	for _, evt := range initByAuthor {
-		initIDs = append(initIDs, evt.ID)
-		initIDToAuthor[evt.ID] = evt.PubKey
+// tournamentBoard holds the events a leaderboard is computed from. It is
+// filled in one go for a page load and then kept up to date event by event
+// by the live stream.
+type tournamentBoard struct {
+	coursePar     int
+	course        nip101g.Course // zero value when the course couldn't be fetched
+	teeSet        string
+	net           bool
+	format        string
+	teams         []nip101g.Team
+	rounds        int
+	cut           *nip101g.CutRule
+	tiebreaks     []string
+	flights       []nip101g.Flight
+	divisions     map[string]string
+	rosterSet     map[string]bool              // tournament p-tags + anyone who submitted a 1501
+	initByRound   map[playerRound]*nostr.Event // latest 1501 per player and round
+	initIDToRound map[string]playerRound       // 1501 ID → player and round
+	finalByRound  map[playerRound]*nostr.Event // 1502s by the 1501 they reference
+	liveByRound   map[playerRound]*nostr.Event // 31501s by the 1501 they reference
+	profiles      map[string]PlayerData
+}
+
+func newTournamentBoard(roster []string, coursePar int, rounds int) *tournamentBoard {
+	b := &tournamentBoard{
+		coursePar:     coursePar,
+		rounds:        max(1, rounds),
+		rosterSet:     make(map[string]bool, len(roster)),
+		initByRound:   make(map[playerRound]*nostr.Event),
+		initIDToRound: make(map[string]playerRound),
+		finalByRound:  make(map[playerRound]*nostr.Event),
+		liveByRound:   make(map[playerRound]*nostr.Event),
+		profiles:      make(map[string]PlayerData),
 	}
+	for _, pk := range roster {
+		b.rosterSet[pk] = true
+	}
+	return b
+}
 
-	// Query 2: 1502s and 31501s referencing the 1501s
-	records, livecards := fetchTournamentScores(ctx, initIDs)
+// loadTournamentBoard fetches everything needed for the leaderboard of a
+// tournament and returns it together with the problems found in its final records.
+func loadTournamentBoard(ctx context.Context, tournamentEvent *nostr.Event, meta *TournamentMetadata, net bool) (*tournamentBoard, []EventProblems) {
+	coursePar := 72 // default fallback
 
-	// Map 1502s by the author of the 1501 they reference
-	finalByPlayer := make(map[string]*nostr.Event)
-	for _, rec := range records {
-		// Find which 1501 this 1502 references
-		for _, tag := range rec.Tags {
-			if len(tag) >= 2 && tag[0] == "e" {
-				if authorPK, ok := initIDToAuthor[tag[1]]; ok {
-					finalByPlayer[authorPK] = rec
-					break
-				}
+	// Try to fetch course par, ratings and stroke indexes from 33501
+	var course nip101g.Course
+	if meta.CourseCoord != "" {
+		if c, ok := fetchCourse(ctx, meta.CourseCoord); ok {
+			course = c
+			if c.TotalPar > 0 {
+				coursePar = c.TotalPar
 			}
 		}
 	}
 
-	// Map 31501s by the author of the 1501 they reference
-	liveByPlayer := make(map[string]*nostr.Event)
+	board := newTournamentBoard(meta.RosterPubkeys, coursePar, meta.Rounds)
+	board.course = course
+	board.teeSet = meta.TeeSet
+	board.net = net
+	board.format = meta.Format
+	board.teams = meta.Teams
+	board.cut = meta.Cut
+	board.tiebreaks = meta.Tiebreaks
+	board.flights = meta.Flights
+	board.divisions = meta.Divisions
+
+	// Query 1: kind 1501s linked to this tournament via #a tag
+	for _, evt := range fetchTournament1501s(ctx, tournamentCoord(tournamentEvent)) {
+		board.addRound(evt)
+	}
+
+	// Query 2: 1502s and 31501s referencing the 1501s
+	var problems []EventProblems
+	records, livecards := fetchTournamentScores(ctx, board.roundIDs())
+	for _, rec := range records {
+		_, errs := nip101g.ParseRoundRecord(*rec)
+		problems = appendProblems(problems, "Final record", rec.ID, errs)
+		board.addScore(rec)
+	}
 	for _, lc := range livecards {
-		for _, tag := range lc.Tags {
-			if len(tag) >= 2 && tag[0] == "e" {
-				if authorPK, ok := initIDToAuthor[tag[1]]; ok {
-					liveByPlayer[authorPK] = lc
-					break
-				}
+		board.addScore(lc)
+	}
+
+	// Query 3: profiles
+	board.fetchMissingProfiles(ctx)
+
+	return board, problems
+}
+
+// addRound records a 1501 for the tournament under the round number it
+// declares. It reports whether the set of round IDs changed, i.e. the 1501 is
+// the first or a newer one from its author for that round.
+func (b *tournamentBoard) addRound(evt *nostr.Event) bool {
+	round, _ := nip101g.ParseRound(*evt)
+	key := playerRound{evt.PubKey, round.RoundNumber()}
+	if key.number > b.rounds {
+		return false
+	}
+
+	existing, ok := b.initByRound[key]
+	if ok && evt.CreatedAt <= existing.CreatedAt {
+		return false
+	}
+	if ok {
+		// scores for the round being replaced no longer count
+		delete(b.initIDToRound, existing.ID)
+		delete(b.finalByRound, key)
+		delete(b.liveByRound, key)
+	}
+	b.initByRound[key] = evt
+	b.initIDToRound[evt.ID] = key
+	b.rosterSet[evt.PubKey] = true
+	return true
+}
+
+// addScore records a 1502 or 31501 under the player and round of the 1501 it
+// references. It reports whether the event changed the board.
+func (b *tournamentBoard) addScore(evt *nostr.Event) bool {
+	byRound := b.liveByRound
+	if evt.Kind == 1502 {
+		byRound = b.finalByRound
+	}
+
+	for _, tag := range evt.Tags {
+		if len(tag) >= 2 && tag[0] == "e" {
+			key, ok := b.initIDToRound[tag[1]]
+			if !ok {
+				continue
+			}
+			if existing, ok := byRound[key]; ok && existing.CreatedAt > evt.CreatedAt {
+				return false
 			}
+			byRound[key] = evt
+			return true
 		}
 	}
+	return false
+}
 
-	// Build roster set (from tournament p-tags + anyone who submitted a 1501)
-	rosterSet := make(map[string]bool)
-	for _, pk := range meta.RosterPubkeys {
-		rosterSet[pk] = true
+// roundIDs returns the IDs of the 1501s currently on the board.
+func (b *tournamentBoard) roundIDs() []string {
+	ids := make([]string, 0, len(b.initIDToRound))
+	for id := range b.initIDToRound {
+		ids = append(ids, id)
 	}
-	for pk := range initByAuthor {
-		rosterSet[pk] = true
+	return ids
+}
+
+// fetchMissingProfiles loads profiles for players that don't have one yet.
+func (b *tournamentBoard) fetchMissingProfiles(ctx context.Context) {
+	if missing := b.missingProfiles(); len(missing) > 0 {
+		b.addProfiles(fetchPlayerProfiles(ctx, missing))
 	}
+}
 
-	// Collect all pubkeys for profile fetching
-	var allPubkeys []string
-	for pk := range rosterSet {
-		allPubkeys = append(allPubkeys, pk)
+// missingProfiles lists the players that don't have a profile yet.
+func (b *tournamentBoard) missingProfiles() []string {
+	var missing []string
+	for pk := range b.rosterSet {
+		if _, ok := b.profiles[pk]; !ok {
+			missing = append(missing, pk)
+		}
 	}
+	return missing
+}
 
-	// Query 3: profiles
-	profiles := fetchPlayerProfiles(ctx, allPubkeys)
+func (b *tournamentBoard) addProfiles(profiles map[string]PlayerData) {
+	for pk, pd := range profiles {
+		b.profiles[pk] = pd
+	}
+}
 
-	// Build leaderboard entries
+// entries builds the sorted and ranked leaderboard.
+func (b *tournamentBoard) entries() []LeaderboardEntry {
 	var entries []LeaderboardEntry
-	for pk := range rosterSet {
+	for pk := range b.rosterSet {
 		entry := LeaderboardEntry{
-			Player: profiles[pk],
+			Player: b.profiles[pk],
+			Thru:   "-",
 		}
 
-		if finalEvt, ok := finalByPlayer[pk]; ok {
-			// Has a 1502 — finished
-			entry.IsFinished = true
-			entry.Thru = "F"
-			entry.Total = parseTotalFromEvent(finalEvt)
-			if entry.Total > 0 && tpd.CoursePar > 0 {
-				entry.ScoreToPar = entry.Total - tpd.CoursePar
-			}
-		} else if liveEvt, ok := liveByPlayer[pk]; ok {
-			// Has a 31501 — in progress
-			entry.IsPlaying = true
-			total, holesPlayed := parseLiveScorecardScores(liveEvt)
-			entry.Total = total
-			entry.Thru = strconv.Itoa(holesPlayed)
-			if entry.Total > 0 && tpd.CoursePar > 0 {
-				entry.ScoreToPar = entry.Total - tpd.CoursePar
+		current := 0 // latest round the player has started
+		for n := 1; n <= b.rounds; n++ {
+			rr := b.roundResult(playerRound{pk, n})
+			entry.Rounds = append(entry.Rounds, rr)
+			if rr.Started {
+				current = n
+				entry.Total += rr.Total
+				entry.ScoreToPar += rr.ScoreToPar
 			}
-		} else if _, ok := initByAuthor[pk]; ok {
-			// Has a 1501 but no scores yet — treat as playing with no holes
-			entry.IsPlaying = true
-			entry.Thru = "-"
-		} else {
+		}
+
+		if current == 0 {
 			// On roster but no 1501 — DNS
 			entry.IsDNS = true
-			entry.Thru = "-"
+		} else {
+			// a 1501 with no scores yet counts as playing with no holes
+			cur := entry.Rounds[current-1]
+			entry.Thru = cur.Thru
+			entry.IsFinished = current == b.rounds && cur.IsFinished
+			entry.IsPlaying = !cur.IsFinished
 		}
 
+		b.applyNet(pk, &entry)
+		entry.Flight = b.flightOf(pk, entry)
+		if len(b.tiebreaks) > 0 && entry.IsFinished {
+			entry.tiebreak = b.tiebreakKeys(pk, entry)
+		}
 		entries = append(entries, entry)
 	}
 
-	// Sort: finished (asc scoreToPar) → in progress (asc scoreToPar) → DNS
+	b.applyCut(entries)
+	for i := range entries {
+		entries[i].RankScore = entries[i].ScoreToPar
+		if b.net {
+			entries[i].RankScore = entries[i].NetToPar
+		}
+	}
+
+	// Sort by flight, then: finished (asc rankScore) → in progress (asc rankScore) → missed cut → DNS
 	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Flight != entries[j].Flight {
+			return entries[i].Flight < entries[j].Flight
+		}
 		ci := sortCategory(entries[i])
 		cj := sortCategory(entries[j])
 		if ci != cj {
@@ -193,42 +436,424 @@ func buildTournamentPageData(ctx context.Context, tournamentEvent *nostr.Event,
 		if entries[i].IsDNS && entries[j].IsDNS {
 			return entries[i].Player.DisplayName < entries[j].Player.DisplayName
 		}
-		return entries[i].ScoreToPar < entries[j].ScoreToPar
+		if entries[i].RankScore != entries[j].RankScore {
+			return entries[i].RankScore < entries[j].RankScore
+		}
+		// keep tied players in a fixed order so live updates don't shuffle them
+		return entries[i].Player.PubkeyHex < entries[j].Player.PubkeyHex
 	})
 
-	// Assign ranks with ties
-	assignRanks(entries)
+	// Within each flight, break ties with the tournament's rules, then assign
+	// ranks to what's left tied
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && entries[end].Flight == entries[start].Flight {
+			end++
+		}
+		breakTies(entries[start:end], b.tiebreaks, b.net)
+		assignRanks(entries[start:end])
+		start = end
+	}
+
+	return entries
+}
 
-	tpd.Players = entries
-	return tpd
+// roundResult is a player's score in one round: the final record if there is
+// one, otherwise the live scorecard.
+func (b *tournamentBoard) roundResult(key playerRound) LeaderboardRound {
+	rr := LeaderboardRound{Thru: "-"}
+	if finalEvt, ok := b.finalByRound[key]; ok {
+		rr.Started, rr.IsFinished = true, true
+		rr.Thru = "F"
+		rr.Total = parseTotalFromEvent(finalEvt)
+		if rr.Total > 0 && b.coursePar > 0 {
+			rr.ScoreToPar = rr.Total - b.coursePar
+		}
+	} else if liveEvt, ok := b.liveByRound[key]; ok {
+		rr.Started = true
+		total, holesPlayed := parseLiveScorecardScores(liveEvt)
+		rr.Total = total
+		rr.Thru = strconv.Itoa(holesPlayed)
+		if par := b.parPlayed(key); rr.Total > 0 && par > 0 {
+			rr.ScoreToPar = rr.Total - par
+		}
+	} else if _, ok := b.initByRound[key]; ok {
+		rr.Started = true
+	}
+	return rr
+}
+
+// parPlayed is the par of the holes a player has scores for in a round, from
+// the course or else the snapshot on their 1501. When a hole's par isn't
+// known it falls back to the course par.
+func (b *tournamentBoard) parPlayed(key playerRound) int {
+	pars := b.course.HolePars()
+	if len(pars) == 0 {
+		if initEvt, ok := b.initByRound[key]; ok {
+			round, _ := nip101g.ParseRound(*initEvt)
+			pars = round.Snapshot.HolePars
+		}
+	}
+	par := 0
+	for _, hs := range b.scorecard(key).Scores {
+		if hs.Hole > len(pars) || pars[hs.Hole-1] == 0 {
+			return b.coursePar
+		}
+		par += pars[hs.Hole-1]
+	}
+	return par
+}
+
+// applyNet fills in the net score of an entry from the handicap index on the
+// player's 1501 for each round (or their profile) and the tee being played.
+func (b *tournamentBoard) applyNet(pk string, entry *LeaderboardEntry) {
+	received := 0
+	for n := 1; n <= b.rounds; n++ {
+		key := playerRound{pk, n}
+		initEvt, ok := b.initByRound[key]
+		if !ok {
+			continue
+		}
+		round, _ := nip101g.ParseRound(*initEvt)
+		index, ok := round.Handicaps[pk]
+		if !ok {
+			index, ok = entry.Player.HandicapIndex, entry.Player.HasHandicap
+		}
+		if !ok {
+			continue
+		}
+		hc, ok := b.handicapCourse(round)
+		if !ok {
+			continue
+		}
+
+		rr := entry.Rounds[n-1]
+		ns := hc.netScoreFor(index, scorecardHoles(b.scorecard(key), round.HoleCount()), rr.Total)
+		entry.HasHandicap = true
+		entry.HandicapIndex = index
+		entry.CourseHandicap = ns.CourseHandicap
+		if rr.Total > 0 {
+			received += ns.Received
+		}
+	}
+	entry.NetToPar = entry.ScoreToPar - received
+}
+
+// handicapCourse is the handicap data for the tee of the tournament, or of
+// the round when the tournament doesn't name one.
+func (b *tournamentBoard) handicapCourse(round nip101g.Round) (handicapCourse, bool) {
+	teeSet := b.teeSet
+	if teeSet == "" {
+		teeSet = round.TeeSet
+	}
+	return newHandicapCourse(b.course, teeSet)
+}
+
+// scorecard is a player's latest scores for a round: their final record, or
+// else their live scorecard.
+func (b *tournamentBoard) scorecard(key playerRound) nip101g.Scorecard {
+	if finalEvt, ok := b.finalByRound[key]; ok {
+		record, _ := nip101g.ParseRoundRecord(*finalEvt)
+		return record.Scorecard
+	} else if liveEvt, ok := b.liveByRound[key]; ok {
+		live, _ := nip101g.ParseLiveScorecard(*liveEvt)
+		return live.Scorecard
+	}
+	return nip101g.Scorecard{}
+}
+
+// throughCut is a player's gross score to par over the rounds up to the cut,
+// and whether they have finished all of them.
+func (b *tournamentBoard) throughCut(e LeaderboardEntry) (score int, finished bool) {
+	finished = true
+	for _, rr := range e.Rounds[:b.cut.AfterRound] {
+		score += rr.ScoreToPar
+		finished = finished && rr.IsFinished
+	}
+	return score, finished
+}
+
+// cutScore is the highest score through the cut round that makes the cut.
+// made reports whether the cut has happened: every player in the field has
+// finished the cut round, or the next round has started. Before that the
+// score is a projection from the scores so far.
+func (b *tournamentBoard) cutScore(entries []LeaderboardEntry) (score int, made bool, ok bool) {
+	if b.cut == nil {
+		return 0, false, false
+	}
+
+	var scores []int
+	made = true
+	for _, e := range entries {
+		if e.IsDNS {
+			continue
+		}
+		s, finished := b.throughCut(e)
+		made = made && finished
+		if slices.ContainsFunc(e.Rounds[:b.cut.AfterRound], func(rr LeaderboardRound) bool { return rr.Total > 0 }) {
+			scores = append(scores, s)
+		}
+	}
+	for _, e := range entries {
+		if slices.ContainsFunc(e.Rounds[b.cut.AfterRound:], func(rr LeaderboardRound) bool { return rr.Started }) {
+			made = true
+		}
+	}
+	if len(scores) == 0 {
+		return 0, false, false
+	}
+
+	slices.Sort(scores)
+	if b.cut.Top > 0 {
+		return scores[min(b.cut.Top, len(scores))-1], made, true
+	}
+	return scores[0] + b.cut.Within, made, true
+}
+
+// applyCut marks the players who missed the cut once it has been made. Anyone
+// who didn't finish the cut round misses it too.
+func (b *tournamentBoard) applyCut(entries []LeaderboardEntry) {
+	score, made, ok := b.cutScore(entries)
+	if !ok || !made {
+		return
+	}
+	for i, e := range entries {
+		if e.IsDNS {
+			continue
+		}
+		s, finished := b.throughCut(e)
+		if finished && s <= score {
+			continue
+		}
+		entries[i].MissedCut = true
+		entries[i].IsFinished = finished
+		entries[i].IsPlaying = false
+	}
+}
+
+// cutLine says where to draw the cut line in a flight of a sorted gross
+// leaderboard: after the last player who made (or is projected to make) the
+// cut. The cut itself is made over the whole field. There is no line when
+// nobody in the flight is below it.
+func (b *tournamentBoard) cutLine(entries []LeaderboardEntry, flight int) (after string, label string) {
+	score, made, ok := b.cutScore(entries)
+	if !ok || b.net {
+		return "", ""
+	}
+	label = "Projected cut " + formatScoreToPar(score)
+	if made {
+		label = "Cut " + formatScoreToPar(score)
+	}
+
+	below := false
+	for _, e := range entries {
+		if e.IsDNS || e.Flight != flight {
+			continue
+		}
+		s, _ := b.throughCut(e)
+		if e.MissedCut || (!made && s > score) {
+			below = true
+			break
+		}
+		after = e.Player.PubkeyHex
+	}
+	if !below {
+		return "", ""
+	}
+	return after, label
 }
 
-// sortCategory returns a sort priority: finished=0, playing=1, DNS=2
+// teamScores builds the team leaderboard from the teams declared on the
+// tournament, adding up the rounds of a multi-round event.
+func (b *tournamentBoard) teamScores() []TeamScoreData {
+	holeCount := len(b.course.Holes)
+	if holeCount == 0 {
+		holeCount = nip101g.DefaultHoleCount
+	}
+
+	var teams []TeamScoreData
+	for n := 1; n <= b.rounds; n++ {
+		holes := make(map[string][]int)
+		for _, team := range b.teams {
+			for _, pk := range team.Members {
+				holes[pk] = scorecardHoles(b.scorecard(playerRound{pk, n}), holeCount)
+			}
+		}
+		round := computeTeamScores(b.teams, b.format, b.profiles, holes, b.course.HolePars(), holeCount)
+		if teams == nil {
+			teams = round
+		} else {
+			teams = addTeamRounds(teams, round)
+		}
+	}
+	return teams
+}
+
+// TournamentStats are the field statistics of a tournament, worked out from
+// the final records only.
+type TournamentStats struct {
+	Records   int // final rounds counted
+	Pending   int // rounds started without a final record yet
+	Holes     []HoleStats
+	LowRounds []LowRound // lowest score of every round with a final record
+}
+
+// HoleStats is how one hole played over all the final rounds.
+type HoleStats struct {
+	Hole    int
+	Par     int // 0 when the course is unknown
+	Played  int
+	Average float64
+	Rank    int // difficulty, 1 = hardest
+	Eagles  int // eagle or better
+	Birdies int
+	Pars    int
+	Bogeys  int
+	Doubles int // double bogey or worse
+}
+
+// LowRound is the lowest final score in one round of the tournament, with
+// everyone who shot it.
+type LowRound struct {
+	Round      int
+	Total      int
+	ScoreToPar int
+	Players    []PlayerData
+}
+
+// stats adds up the final records of the board hole by hole.
+func (b *tournamentBoard) stats() TournamentStats {
+	var st TournamentStats
+	keys := make([]playerRound, 0, len(b.finalByRound))
+	for key := range b.finalByRound {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b playerRound) int {
+		if a.number != b.number {
+			return a.number - b.number
+		}
+		return strings.Compare(a.pubkey, b.pubkey)
+	})
+	for key := range b.initByRound {
+		if _, ok := b.finalByRound[key]; !ok {
+			st.Pending++
+		}
+	}
+
+	lows := make(map[int]*LowRound)
+	for _, key := range keys {
+		evt := b.finalByRound[key]
+		record, _ := nip101g.ParseRoundRecord(*evt)
+		st.Records++
+
+		pars := b.course.HolePars()
+		if len(pars) == 0 {
+			if initEvt, ok := b.initByRound[key]; ok {
+				round, _ := nip101g.ParseRound(*initEvt)
+				pars = round.Snapshot.HolePars
+			}
+		}
+		for _, hs := range record.Scores {
+			for len(st.Holes) < hs.Hole {
+				st.Holes = append(st.Holes, HoleStats{Hole: len(st.Holes) + 1})
+			}
+			h := &st.Holes[hs.Hole-1]
+			h.Played++
+			h.Average += float64(hs.Strokes)
+			if hs.Hole > len(pars) || pars[hs.Hole-1] == 0 {
+				continue
+			}
+			h.Par = pars[hs.Hole-1]
+			switch diff := hs.Strokes - h.Par; {
+			case diff <= -2:
+				h.Eagles++
+			case diff == -1:
+				h.Birdies++
+			case diff == 0:
+				h.Pars++
+			case diff == 1:
+				h.Bogeys++
+			default:
+				h.Doubles++
+			}
+		}
+
+		total := parseTotalFromEvent(evt)
+		if total == 0 {
+			continue
+		}
+		par := b.coursePar
+		if len(pars) > 0 {
+			par = sumSlice(pars, 0, len(pars))
+		}
+		low, ok := lows[key.number]
+		if !ok || total < low.Total {
+			low = &LowRound{Round: key.number, Total: total, ScoreToPar: total - par}
+			lows[key.number] = low
+		}
+		if total == low.Total {
+			low.Players = append(low.Players, b.profiles[key.pubkey])
+		}
+	}
+
+	for n := 1; n <= b.rounds; n++ {
+		if low, ok := lows[n]; ok {
+			st.LowRounds = append(st.LowRounds, *low)
+		}
+	}
+
+	var played []*HoleStats
+	for i := range st.Holes {
+		h := &st.Holes[i]
+		if h.Played > 0 {
+			h.Average /= float64(h.Played)
+			played = append(played, h)
+		}
+	}
+	// the hardest hole plays furthest over par, earlier holes first on a tie
+	slices.SortStableFunc(played, func(a, b *HoleStats) int {
+		return cmp.Compare(b.Average-float64(b.Par), a.Average-float64(a.Par))
+	})
+	for i, h := range played {
+		h.Rank = i + 1
+	}
+	return st
+}
+
+// sortCategory returns a sort priority: finished=0, playing=1, missed cut=2, DNS=3
 func sortCategory(e LeaderboardEntry) int {
-	if e.IsFinished {
+	switch {
+	case e.IsDNS:
+		return 3
+	case e.MissedCut:
+		return 2
+	case e.IsFinished:
 		return 0
 	}
-	if e.IsPlaying {
-		return 1
-	}
-	return 2 // DNS
+	return 1
 }
 
 // assignRanks assigns standard golf ranking with ties ("T2" for tied 2nd).
+// Players who missed the cut are ranked "MC".
 func assignRanks(entries []LeaderboardEntry) {
 	if len(entries) == 0 {
 		return
 	}
 
+	ranked := func(e LeaderboardEntry) bool { return !e.IsDNS && !e.MissedCut }
 	rank := 1
 	for i := range entries {
 		if entries[i].IsDNS {
 			entries[i].Rank = "-"
 			continue
 		}
+		if entries[i].MissedCut {
+			entries[i].Rank = "MC"
+			continue
+		}
 
-		if i > 0 && !entries[i-1].IsDNS &&
-			entries[i].ScoreToPar == entries[i-1].ScoreToPar &&
+		if i > 0 && ranked(entries[i-1]) && !entries[i].separated &&
+			entries[i].RankScore == entries[i-1].RankScore &&
 			entries[i].IsFinished == entries[i-1].IsFinished {
 			// Same rank as previous
 			entries[i].Rank = entries[i-1].Rank
@@ -241,12 +866,12 @@ func assignRanks(entries []LeaderboardEntry) {
 	// Now mark ties with "T" prefix
 	rankCounts := make(map[string]int)
 	for _, e := range entries {
-		if e.Rank != "-" {
+		if ranked(e) {
 			rankCounts[e.Rank]++
 		}
 	}
 	for i := range entries {
-		if entries[i].Rank != "-" && rankCounts[entries[i].Rank] > 1 {
+		if ranked(entries[i]) && rankCounts[entries[i].Rank] > 1 {
 			if !strings.HasPrefix(entries[i].Rank, "T") {
 				entries[i].Rank = "T" + entries[i].Rank
 			}
@@ -254,58 +879,18 @@ func assignRanks(entries []LeaderboardEntry) {
 	}
 }
 
-// parseTotalFromEvent reads the total tag from a 1502 event.
+// parseTotalFromEvent reads the total from a 1502 event, falling back to the
+// sum of its score tags.
 func parseTotalFromEvent(evt *nostr.Event) int {
-	if totalTag := evt.Tags.GetFirst([]string{"total", ""}); totalTag != nil && len(*totalTag) >= 2 {
-		if t, err := strconv.Atoi((*totalTag)[1]); err == nil {
-			return t
-		}
-	}
-	// Fallback: sum score tags
-	total := 0
-	for _, tag := range evt.Tags {
-		if len(tag) >= 3 && tag[0] == "score" {
-			if s, err := strconv.Atoi(tag[2]); err == nil {
-				total += s
-			}
-		}
-	}
-	return total
+	record, _ := nip101g.ParseRoundRecord(*evt)
+	return record.TotalOrSum()
 }
 
 // parseLiveScorecardScores reads scores from a 31501 live scorecard event.
 // Returns total strokes and number of holes played.
 func parseLiveScorecardScores(evt *nostr.Event) (total int, holesPlayed int) {
-	// Try JSON content first (newer format: {"scores": [{holeNumber, strokes}]})
-	if evt.Content != "" {
-		var contentData map[string]interface{}
-		if err := json.Unmarshal([]byte(evt.Content), &contentData); err == nil {
-			if scores, ok := contentData["scores"].([]interface{}); ok {
-				for _, s := range scores {
-					if sm, ok := s.(map[string]interface{}); ok {
-						if strokes, ok := sm["strokes"].(float64); ok && strokes > 0 {
-							total += int(strokes)
-							holesPlayed++
-						}
-					}
-				}
-				if holesPlayed > 0 {
-					return
-				}
-			}
-		}
-	}
-
-	// Fallback: score tags
-	for _, tag := range evt.Tags {
-		if len(tag) >= 3 && tag[0] == "score" {
-			if s, err := strconv.Atoi(tag[2]); err == nil && s > 0 {
-				total += s
-				holesPlayed++
-			}
-		}
-	}
-	return
+	live, _ := nip101g.ParseLiveScorecard(*evt)
+	return live.Sum(), live.HolesPlayed()
 }
 
 // fetchTournament1501s queries relay.gambit.golf for kind 1501 events
@@ -387,59 +972,53 @@ func fetchTournamentScores(ctx context.Context, initIDs []string) (records []*no
 	return
 }
 
-// fetchCoursePar fetches the kind 33501 event from the course coordinate
-// and sums hole pars. Returns 0 if unavailable.
-func fetchCoursePar(ctx context.Context, courseCoord string) int {
-	// Parse "33501:<pubkey>:<d>"
-	parts := strings.Split(courseCoord, ":")
-	if len(parts) < 3 || parts[0] != "33501" {
-		return 0
-	}
-	authorPK := parts[1]
-	dTag := parts[2]
-
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// Tournament template helper functions
 
-	relay, err := sys.Pool.EnsureRelay(gambitRelay)
-	if err != nil {
-		return 0
+func holeStatsPar(h HoleStats) string {
+	if h.Par == 0 {
+		return "-"
 	}
+	return strconv.Itoa(h.Par)
+}
 
-	filter := nostr.Filter{
-		Kinds:   []int{33501},
-		Authors: []string{authorPK},
-		Tags:    nostr.TagMap{"d": {dTag}},
-		Limit:   1,
+// holeStatsToPar is the field's average against par, e.g. "+0.32".
+func holeStatsToPar(h HoleStats) string {
+	if h.Par == 0 || h.Played == 0 {
+		return "-"
 	}
+	return fmt.Sprintf("%+.2f", h.Average-float64(h.Par))
+}
 
-	ch, err := relay.QueryEvents(ctx, filter)
-	if err != nil {
-		return 0
+func holeStatsToParClass(h HoleStats) string {
+	if h.Par == 0 || h.Played == 0 {
+		return "text-gray-600"
 	}
+	return totalScoreClass(int(math.Round((h.Average - float64(h.Par)) * 100)))
+}
 
-	var courseEvt *nostr.Event
-	for evt := range ch {
-		courseEvt = evt
-		break
-	}
-	if courseEvt == nil {
-		return 0
+func holeStatsRank(h HoleStats) string {
+	if h.Rank == 0 {
+		return "-"
 	}
+	return strconv.Itoa(h.Rank)
+}
 
-	totalPar := 0
-	for _, tag := range courseEvt.Tags {
-		if len(tag) >= 3 && tag[0] == "hole" {
-			if par, err := strconv.Atoi(tag[2]); err == nil {
-				totalPar += par
-			}
-		}
+// holeStatsBarStyle sizes one segment of the score distribution bar.
+func holeStatsBarStyle(n int, h HoleStats) string {
+	if h.Played == 0 {
+		return "width: 0"
 	}
-
-	return totalPar
+	return fmt.Sprintf("width: %.1f%%", float64(n)*100/float64(h.Played))
 }
 
-// Tournament template helper functions
+// lowRoundPlayers names who shot the low round.
+func lowRoundPlayers(low LowRound) string {
+	names := make([]string, len(low.Players))
+	for i, p := range low.Players {
+		names[i] = p.DisplayName
+	}
+	return strings.Join(names, ", ")
+}
 
 func tournamentStatusBadgeClass(status string) string {
 	switch status {
@@ -481,6 +1060,21 @@ func leaderboardScoreDisplay(e LeaderboardEntry) string {
 	return formatScoreToPar(e.ScoreToPar)
 }
 
+func leaderboardNetDisplay(e LeaderboardEntry) string {
+	if e.IsDNS {
+		return "DNS"
+	}
+	if e.Total == 0 && !e.IsFinished {
+		return "-"
+	}
+	return formatScoreToPar(e.NetToPar)
+}
+
+func leaderboardNetClass(e LeaderboardEntry) string {
+	e.ScoreToPar = e.NetToPar
+	return leaderboardScoreClass(e)
+}
+
 func leaderboardScoreClass(e LeaderboardEntry) string {
 	if e.IsDNS {
 		return "text-gray-400"
@@ -497,9 +1091,27 @@ func leaderboardScoreClass(e LeaderboardEntry) string {
 }
 
 func tournamentOGDescription(tpd TournamentPageData) string {
+	if tpd.Bracket != nil {
+		if desc := bracketOGDescription(*tpd.Bracket); desc != "" {
+			return desc
+		}
+		return tpd.Title
+	}
+	if len(tpd.Teams) > 0 && tpd.Teams[0].Thru > 0 {
+		return fmt.Sprintf("Leading team: %s (%s)", tpd.Teams[0].Name, teamScoreDisplay(tpd.Teams[0]))
+	}
+	if winners := flightWinners(tpd.Flights); len(winners) > 0 {
+		if tpd.TournamentStatus == "complete" {
+			return "Winners: " + strings.Join(winners, " · ")
+		}
+		return "Leaders: " + strings.Join(winners, " · ")
+	}
 	if len(tpd.Players) > 0 {
 		for _, p := range tpd.Players {
 			if !p.IsDNS {
+				if p.Tiebreak != "" {
+					return fmt.Sprintf("Leader: %s (%s, %s)", p.Player.DisplayName, leaderboardScoreDisplay(p), strings.ToLower(p.Tiebreak))
+				}
 				return fmt.Sprintf("Leader: %s (%s)", p.Player.DisplayName, leaderboardScoreDisplay(p))
 			}
 		}
@@ -509,3 +1121,37 @@ func tournamentOGDescription(tpd TournamentPageData) string {
 	}
 	return tpd.Title
 }
+
+// leaderboardRoundDisplay shows one round of a multi-round leaderboard:
+// strokes once the round is finished, the score to par while it's being played.
+func leaderboardRoundDisplay(rr LeaderboardRound) string {
+	switch {
+	case !rr.Started || rr.Total == 0:
+		return "-"
+	case rr.IsFinished:
+		return strconv.Itoa(rr.Total)
+	}
+	return formatScoreToPar(rr.ScoreToPar)
+}
+
+func leaderboardTotalDisplay(e LeaderboardEntry) string {
+	if e.Total == 0 {
+		return "-"
+	}
+	return strconv.Itoa(e.Total)
+}
+
+// leaderboardColumns is the number of columns of the leaderboard table.
+func leaderboardColumns(tpd TournamentPageData) int {
+	columns := 4 // pos, player, score, thru
+	if tpd.NetAvailable {
+		columns++
+	}
+	if tpd.ShowPayouts {
+		columns++
+	}
+	if tpd.Rounds > 1 {
+		columns += tpd.Rounds + 1
+	}
+	return columns
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolfCut(t *testing.T) {
+	board := newTournamentBoard([]string{"aa", "bb", "cc"}, 72, 2)
+	board.cut = &nip101g.CutRule{AfterRound: 1, Top: 2}
+	for _, pk := range []string{"aa", "bb", "cc"} {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk}
+	}
+
+	at := nostr.Timestamp(1000)
+	play := func(pk string, round string, total string) {
+		at++
+		start := &nostr.Event{ID: pk + round, PubKey: pk, Kind: 1501, CreatedAt: at,
+			Tags: nostr.Tags{{"round", round}}}
+		board.addRound(start)
+		if total != "" {
+			board.addScore(&nostr.Event{ID: pk + round + "f", PubKey: pk, Kind: 1502, CreatedAt: at,
+				Tags: nostr.Tags{{"e", start.ID}, {"total", total}}})
+		}
+	}
+	play("aa", "1", "70")
+	play("bb", "1", "74")
+	play("cc", "1", "")
+
+	// cc is still out on the course and nobody is below the projected cut yet
+	entries := board.entries()
+	after, _ := board.cutLine(entries, 0)
+	assert.Equal(t, "", after)
+	assert.Equal(t, "1", entries[0].Rank)
+
+	play("cc", "1", "80")
+	entries = board.entries()
+	after, label := board.cutLine(entries, 0)
+	assert.Equal(t, "Cut +2", label)
+	assert.Equal(t, "bb", after)
+	assert.Equal(t, "MC", entries[2].Rank)
+	assert.True(t, entries[2].MissedCut)
+
+	play("aa", "2", "71")
+	entries = board.entries()
+	assert.Equal(t, "aa", entries[0].Player.PubkeyHex)
+	assert.Equal(t, 141, entries[0].Total)
+	assert.Equal(t, -3, entries[0].ScoreToPar)
+	assert.True(t, entries[0].IsFinished)
+	assert.Equal(t, "74", leaderboardRoundDisplay(entries[1].Rounds[0]))
+	assert.Equal(t, "-", leaderboardRoundDisplay(entries[1].Rounds[1]))
+}
+
+func TestGolfLiveRoundToPar(t *testing.T) {
+	board := newTournamentBoard([]string{"aa", "bb"}, 72, 2)
+	for h := 1; h <= 18; h++ {
+		board.course.Holes = append(board.course.Holes, nip101g.CourseHole{Number: h, Par: 4, Handicap: h})
+	}
+	for _, pk := range []string{"aa", "bb"} {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk}
+	}
+
+	at := nostr.Timestamp(1000)
+	play := func(pk string, round string, total string, front9 int) {
+		at++
+		start := &nostr.Event{ID: pk + round, PubKey: pk, Kind: 1501, CreatedAt: at,
+			Tags: nostr.Tags{{"round", round}}}
+		board.addRound(start)
+		if total != "" {
+			board.addScore(&nostr.Event{ID: pk + round + "f", PubKey: pk, Kind: 1502, CreatedAt: at,
+				Tags: nostr.Tags{{"e", start.ID}, {"total", total}}})
+			return
+		}
+		tags := nostr.Tags{{"d", pk + round}, {"e", start.ID}}
+		for h := 1; h <= 9; h++ {
+			strokes := 4
+			if h == 1 {
+				strokes = front9 - 32
+			}
+			tags = append(tags, nostr.Tag{"score", strconv.Itoa(h), strconv.Itoa(strokes)})
+		}
+		board.addScore(&nostr.Event{ID: pk + round + "l", PubKey: pk, Kind: 31501, CreatedAt: at, Tags: tags})
+	}
+	play("aa", "1", "70", 0)
+	play("bb", "1", "71", 0)
+	play("aa", "2", "", 37)
+	play("bb", "2", "", 35)
+
+	// round 2 is compared with the par of the 9 holes played, not the course par
+	entries := board.entries()
+	assert.Equal(t, "bb", entries[0].Player.PubkeyHex)
+	assert.Equal(t, -2, entries[0].ScoreToPar)
+	assert.Equal(t, -1, entries[0].Rounds[1].ScoreToPar)
+	assert.Equal(t, "9", entries[0].Rounds[1].Thru)
+	assert.Equal(t, -1, entries[1].ScoreToPar)
+}
+
+func TestGolfTiebreak(t *testing.T) {
+	board := newTournamentBoard(nil, 72, 1)
+	board.tiebreaks = []string{nip101g.TiebreakCountback, nip101g.TiebreakPlayoff}
+
+	finish := func(pk string, holes map[int]string, playoff ...string) {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk}
+		start := &nostr.Event{ID: pk, PubKey: pk, Kind: 1501, CreatedAt: 1000}
+		board.addRound(start)
+		tags := nostr.Tags{{"e", start.ID}}
+		for h := 1; h <= 18; h++ {
+			strokes := "4"
+			if s, ok := holes[h]; ok {
+				strokes = s
+			}
+			tags = append(tags, nostr.Tag{"score", strconv.Itoa(h), strokes})
+		}
+		for i, s := range playoff {
+			tags = append(tags, nostr.Tag{"score", strconv.Itoa(i + 1), s, "playoff"})
+		}
+		board.addScore(&nostr.Event{ID: pk + "f", PubKey: pk, Kind: 1502, CreatedAt: 1001, Tags: tags})
+	}
+	finish("aa", nil, "4")
+	finish("bb", map[int]string{1: "3", 18: "5"})
+	finish("cc", nil, "5")
+
+	entries := board.entries()
+	var order, ranks, labels []string
+	for _, e := range entries {
+		order = append(order, e.Player.PubkeyHex)
+		ranks = append(ranks, e.Rank)
+		labels = append(labels, e.Tiebreak)
+	}
+	assert.Equal(t, []string{"aa", "cc", "bb"}, order)
+	assert.Equal(t, []string{"1", "2", "3"}, ranks)
+	assert.Equal(t, []string{"Playoff", "Playoff", "Back 9"}, labels)
+}
+
+func TestGolfFlights(t *testing.T) {
+	board := newTournamentBoard([]string{"aa", "bb", "cc", "dd"}, 72, 1)
+	board.flights = []nip101g.Flight{
+		{Name: "A", HasBand: true, MinIndex: nip101g.MinHandicapIndex, MaxIndex: 9.9},
+		{Name: "B", HasBand: true, MinIndex: 10, MaxIndex: nip101g.MaxHandicapIndex},
+	}
+	board.divisions = map[string]string{"cc": "A"}
+	for pk, index := range map[string]float64{"aa": 4.2, "bb": 18, "cc": 25} {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk, HandicapIndex: index, HasHandicap: true}
+	}
+	board.profiles["dd"] = PlayerData{PubkeyHex: "dd", DisplayName: "dd"}
+	for i, pk := range []string{"aa", "bb", "cc"} {
+		start := &nostr.Event{ID: pk, PubKey: pk, Kind: 1501, CreatedAt: 1000}
+		board.addRound(start)
+		board.addScore(&nostr.Event{ID: pk + "f", PubKey: pk, Kind: 1502, CreatedAt: 1001,
+			Tags: nostr.Tags{{"e", start.ID}, {"total", strconv.Itoa(80 - i)}}})
+	}
+
+	entries := board.entries()
+	flights := board.flightSections(entries)
+	assert.Len(t, flights, 3)
+	assert.Equal(t, "A", flights[0].Name)
+	assert.Equal(t, "cc", flights[0].Players[0].Player.PubkeyHex) // placed in A by name
+	assert.Equal(t, "2", flights[0].Players[1].Rank)
+	assert.Equal(t, "1", flights[1].Players[0].Rank)
+	assert.Equal(t, unflightedName, flights[2].Name)
+	assert.Equal(t, []string{"A: cc (+6)", "B: bb (+7)"}, flightWinners(flights))
+}
+
+func TestGolfPayouts(t *testing.T) {
+	purse := &nip101g.Purse{Amount: 10000, Unit: "sats", Payouts: []nip101g.Payout{
+		{Place: 1, Percent: 50}, {Place: 2, Percent: 30}, {Place: 3, Percent: 15}, {Place: 4, Amount: 501},
+	}}
+	flight := FlightData{Players: []LeaderboardEntry{
+		{Rank: "1"}, {Rank: "T2"}, {Rank: "T2"}, {Rank: "4"}, {Rank: "MC", MissedCut: true},
+	}}
+	applyPayouts(&flight, purse)
+
+	var paid []string
+	for _, e := range flight.Players {
+		paid = append(paid, leaderboardPayoutDisplay(e, purse.Unit))
+	}
+	assert.Equal(t, []string{"5,000 sats", "2,250 sats", "2,250 sats", "501 sats", ""}, paid)
+	assert.Equal(t, "$1,234.50", formatPurseAmount(1234.5, "USD"))
+	assert.Equal(t, "$100.00", formatPurseAmount(99.996, "USD"))
+	assert.Equal(t, "1.00 CHF", formatPurseAmount(0.999, "CHF"))
+	assert.Equal(t, "1.00 CHF", formatPurseAmount(0.999, "chf"))
+	assert.Equal(t, "12th", ordinal(12))
+}
+
+func TestGolfScoreGrid(t *testing.T) {
+	board := newTournamentBoard(nil, 72, 1)
+	score := func(pk string, kind int, holes ...string) {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk}
+		start := &nostr.Event{ID: pk, PubKey: pk, Kind: 1501, CreatedAt: 1000}
+		board.addRound(start)
+		tags := nostr.Tags{{"e", start.ID}}
+		for i, strokes := range holes {
+			tags = append(tags, nostr.Tag{"score", strconv.Itoa(i + 1), strokes})
+		}
+		board.addScore(&nostr.Event{ID: pk + "s", PubKey: pk, Kind: kind, CreatedAt: 1001, Tags: tags})
+	}
+	full := make([]string, 18)
+	for i := range full {
+		full[i] = "4"
+	}
+	full[0] = "3"
+	score("aa", 1502, full...)
+	score("bb", 31501, "5", "4", "4")
+	board.profiles["cc"] = PlayerData{PubkeyHex: "cc", DisplayName: "cc"}
+	board.addRound(&nostr.Event{ID: "cc", PubKey: "cc", Kind: 1501, CreatedAt: 1000})
+
+	entries := board.entries()
+	grid := board.scoreGrid(board.flightSections(entries), board.latestRound())
+	require.Len(t, grid.Sections, 1)
+	rows := grid.Sections[0].Rows
+	require.Len(t, rows, 3) // cc has started without a score yet
+	assert.Equal(t, "aa", rows[0].Player.PubkeyHex)
+	assert.Equal(t, "F", rows[0].Thru)
+	assert.Equal(t, "35", nineTotal(rows[0].Holes, 0, 9))
+	assert.Equal(t, "3", rows[1].Thru)
+	assert.True(t, rows[1].IsPlaying)
+	assert.Equal(t, "-", nineTotal(rows[2].Holes, 0, 9))
+
+	assert.Equal(t, 18, grid.HoleCount)
+	assert.Equal(t, 4.0, grid.Averages[0])
+	assert.Equal(t, 4.0, grid.Averages[3]) // only aa has played it
+	assert.Equal(t, "4.00", gridAverageDisplay(grid.Averages[1]))
+	assert.Equal(t, [][2]int{{0, 9}, {9, 18}}, gridHalves(grid.HoleCount))
+}
+
+func TestGolfStats(t *testing.T) {
+	board := newTournamentBoard(nil, 12, 1)
+	board.course = nip101g.Course{Holes: []nip101g.CourseHole{{Number: 1, Par: 4}, {Number: 2, Par: 3}, {Number: 3, Par: 5}}}
+	finish := func(pk string, holes ...string) {
+		board.profiles[pk] = PlayerData{PubkeyHex: pk, DisplayName: pk}
+		start := &nostr.Event{ID: pk, PubKey: pk, Kind: 1501, CreatedAt: 1000}
+		board.addRound(start)
+		tags := nostr.Tags{{"e", start.ID}}
+		for i, strokes := range holes {
+			tags = append(tags, nostr.Tag{"score", strconv.Itoa(i + 1), strokes})
+		}
+		board.addScore(&nostr.Event{ID: pk + "f", PubKey: pk, Kind: 1502, CreatedAt: 1001, Tags: tags})
+	}
+	finish("aa", "4", "2", "5")
+	finish("bb", "6", "3", "3")
+	finish("cc", "5", "4", "6")
+	board.addRound(&nostr.Event{ID: "dd", PubKey: "dd", Kind: 1501, CreatedAt: 1000})
+
+	st := board.stats()
+	assert.Equal(t, 3, st.Records)
+	assert.Equal(t, 1, st.Pending)
+	require.Len(t, st.Holes, 3)
+	assert.Equal(t, HoleStats{Hole: 1, Par: 4, Played: 3, Average: 5, Rank: 1, Pars: 1, Bogeys: 1, Doubles: 1}, st.Holes[0])
+	assert.Equal(t, 2, st.Holes[1].Rank)
+	assert.Equal(t, 1, st.Holes[2].Eagles)
+	assert.Equal(t, 3, st.Holes[2].Rank) // it played under par
+	assert.Equal(t, "-0.33", holeStatsToPar(st.Holes[2]))
+
+	require.Len(t, st.LowRounds, 1)
+	assert.Equal(t, 11, st.LowRounds[0].Total)
+	assert.Equal(t, -1, st.LowRounds[0].ScoreToPar)
+	assert.Equal(t, "aa", lowRoundPlayers(st.LowRounds[0]))
+}
+
+func TestGolfTournamentStreamSlowClient(t *testing.T) {
+	board := newTournamentBoard([]string{"aa"}, 72, 1)
+	board.profiles["aa"] = PlayerData{PubkeyHex: "aa", DisplayName: "aa"}
+	fast, slow := make(chan leaderboardUpdate, 1), make(chan leaderboardUpdate)
+	ts := &tournamentStream{board: board, clients: map[chan leaderboardUpdate]struct{}{fast: {}, slow: {}}}
+
+	board.addRound(&nostr.Event{ID: "r", PubKey: "aa", Kind: 1501, CreatedAt: 1000})
+	ts.publish()
+
+	// the client that couldn't take the update is disconnected to start over
+	update := <-fast
+	assert.Len(t, update.Rows, 1)
+	_, ok := <-slow
+	assert.False(t, ok)
+	assert.Len(t, ts.clients, 1)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip05"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// CourseVerification is why we believe a 33501 was published by the people
+// who run the course. The zero value is an unverified course.
+type CourseVerification struct {
+	Method   string // courseVerifiedNIP05 or courseVerifiedAttestation
+	NIP05    string // the operator's identifier, on the domain of the course's website
+	Attester string // the trusted pubkey that labeled the course verified
+}
+
+const (
+	courseVerifiedNIP05       = "nip05"
+	courseVerifiedAttestation = "attestation"
+)
+
+// Attestations are NIP-32 labels: a kind 1985 signed by one of
+// s.TrustedPubKeys with ["L", courseAttestationNamespace],
+// ["l", courseAttestationLabel, courseAttestationNamespace] and an "a" tag
+// for every course it vouches for.
+const (
+	courseAttestationNamespace = "golf.gambit.course"
+	courseAttestationLabel     = "verified"
+)
+
+const (
+	// courseNIP05TTL is how long the result of an operator's NIP-05 check is kept.
+	courseNIP05TTL = 6 * time.Hour
+
+	// courseNIP05Timeout bounds one NIP-05 check, profile lookup included.
+	courseNIP05Timeout = 5 * time.Second
+
+	// courseNIP05Parallel is how many NIP-05 checks a directory refresh runs
+	// at the same time.
+	courseNIP05Parallel = 8
+)
+
+func (v CourseVerification) Verified() bool {
+	return v.Method != ""
+}
+
+// Attested reports whether a trusted pubkey vouched for the course, the only
+// verification that lets a listing stand in for others of the same course.
+func (v CourseVerification) Attested() bool {
+	return v.Method == courseVerifiedAttestation
+}
+
+// verifyCourse checks a course event, first for an attestation from a
+// trusted pubkey and then for its operator's NIP-05.
+func verifyCourse(ctx context.Context, evt *nostr.Event, course nip101g.Course) CourseVerification {
+	if attester := fetchCourseAttestation(ctx, courseCoordinate(evt)); attester != "" {
+		return CourseVerification{Method: courseVerifiedAttestation, Attester: attester}
+	}
+	return verifyCourseOperator(ctx, evt.PubKey, course.OperatorPubkey, course.Website)
+}
+
+// verifyCourseOperator checks that a course was published by its own
+// operator and that the operator's NIP-05 is on the domain of the course's
+// website. An operator tag naming somebody else proves nothing, as anybody
+// can write it.
+func verifyCourseOperator(ctx context.Context, author, operator, website string) CourseVerification {
+	if operator != author || website == "" {
+		return CourseVerification{}
+	}
+	if identifier := operatorNIP05s.check(ctx, operator, website); identifier != "" {
+		return CourseVerification{Method: courseVerifiedNIP05, NIP05: identifier}
+	}
+	return CourseVerification{}
+}
+
+// operatorNIP05s caches NIP-05 checks by operator and domain, so directory
+// refreshes and course pages don't hit the operators' domains every time.
+var operatorNIP05s = &nip05Checks{checks: make(map[string]nip05Check)}
+
+type nip05Checks struct {
+	mu     sync.Mutex
+	checks map[string]nip05Check
+}
+
+type nip05Check struct {
+	identifier string // empty when the check failed
+	at         time.Time
+}
+
+// check returns the operator's NIP-05 identifier when it is on the website's
+// domain and resolves to the operator, or an empty string.
+func (nc *nip05Checks) check(ctx context.Context, operator, website string) string {
+	domain := websiteDomain(website)
+	if domain == "" {
+		return ""
+	}
+	key := operator + " " + domain
+	nc.mu.Lock()
+	cached, ok := nc.checks[key]
+	nc.mu.Unlock()
+	if ok && time.Since(cached.at) < courseNIP05TTL {
+		return cached.identifier
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, courseNIP05Timeout)
+	defer cancel()
+
+	result := nip05Check{at: time.Now()}
+	profile := sys.FetchProfileMetadata(ctx, operator)
+	if nip05MatchesWebsite(profile.NIP05, website) && profile.NIP05Valid(ctx) {
+		result.identifier = nip05.NormalizeIdentifier(profile.NIP05)
+	}
+
+	nc.mu.Lock()
+	nc.checks[key] = result
+	nc.mu.Unlock()
+	return result.identifier
+}
+
+// nip05MatchesWebsite reports whether a NIP-05 identifier is on the domain of
+// a website, ignoring a leading "www." on either.
+func nip05MatchesWebsite(identifier, website string) bool {
+	_, domain, err := nip05.ParseIdentifier(identifier)
+	if err != nil {
+		return false
+	}
+	websiteDomain := websiteDomain(website)
+	return websiteDomain != "" && normalizeDomain(domain) == websiteDomain
+}
+
+// websiteDomain is the host of a website without a leading "www.", or an
+// empty string when it can't be parsed.
+func websiteDomain(website string) string {
+	if !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+	return normalizeDomain(u.Hostname())
+}
+
+func normalizeDomain(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// fetchCourseAttestation returns the trusted pubkey that attested the course
+// at the coordinate, or an empty string.
+func fetchCourseAttestation(ctx context.Context, courseCoord string) string {
+	if len(s.TrustedPubKeys) == 0 {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	defer cancel()
+
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for course attestations")
+		return ""
+	}
+	ch, err := relay.QueryEvents(ctx, nostr.Filter{
+		Kinds:   []int{nostr.KindLabel},
+		Authors: s.TrustedPubKeys,
+		Tags:    nostr.TagMap{"a": {courseCoord}},
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("course", courseCoord).Msg("failed to query course attestations")
+		return ""
+	}
+
+	attester := ""
+	for evt := range ch {
+		if attester == "" && slices.Contains(courseAttestations(evt), courseCoord) {
+			attester = evt.PubKey
+		}
+	}
+	return attester
+}
+
+// courseAttestations returns the course coordinates a label event attests,
+// if it is an attestation from a trusted pubkey.
+func courseAttestations(evt *nostr.Event) []string {
+	if evt.Kind != nostr.KindLabel || !slices.Contains(s.TrustedPubKeys, evt.PubKey) {
+		return nil
+	}
+	labeled := false
+	for _, tag := range evt.Tags {
+		if len(tag) >= 3 && tag[0] == "l" && tag[1] == courseAttestationLabel && tag[2] == courseAttestationNamespace {
+			labeled = true
+		}
+	}
+	if !labeled {
+		return nil
+	}
+
+	var coords []string
+	for _, tag := range evt.Tags {
+		if len(tag) >= 2 && tag[0] == "a" && strings.HasPrefix(tag[1], "33501:") {
+			coords = append(coords, tag[1])
+		}
+	}
+	return coords
+}
+
+// courseDuplicateKey is what two listings of the same course share: the
+// name and the location, ignoring case and spacing. Courses without a name
+// are never taken as duplicates.
+func courseDuplicateKey(title, location string) string {
+	if strings.TrimSpace(title) == "" {
+		return ""
+	}
+	normalize := func(v string) string {
+		return strings.ToLower(strings.Join(strings.Fields(v), " "))
+	}
+	return normalize(title) + "|" + normalize(location)
+}
+
+// Course verification template helper functions
+
+func courseVerificationTitle(v CourseVerification) string {
+	switch v.Method {
+	case courseVerifiedNIP05:
+		return "Published by the course operator, " + v.NIP05
+	case courseVerifiedAttestation:
+		npub, _ := nip19.EncodePublicKey(v.Attester)
+		return "Attested by a trusted key, " + npub
+	}
+	return ""
+}
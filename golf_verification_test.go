@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGolfCourseVerification(t *testing.T) {
+	assert.True(t, nip05MatchesWebsite("pro@pinevalley.com", "https://www.pinevalley.com/golf"))
+	assert.True(t, nip05MatchesWebsite("_@www.PineValley.com", "pinevalley.com"))
+	assert.False(t, nip05MatchesWebsite("pro@pinevalley.com.evil.net", "https://pinevalley.com"))
+	assert.False(t, nip05MatchesWebsite("pro@gmail.com", "https://pinevalley.com"))
+	assert.False(t, nip05MatchesWebsite("", "https://pinevalley.com"))
+	assert.False(t, nip05MatchesWebsite("pro@pinevalley.com", ""))
+
+	// checks are cached by operator and domain, whatever page of the site is given
+	nc := &nip05Checks{checks: map[string]nip05Check{
+		"operator pinevalley.com": {identifier: "pro@pinevalley.com", at: time.Now()},
+	}}
+	assert.Equal(t, "pro@pinevalley.com", nc.check(t.Context(), "operator", "https://www.PineValley.com/tee-times"))
+	assert.Empty(t, nc.check(t.Context(), "operator", "not a url ://"))
+
+	// an operator tag naming somebody other than the author is not checked
+	assert.False(t, verifyCourseOperator(t.Context(), "author", "operator", "https://pinevalley.com").Verified())
+	assert.False(t, verifyCourseOperator(t.Context(), "operator", "operator", "").Verified())
+
+	trusted, stranger := strings.Repeat("aa", 32), strings.Repeat("bb", 32)
+	defer func(keys []string) { s.TrustedPubKeys = keys }(s.TrustedPubKeys)
+	s.TrustedPubKeys = []string{trusted}
+
+	label := func(pubkey, value string) *nostr.Event {
+		return &nostr.Event{PubKey: pubkey, Kind: nostr.KindLabel, Tags: nostr.Tags{
+			{"L", courseAttestationNamespace},
+			{"l", value, courseAttestationNamespace},
+			{"a", "33501:" + stranger + ":pines"},
+			{"a", "31923:" + stranger + ":open"},
+		}}
+	}
+	assert.Equal(t, []string{"33501:" + stranger + ":pines"}, courseAttestations(label(trusted, "verified")))
+	assert.Empty(t, courseAttestations(label(stranger, "verified")))
+	assert.Empty(t, courseAttestations(label(trusted, "spam")))
+
+	assert.Equal(t, courseDuplicateKey("Pine  Valley", "Clementon, NJ"), courseDuplicateKey("pine valley", " clementon,  nj"))
+	assert.Empty(t, courseDuplicateKey("", "Clementon, NJ"))
+
+	// an attested listing ranks first and hides its duplicates
+	course := func(pubkey, d, title string, at nostr.Timestamp) *nostr.Event {
+		return &nostr.Event{PubKey: pubkey, Kind: 33501, CreatedAt: at, Tags: nostr.Tags{
+			{"d", d}, {"title", title}, {"location", "Clementon, NJ"}, {"hole", "1", "4", "1"},
+		}}
+	}
+	cd := &CourseDirectory{courses: make(map[string]CourseListing)}
+	cd.add(t.Context(), course(stranger, "pines", "Pine Valley", 10))
+	cd.add(t.Context(), course(trusted, "pv", "Pine Valley", 10))
+	cd.add(t.Context(), course(stranger, "aaa", "Augusta", 10))
+	cd.rounds.byCourse = map[string]int{"33501:" + stranger + ":pines": 5, "33501:" + stranger + ":aaa": 3}
+
+	titles := func() []string {
+		var titles []string
+		for _, c := range cd.search(CourseSearch{Sort: courseSortRounds}) {
+			titles = append(titles, c.Title)
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"Pine Valley", "Augusta", "Pine Valley"}, titles())
+	_, ok := cd.attestedDuplicate("33501:" + stranger + ":pines")
+	assert.False(t, ok)
+
+	verified := cd.courses["33501:"+trusted+":pv"]
+	verified.Verification = CourseVerification{Method: courseVerifiedAttestation, Attester: trusted}
+	cd.courses["33501:"+trusted+":pv"] = verified
+	assert.Equal(t, []string{"Pine Valley", "Augusta"}, titles())
+	assert.True(t, cd.search(CourseSearch{})[0].Verification.Verified())
+
+	duplicate, ok := cd.attestedDuplicate("33501:" + stranger + ":pines")
+	assert.True(t, ok)
+	assert.Equal(t, verified.Naddr, duplicate.Naddr)
+	_, ok = cd.attestedDuplicate("33501:" + trusted + ":pv")
+	assert.False(t, ok)
+
+	// a NIP-05 on a website of the publisher's choosing ranks first but hides nothing
+	attacker := strings.Repeat("cc", 32)
+	cd.add(t.Context(), course(stranger, "oak", "Oakmont", 10))
+	cd.add(t.Context(), course(attacker, "oak", "Oakmont", 10))
+	spoof := cd.courses["33501:"+attacker+":oak"]
+	spoof.Verification = CourseVerification{Method: courseVerifiedNIP05, NIP05: "_@attacker.tld"}
+	cd.courses["33501:"+attacker+":oak"] = spoof
+	oakmont := cd.search(CourseSearch{Text: "oakmont"})
+	assert.Len(t, oakmont, 2)
+	assert.Equal(t, spoof.Naddr, oakmont[0].Naddr)
+	_, ok = cd.attestedDuplicate("33501:" + stranger + ":oak")
+	assert.False(t, ok)
+
+	// a newer version keeps its verification until the next refresh
+	cd.add(t.Context(), course(trusted, "pv", "Pine Valley GC", 20))
+	assert.True(t, cd.courses["33501:"+trusted+":pv"].Verification.Verified())
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// HandicapSummary is a player's World Handicap System index computed from
+// their published 1502 final records.
+type HandicapSummary struct {
+	HasIndex bool // at least 3 acceptable rounds
+	Index    float64
+	LowIndex float64 // lowest index over the 365 days before the latest round
+	HasLow   bool    // there are 20 rounds and an index within those 365 days
+	Capped   bool    // the soft or hard cap lowered the index
+	Rounds   []WHSRound
+	History  []WHSHistoryPoint // index after each round, oldest first
+}
+
+// WHSRound is one acceptable round and its score differential.
+type WHSRound struct {
+	EventID       string
+	Nevent        string
+	Date          time.Time
+	CourseName    string
+	TeeSet        string
+	Gross         int
+	AdjustedGross int // after net double bogey
+	Rating        float64
+	Slope         int
+	Differential  float64
+	Counting      bool // one of the differentials the current index is averaged from
+}
+
+// WHSHistoryPoint is the index right after a round was posted.
+type WHSHistoryPoint struct {
+	Date  time.Time
+	Index float64
+}
+
+// whsScore is a round ready for the calculator: 18 holes played on a tee with
+// a known rating and slope.
+type whsScore struct {
+	round      WHSRound
+	course     handicapCourse
+	holeScores []int
+}
+
+// whsCounting is how many of the lowest differentials count (and the adjustment
+// applied to their average) when a player has fewer than 20 rounds.
+var whsCounting = []struct {
+	rounds     int
+	lowest     int
+	adjustment float64
+}{
+	{3, 1, -2.0},
+	{4, 1, -1.0},
+	{5, 1, 0},
+	{6, 2, -1.0},
+	{8, 2, 0},
+	{11, 3, 0},
+	{14, 4, 0},
+	{16, 5, 0},
+	{18, 6, 0},
+	{19, 7, 0},
+	{20, 8, 0},
+}
+
+const (
+	whsMaxRounds = 20
+	whsSoftCap   = 3.0
+	whsHardCap   = 5.0
+)
+
+// buildHandicapSummary computes a player's handicap index from their final
+// records and the courses they were played on.
+func buildHandicapSummary(ctx context.Context, records []*nostr.Event, courses courseCache) HandicapSummary {
+	parsed := make([]nip101g.RoundRecord, len(records))
+	var courseRefs []string
+	for i, evt := range records {
+		parsed[i], _ = nip101g.ParseRoundRecord(*evt)
+		if parsed[i].CourseRef != "" {
+			courseRefs = append(courseRefs, parsed[i].CourseRef)
+		}
+	}
+	courses.prefetch(ctx, courseRefs)
+
+	var scores []whsScore
+	for i, evt := range records {
+		record := parsed[i]
+		if record.CourseRef == "" {
+			continue
+		}
+		course := courses.get(ctx, record.CourseRef, roundTime(record.Date, evt.CreatedAt.Time()))
+		if course == nil || len(course.Holes) != 18 {
+			continue
+		}
+		hc, ok := newHandicapCourse(*course, record.TeeSet)
+		if !ok {
+			continue
+		}
+
+		if record.HolesPlayed() != 18 {
+			continue // only complete 18 hole rounds are acceptable
+		}
+
+		date := evt.CreatedAt.Time()
+		if t, err := time.Parse("2006-01-02", formatDate(record.Date)); err == nil {
+			date = t
+		}
+		nevent, _ := nip19.EncodeEvent(evt.ID, []string{gambitRelay}, evt.PubKey)
+		courseName := course.Title
+		if courseName == "" {
+			courseName = record.Snapshot.CourseName
+		}
+
+		scores = append(scores, whsScore{
+			round: WHSRound{
+				EventID:    evt.ID,
+				Nevent:     nevent,
+				Date:       date,
+				CourseName: courseName,
+				TeeSet:     record.TeeSet,
+				Gross:      record.Sum(),
+				Rating:     hc.Rating,
+				Slope:      hc.Slope,
+			},
+			course:     hc,
+			holeScores: scorecardHoles(record.Scorecard, 18),
+		})
+	}
+
+	return computeWHS(scores)
+}
+
+// computeWHS posts rounds in date order, each one adjusted with the index the
+// player had before it, and returns the resulting index with its history.
+func computeWHS(scores []whsScore) HandicapSummary {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].round.Date.Before(scores[j].round.Date)
+	})
+
+	var summary HandicapSummary
+	var posted []WHSRound
+	for _, s := range scores {
+		r := s.round
+		r.AdjustedGross = adjustedGrossScore(s.course, s.holeScores, summary.Index, summary.HasIndex)
+		r.Differential = scoreDifferential(r.AdjustedGross, r.Rating, r.Slope)
+		posted = append(posted, r)
+
+		index, ok := whsIndex(posted)
+		if !ok {
+			continue
+		}
+		summary.Capped = false
+		if len(posted) >= whsMaxRounds {
+			summary.LowIndex, summary.HasLow = lowIndex(summary.History, r.Date)
+			if summary.HasLow {
+				index, summary.Capped = applyCaps(index, summary.LowIndex)
+			}
+		}
+		summary.Index = index
+		summary.HasIndex = true
+		summary.History = append(summary.History, WHSHistoryPoint{Date: r.Date, Index: index})
+	}
+
+	// mark which of the most recent rounds the index is built from, newest first
+	recent := posted[max(0, len(posted)-whsMaxRounds):]
+	for _, i := range countingRounds(recent) {
+		recent[i].Counting = true
+	}
+	for i := len(recent) - 1; i >= 0; i-- {
+		summary.Rounds = append(summary.Rounds, recent[i])
+	}
+
+	return summary
+}
+
+// adjustedGrossScore caps every hole at net double bogey (par + 2 + strokes
+// received). Players without an index yet are capped at par + 5.
+func adjustedGrossScore(hc handicapCourse, holeScores []int, index float64, hasIndex bool) int {
+	var strokes []int
+	if hasIndex {
+		strokes = hc.strokesReceived(hc.courseHandicap(index, len(holeScores)), len(holeScores))
+	}
+
+	total := 0
+	for i, s := range holeScores {
+		par := 0
+		if i < len(hc.HolePars) {
+			par = hc.HolePars[i]
+		}
+		limit := par + 5
+		if hasIndex {
+			limit = par + 2 + strokes[i]
+		}
+		if par > 0 && s > limit {
+			s = limit
+		}
+		total += s
+	}
+	return total
+}
+
+// scoreDifferential is (113 ÷ Slope) × (Adjusted Gross Score − Course Rating),
+// rounded to one decimal. The playing conditions calculation is taken as 0.
+func scoreDifferential(adjustedGross int, rating float64, slope int) float64 {
+	return roundTenth(113 / float64(slope) * (float64(adjustedGross) - rating))
+}
+
+// countingRounds returns the positions of the differentials that count
+// towards the index for the given (at most 20) rounds.
+func countingRounds(rounds []WHSRound) []int {
+	lowest := 0
+	for _, c := range whsCounting {
+		if len(rounds) <= c.rounds {
+			if len(rounds) >= 3 {
+				lowest = c.lowest
+			}
+			break
+		}
+	}
+
+	order := make([]int, len(rounds))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rounds[order[a]].Differential < rounds[order[b]].Differential
+	})
+	return order[:lowest]
+}
+
+// whsIndex averages the counting differentials of the 20 most recent rounds.
+func whsIndex(posted []WHSRound) (float64, bool) {
+	recent := posted[max(0, len(posted)-whsMaxRounds):]
+	counting := countingRounds(recent)
+	if len(counting) == 0 {
+		return 0, false
+	}
+
+	adjustment := 0.0
+	for _, c := range whsCounting {
+		if len(recent) <= c.rounds {
+			adjustment = c.adjustment
+			break
+		}
+	}
+
+	sum := 0.0
+	for _, i := range counting {
+		sum += recent[i].Differential
+	}
+	index := roundTenth(sum/float64(len(counting)) + adjustment)
+	return min(index, nip101g.MaxHandicapIndex), true
+}
+
+// lowIndex is the lowest index in the 365 days before the given date.
+func lowIndex(history []WHSHistoryPoint, date time.Time) (float64, bool) {
+	low, found := 0.0, false
+	for _, h := range history {
+		if date.Sub(h.Date) <= 365*24*time.Hour && (!found || h.Index < low) {
+			low, found = h.Index, true
+		}
+	}
+	return low, found
+}
+
+// applyCaps limits how fast an index can go up: half of any increase beyond
+// 3.0 strokes over the low index is dropped (soft cap), and it can never be
+// more than 5.0 over it (hard cap).
+func applyCaps(index float64, low float64) (float64, bool) {
+	capped := index
+	if capped-low > whsSoftCap {
+		capped = low + whsSoftCap + (capped-low-whsSoftCap)/2
+	}
+	capped = roundTenth(min(capped, low+whsHardCap))
+	return capped, capped < index
+}
+
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// playerMaxRecords bounds how many of a player's final records are read: the
+// most recent ones, as the relay returns the newest first.
+const playerMaxRecords = 1000
+
+// fetchPlayerRecords pages through relay.gambit.golf for a player's most
+// recent 1502 final records. Most profiles aren't golfers, so a single record
+// is looked up first and nothing else is fetched when there isn't one.
+func fetchPlayerRecords(ctx context.Context, pubkey string) []*nostr.Event {
+	relay, err := sys.Pool.EnsureRelay(gambitRelay)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to connect to gambit relay for player records")
+		return nil
+	}
+
+	if !hasPlayerRecords(ctx, relay, pubkey) {
+		return nil
+	}
+
+	filter := nostr.Filter{
+		Kinds:   []int{1502},
+		Authors: []string{pubkey},
+		Limit:   500,
+	}
+
+	var events []*nostr.Event
+	err = pageEvents(ctx, relay.QueryEvents, filter, playerMaxRecords, func(evt *nostr.Event) {
+		events = append(events, evt)
+	})
+	// past playerMaxRecords the older records are left out on purpose
+	if err != nil && !errors.Is(err, errPageLimit) {
+		log.Warn().Err(err).Msg("failed to query player 1502s")
+	}
+	return events
+}
+
+// hasPlayerRecords reports whether a player has published any 1502.
+func hasPlayerRecords(ctx context.Context, relay *nostr.Relay, pubkey string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	ch, err := relay.QueryEvents(ctx, nostr.Filter{
+		Kinds:   []int{1502},
+		Authors: []string{pubkey},
+		Limit:   1,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to query player 1502s")
+		return false
+	}
+	found := false
+	for range ch {
+		found = true
+	}
+	return found
+}
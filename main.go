@@ -25,6 +25,7 @@ type Settings struct {
 	InternalDBPath      string   `envconfig:"DISK_CACHE_PATH" default:"/tmp/gambit-internal"`
 	EventStorePath      string   `envconfig:"EVENT_STORE_PATH" default:"/tmp/gambit-db"`
 	KVStorePath         string   `envconfig:"KV_STORE_PATH" default:"/tmp/gambit-kv"`
+	CourseStorePath     string   `envconfig:"COURSE_STORE_PATH" default:"/tmp/gambit-courses"`
 	HintsMemoryDumpPath string   `envconfig:"HINTS_SAVE_PATH" default:"/tmp/gambit-hints.json"`
 	TailwindDebug       bool     `envconfig:"TAILWIND_DEBUG"`
 	RelayConfigPath     string   `envconfig:"RELAY_CONFIG_PATH"`
@@ -60,6 +61,7 @@ func main() {
 
 	// eventstore and nostr system
 	defer initSystem()()
+	defer initCourseStore()()
 
 	if s.RelayConfigPath != "" {
 		configr, err := os.ReadFile(s.RelayConfigPath)
@@ -121,6 +123,7 @@ func main() {
 	go updateArchives(ctx)
 	go deleteOldCachedEvents(ctx)
 	go outboxHintsFileLoaderSaver(ctx)
+	go updateCourseDirectory(ctx)
 
 	// expose our internal cache as a relay (mostly for debugging purposes)
 	relay := khatru.NewRelay()
@@ -167,11 +170,24 @@ func main() {
 		r.SetPathValue("code", r.PathValue("code"))
 		renderEvent(w, r)
 	})
+	mux.HandleFunc("/round/{code}/live", renderRoundLive)
 	mux.HandleFunc("/tournament/{code}", func(w http.ResponseWriter, r *http.Request) {
 		// /tournament/<naddr> is an alias for /<naddr>
 		r.SetPathValue("code", r.PathValue("code"))
 		renderEvent(w, r)
 	})
+	mux.HandleFunc("/tournament/{code}/live", renderTournamentLive)
+	mux.HandleFunc("/league/{code}", func(w http.ResponseWriter, r *http.Request) {
+		// /league/<naddr> is an alias for /<naddr>
+		r.SetPathValue("code", r.PathValue("code"))
+		renderEvent(w, r)
+	})
+	mux.HandleFunc("/compare/{a}/{b}", renderCompare)
+	mux.HandleFunc("/courses", renderCourses)
+	mux.HandleFunc("/courses.geojson", renderCoursesGeoJSON)
+	mux.HandleFunc("/course/{file}", renderCourseGeoJSON)
+	mux.HandleFunc("/course/{code}/history", renderCourseHistory)
+	mux.HandleFunc("/achievements/{code}", renderAchievements)
 	mux.HandleFunc("/webhooks/asc-feedback", handleASCWebhook)
 	mux.HandleFunc("/{code}", renderEvent)
 	mux.HandleFunc("/{$}", renderLanding)
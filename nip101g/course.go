@@ -0,0 +1,280 @@
+package nip101g
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// CourseHole is a ["hole", <number>, <par>, <handicap>] tag.
+type CourseHole struct {
+	Number   int
+	Par      int
+	Handicap int // stroke index, 1 = hardest hole
+}
+
+// CourseTee is a ["tee", <name>, <rating>, <slope>] tag.
+type CourseTee struct {
+	Name   string
+	Rating float64
+	Slope  int
+}
+
+// CourseYardage is a ["yardage", <hole>, <tee>, <yards>] tag.
+type CourseYardage struct {
+	Hole  int
+	Tee   string
+	Yards int
+}
+
+// CourseHoleGeo is where a hole's tee and green are, from the
+// ["teebox", <hole>, <lat>, <lon>] and ["green", <hole>, <lat>, <lon>] tags.
+type CourseHoleGeo struct {
+	Hole  int
+	Tee   *LatLon
+	Green *LatLon
+}
+
+// Course is a kind 33501 course definition.
+type Course struct {
+	DTag           string
+	Title          string
+	Location       string
+	Country        string
+	Website        string
+	Architect      string
+	Established    string
+	ImageURL       string
+	OperatorPubkey string
+	Holes          []CourseHole
+	Tees           []CourseTee
+	Yardages       []CourseYardage
+	TotalPar       int
+	Geohash        string          // the most precise g tag
+	Position       *LatLon         // from the lat and lon tags, or the center of the geohash
+	HoleGeo        []CourseHoleGeo // by hole number
+}
+
+// Par returns the par for a hole, or 0 if the course doesn't define it.
+func (c Course) Par(hole int) int {
+	for _, h := range c.Holes {
+		if h.Number == hole {
+			return h.Par
+		}
+	}
+	return 0
+}
+
+// HolePars returns par per hole (index 0 = hole 1) sized to the highest hole number.
+func (c Course) HolePars() []int {
+	n := 0
+	for _, h := range c.Holes {
+		n = max(n, h.Number)
+	}
+	pars := make([]int, n)
+	for _, h := range c.Holes {
+		pars[h.Number-1] = h.Par
+	}
+	return pars
+}
+
+// Tee looks up a tee by name.
+func (c Course) Tee(name string) (CourseTee, bool) {
+	for _, t := range c.Tees {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return CourseTee{}, false
+}
+
+// ParseCourse parses a kind 33501 course definition.
+func ParseCourse(event nostr.Event) (Course, ValidationErrors) {
+	var errs ValidationErrors
+	c := Course{}
+
+	seenHoles := make(map[int]bool)
+	seenHandicaps := make(map[int]bool)
+	var lat, lon string
+	holeGeo := func(hole int) *CourseHoleGeo {
+		for i := range c.HoleGeo {
+			if c.HoleGeo[i].Hole == hole {
+				return &c.HoleGeo[i]
+			}
+		}
+		c.HoleGeo = append(c.HoleGeo, CourseHoleGeo{Hole: hole})
+		return &c.HoleGeo[len(c.HoleGeo)-1]
+	}
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "d":
+			c.DTag = tag[1]
+		case "title":
+			if c.Title == "" {
+				c.Title = tag[1]
+			}
+		case "location":
+			if c.Location == "" {
+				c.Location = tag[1]
+			}
+		case "country":
+			if c.Country == "" {
+				c.Country = tag[1]
+			}
+		case "website":
+			if c.Website == "" {
+				c.Website = tag[1]
+			}
+		case "architect":
+			if c.Architect == "" {
+				c.Architect = tag[1]
+			}
+		case "established":
+			if c.Established == "" {
+				c.Established = tag[1]
+			}
+		case "image":
+			if c.ImageURL == "" {
+				c.ImageURL = tag[1]
+			}
+		case "p":
+			if len(tag) >= 4 && tag[3] == "operator" && c.OperatorPubkey == "" {
+				c.OperatorPubkey = tag[1]
+			}
+		case "hole":
+			if len(tag) < 4 {
+				errs.add("hole", 0, "expected [\"hole\", <number>, <par>, <handicap>]")
+				continue
+			}
+			num, err := strconv.Atoi(tag[1])
+			if err != nil || num < 1 || num > MaxHoleCount {
+				errs.add("hole", 0, "invalid hole number %q", tag[1])
+				continue
+			}
+			if seenHoles[num] {
+				errs.add("hole", num, "duplicate hole")
+				continue
+			}
+			seenHoles[num] = true
+			par, err := strconv.Atoi(tag[2])
+			if err != nil {
+				errs.add("hole", num, "invalid par %q", tag[2])
+			} else if par < MinPar || par > MaxPar {
+				errs.add("hole", num, "par %d outside %d-%d", par, MinPar, MaxPar)
+			}
+			hcp, err := strconv.Atoi(tag[3])
+			if err != nil {
+				errs.add("hole", num, "invalid handicap %q", tag[3])
+			} else if seenHandicaps[hcp] {
+				errs.add("hole", num, "handicap %d used by more than one hole", hcp)
+			} else {
+				seenHandicaps[hcp] = true
+			}
+			c.Holes = append(c.Holes, CourseHole{Number: num, Par: par, Handicap: hcp})
+			c.TotalPar += par
+		case "tee":
+			if len(tag) < 4 {
+				errs.add("tee", 0, "expected [\"tee\", <name>, <rating>, <slope>]")
+				continue
+			}
+			rating, err := strconv.ParseFloat(tag[2], 64)
+			if err != nil {
+				errs.add("tee", 0, "invalid rating %q for tee %s", tag[2], tag[1])
+			}
+			slope, err := strconv.Atoi(tag[3])
+			if err != nil {
+				errs.add("tee", 0, "invalid slope %q for tee %s", tag[3], tag[1])
+			}
+			c.Tees = append(c.Tees, CourseTee{Name: tag[1], Rating: rating, Slope: slope})
+		case "yardage":
+			if len(tag) < 4 {
+				errs.add("yardage", 0, "expected [\"yardage\", <hole>, <tee>, <yards>]")
+				continue
+			}
+			hole, err := strconv.Atoi(tag[1])
+			if err != nil {
+				errs.add("yardage", 0, "invalid hole number %q", tag[1])
+				continue
+			}
+			yards, err := strconv.Atoi(tag[3])
+			if err != nil {
+				errs.add("yardage", hole, "invalid yards %q", tag[3])
+				continue
+			}
+			c.Yardages = append(c.Yardages, CourseYardage{Hole: hole, Tee: tag[2], Yards: yards})
+		case "g":
+			if len(tag[1]) > len(c.Geohash) {
+				c.Geohash = tag[1]
+			}
+		case "lat":
+			lat = tag[1]
+		case "lon":
+			lon = tag[1]
+		case "teebox", "green":
+			if len(tag) < 4 {
+				errs.add(tag[0], 0, "expected [\"%s\", <hole>, <lat>, <lon>]", tag[0])
+				continue
+			}
+			hole, err := strconv.Atoi(tag[1])
+			if err != nil || hole < 1 || hole > MaxHoleCount {
+				errs.add(tag[0], 0, "invalid hole number %q", tag[1])
+				continue
+			}
+			pos, err := parseLatLon(tag[2], tag[3])
+			if err != nil {
+				errs.add(tag[0], hole, "%s", err)
+				continue
+			}
+			if tag[0] == "teebox" {
+				holeGeo(hole).Tee = &pos
+			} else {
+				holeGeo(hole).Green = &pos
+			}
+		}
+	}
+
+	switch {
+	case lat != "" && lon != "":
+		if pos, err := parseLatLon(lat, lon); err != nil {
+			errs.add("lat", 0, "%s", err)
+		} else {
+			c.Position = &pos
+		}
+	case lat != "" || lon != "":
+		errs.add("lat", 0, "expected both a lat and a lon tag")
+	}
+	if c.Geohash != "" {
+		pos, err := DecodeGeohash(c.Geohash)
+		if err != nil {
+			errs.add("g", 0, "%s", err)
+			c.Geohash = ""
+		} else if c.Position == nil {
+			c.Position = &pos
+		}
+	}
+	slices.SortFunc(c.HoleGeo, func(a, b CourseHoleGeo) int { return a.Hole - b.Hole })
+	for _, hg := range c.HoleGeo {
+		if !seenHoles[hg.Hole] {
+			tag := "teebox"
+			if hg.Tee == nil {
+				tag = "green"
+			}
+			errs.add(tag, hg.Hole, "hole is not defined on the course")
+		}
+	}
+
+	for _, y := range c.Yardages {
+		if !seenHoles[y.Hole] {
+			errs.add("yardage", y.Hole, "hole is not defined on the course")
+		}
+		if _, ok := c.Tee(y.Tee); !ok {
+			errs.add("yardage", y.Hole, "tee %q is not defined on the course", y.Tee)
+		}
+	}
+
+	return c, errs
+}
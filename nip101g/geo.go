@@ -0,0 +1,66 @@
+package nip101g
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LatLon is a position in decimal degrees.
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+// Valid reports whether the position is on the globe.
+func (p LatLon) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// DecodeGeohash returns the center of the cell a geohash names.
+func DecodeGeohash(hash string) (LatLon, error) {
+	if hash == "" {
+		return LatLon{}, fmt.Errorf("empty geohash")
+	}
+	lat := [2]float64{-90, 90}
+	lon := [2]float64{-180, 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		v := strings.IndexRune(geohashAlphabet, c)
+		if v < 0 {
+			return LatLon{}, fmt.Errorf("invalid geohash character %q", c)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			rng := &lat
+			if even {
+				rng = &lon
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if v&(1<<bit) != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+	return LatLon{Lat: (lat[0] + lat[1]) / 2, Lon: (lon[0] + lon[1]) / 2}, nil
+}
+
+// parseLatLon reads a latitude and a longitude from two tag values.
+func parseLatLon(lat, lon string) (LatLon, error) {
+	var p LatLon
+	var err error
+	if p.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return p, fmt.Errorf("invalid latitude %q", lat)
+	}
+	if p.Lon, err = strconv.ParseFloat(lon, 64); err != nil {
+		return p, fmt.Errorf("invalid longitude %q", lon)
+	}
+	if !p.Valid() {
+		return p, fmt.Errorf("position %s,%s is off the globe", lat, lon)
+	}
+	return p, nil
+}
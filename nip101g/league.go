@@ -0,0 +1,144 @@
+package nip101g
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// DefaultLeaguePoints is the points table used when a league doesn't declare
+// one, scaled down from the FedEx Cup: 500 for a win down to 1 for 50th.
+var DefaultLeaguePoints = []int{
+	500, 300, 190, 135, 110, 100, 90, 85, 80, 75,
+	70, 65, 60, 57, 56, 55, 54, 53, 52, 51,
+	50, 49, 48, 47, 46, 45, 44, 43, 42, 41,
+	40, 39, 38, 37, 36, 35, 34, 33, 32, 31,
+	30, 25, 20, 15, 10, 8, 6, 4, 2, 1,
+}
+
+// League is a season of tournaments published as a kind 31924 NIP-52
+// calendar: a list of ["a", "31923:<pubkey>:<d>"] tags, with the points
+// awarded by finishing place from a ["points", <1st>, <2nd>, ...] tag. A
+// ["best", <n>] tag only counts each player's n best results.
+type League struct {
+	DTag        string
+	Title       string
+	Season      string
+	Image       string
+	Tournaments []string // tournament coordinates in schedule order
+	Points      []int    // points by place (index 0 = 1st), DefaultLeaguePoints when not declared
+	Best        int      // results counted per player, 0 for all of them
+}
+
+// MaxLeagueTournaments is the most tournaments a league can list, a weekly
+// event for a whole year.
+const MaxLeagueTournaments = 52
+
+// LeagueTag marks a kind 31924 calendar as a golf league with a ["t", LeagueTag] tag.
+const LeagueTag = "golf-league"
+
+// IsLeague reports whether a kind 31924 calendar is a golf league rather than
+// a generic NIP-52 calendar: it carries the LeagueTag, or every event it lists
+// is a 31923 tournament.
+func IsLeague(event nostr.Event) bool {
+	tournaments := 0
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "t":
+			if tag[1] == LeagueTag {
+				return true
+			}
+		case "a":
+			if !strings.HasPrefix(tag[1], "31923:") {
+				return false
+			}
+			tournaments++
+		}
+	}
+	return tournaments > 0
+}
+
+// ParseLeague parses a kind 31924 league.
+func ParseLeague(event nostr.Event) (League, ValidationErrors) {
+	var errs ValidationErrors
+	var l League
+
+	name := ""
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "d":
+			l.DTag = tag[1]
+		case "title":
+			if l.Title == "" {
+				l.Title = tag[1]
+			}
+		case "name":
+			if name == "" {
+				name = tag[1]
+			}
+		case "season":
+			if l.Season == "" {
+				l.Season = tag[1]
+			}
+		case "image":
+			if l.Image == "" {
+				l.Image = tag[1]
+			}
+		case "a":
+			if !strings.HasPrefix(tag[1], "31923:") {
+				errs.add("a", 0, "%q is not a 31923 tournament coordinate", tag[1])
+				continue
+			}
+			if slices.Contains(l.Tournaments, tag[1]) {
+				errs.add("a", 0, "tournament %q is listed more than once", tag[1])
+				continue
+			}
+			if len(l.Tournaments) == MaxLeagueTournaments {
+				errs.add("a", 0, "more than %d tournaments, %q is left out", MaxLeagueTournaments, tag[1])
+				continue
+			}
+			l.Tournaments = append(l.Tournaments, tag[1])
+		case "points":
+			if l.Points != nil {
+				continue
+			}
+			points := make([]int, 0, len(tag)-1)
+			for _, p := range tag[1:] {
+				n, err := strconv.Atoi(p)
+				if err != nil || n < 0 {
+					errs.add("points", 0, "invalid points %q", p)
+					points = nil
+					break
+				}
+				points = append(points, n)
+			}
+			l.Points = points
+		case "best":
+			n, err := strconv.Atoi(tag[1])
+			if err != nil || n < 1 {
+				errs.add("best", 0, "invalid result count %q", tag[1])
+				continue
+			}
+			l.Best = n
+		}
+	}
+	if l.Title == "" {
+		l.Title = name
+	}
+	if len(l.Points) == 0 {
+		l.Points = DefaultLeaguePoints
+	}
+	if len(l.Tournaments) == 0 {
+		errs.add("a", 0, "the league has no tournaments")
+	}
+
+	return l, errs
+}
@@ -0,0 +1,104 @@
+// Package nip101g parses and validates the golf events described by NIP-101g:
+// round initiations (1501), final round records (1502), live scorecards
+// (31501, and the older 30501), courses (33501), tournaments (31923) and
+// leagues of tournaments (31924).
+//
+// Every Parse function returns the typed event together with the list of
+// problems found while reading it. Parsing never stops at the first problem:
+// invalid values are dropped and reported so callers can still render
+// whatever is usable and explain the rest.
+package nip101g
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	KindRound               = 1501
+	KindRoundRecord         = 1502
+	KindLiveScorecard       = 31501
+	KindLegacyLiveScorecard = 30501
+	KindTournament          = 31923
+	KindLeague              = 31924
+	KindCourse              = 33501
+)
+
+const (
+	MinPar = 3
+	MaxPar = 6
+
+	// DefaultHoleCount is assumed when an event doesn't say how many holes
+	// the round has.
+	DefaultHoleCount = 18
+	// MaxHoleCount bounds hole numbers and hole counts so a bad event can't
+	// make callers allocate huge per-hole slices.
+	MaxHoleCount = 36
+
+	// Handicap indexes allowed by the World Handicap System, plus handicaps
+	// being negative.
+	MinHandicapIndex = -10.0
+	MaxHandicapIndex = 54.0
+)
+
+// ValidationError describes one problem found in an event.
+type ValidationError struct {
+	Tag     string // tag (or content field) where the problem was found
+	Hole    int    // hole number the problem refers to, 0 when not hole-specific
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Hole > 0 {
+		return fmt.Sprintf("%s (hole %d): %s", e.Tag, e.Hole, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Tag, e.Message)
+}
+
+// ValidationErrors is the list of problems returned by the Parse functions.
+// It is empty when the event is well-formed.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (errs *ValidationErrors) add(tag string, hole int, format string, args ...any) {
+	*errs = append(*errs, ValidationError{
+		Tag:     tag,
+		Hole:    hole,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Player is a p-tag on a golf event.
+type Player struct {
+	PubKey string
+	Relay  string
+	Role   string // "player" unless the tag says otherwise (e.g. "bot", "operator")
+}
+
+func parsePlayer(tag []string) Player {
+	p := Player{PubKey: tag[1], Role: "player"}
+	if len(tag) >= 3 {
+		p.Relay = tag[2]
+	}
+	if len(tag) >= 4 && tag[3] != "" {
+		p.Role = tag[3]
+	}
+	return p
+}
+
+// Address splits an addressable event coordinate ("<kind>:<pubkey>:<d>").
+// It returns ok=false when the coordinate doesn't have three parts.
+func Address(coord string) (kind string, pubkey string, d string, ok bool) {
+	parts := strings.SplitN(coord, ":", 3)
+	if len(parts) < 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
@@ -0,0 +1,438 @@
+package nip101g
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRound(t *testing.T) {
+	round, errs := ParseRound(nostr.Event{
+		Kind:    KindRound,
+		Content: `{"course_snapshot":{"course_name":"Pebble","tee_set":"blue","holes":[{"hole_number":1,"par":4},{"hole_number":2,"par":3},{"hole_number":3,"par":5}]},"notes":"windy"}`,
+		Tags: nostr.Tags{
+			{"course", "33501:abc:pebble"},
+			{"date", "2025-06-01"},
+			{"p", "aaaa", "", "player"},
+			{"p", "bbbb", "", "bot"},
+			{"handicap", "aaaa", "12.4"},
+			{"format", "Modified Stableford"},
+			{"score", "1", "4"},
+			{"score", "2", "2"},
+			{"score", "3", "6"},
+			{"total", "12"},
+		},
+	})
+	require.Empty(t, errs)
+	assert.Equal(t, "Pebble", round.Snapshot.CourseName)
+	assert.Equal(t, "blue", round.TeeSet)
+	assert.Equal(t, 3, round.HoleCount())
+	assert.Equal(t, []int{4, 3, 5}, round.Snapshot.HolePars)
+	assert.Equal(t, 12, round.Snapshot.TotalPar)
+	assert.Equal(t, 12, round.Total)
+	assert.Equal(t, 2, round.Strokes(2))
+	assert.Equal(t, "bot", round.Players[1].Role)
+	assert.Equal(t, "windy", round.Notes)
+	assert.Equal(t, map[string]float64{"aaaa": 12.4}, round.Handicaps)
+	assert.Equal(t, FormatModifiedStableford, round.Format)
+}
+
+func TestParseRoundProblems(t *testing.T) {
+	round, errs := ParseRound(nostr.Event{
+		Kind:    KindRound,
+		Content: `{"course_snapshot":{"holes":[{"hole_number":1,"par":4},{"hole_number":2,"par":8}]}}`,
+		Tags: nostr.Tags{
+			{"score", "1", "5"},
+			{"score", "1", "4"},
+			{"score", "3", "4"},
+			{"score", "x", "4"},
+			{"score", "2", "four"},
+			{"handicap", "aaaa", "60"},
+			{"format", "wolf"},
+			{"total", "7"},
+		},
+	})
+
+	assert.Equal(t, []HoleScore{{Hole: 1, Strokes: 5}}, round.Scores)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "course_snapshot", Hole: 2, Message: "par 8 outside 3-6"},
+		{Tag: "score", Hole: 1, Message: "duplicate hole"},
+		{Tag: "score", Message: "hole number 3 outside 1-2"},
+		{Tag: "score", Message: `invalid hole number "x"`},
+		{Tag: "score", Hole: 2, Message: `invalid stroke count "four"`},
+		{Tag: "handicap", Message: "handicap index 60 outside -10-54"},
+		{Tag: "format", Message: `unknown format "wolf"`},
+		{Tag: "total", Message: "total 7 does not match the sum of hole scores 5"},
+	}, errs)
+}
+
+func TestParseRoundBoundsHoleCount(t *testing.T) {
+	round, errs := ParseRound(nostr.Event{
+		Kind:    KindRound,
+		Content: `{"course_snapshot":{"hole_count":-1,"holes":[{"hole_number":1,"par":4}]}}`,
+		Tags:    nostr.Tags{{"score", "1", "4"}},
+	})
+	assert.Equal(t, 1, round.HoleCount())
+	assert.Equal(t, []int{4}, round.Snapshot.HolePars)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "course_snapshot", Message: "hole count -1 outside 1-36"},
+	}, errs)
+
+	round, errs = ParseRound(nostr.Event{
+		Kind:    KindRound,
+		Content: `{"course_snapshot":{"hole_count":1000000000}}`,
+	})
+	assert.Equal(t, DefaultHoleCount, round.HoleCount())
+	assert.Equal(t, ValidationErrors{
+		{Tag: "course_snapshot", Message: "hole count 1000000000 outside 1-36"},
+	}, errs)
+}
+
+func TestParseRoundRecordRequiresRound(t *testing.T) {
+	_, errs := ParseRoundRecord(nostr.Event{Kind: KindRoundRecord, Tags: nostr.Tags{{"total", "72"}}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "e", errs[0].Tag)
+}
+
+func TestParseLiveScorecardPrefersContent(t *testing.T) {
+	live, errs := ParseLiveScorecard(nostr.Event{
+		Kind:    KindLiveScorecard,
+		Content: `{"scores":[{"holeNumber":1,"strokes":3},{"holeNumber":2,"strokes":0}]}`,
+		Tags: nostr.Tags{
+			{"d", "live-1"},
+			{"e", "round-id"},
+			{"score", "1", "9"},
+		},
+	})
+	require.Empty(t, errs)
+	assert.Equal(t, "round-id", live.RoundID)
+	assert.Equal(t, 3, live.Sum())
+	assert.Equal(t, 1, live.HolesPlayed())
+}
+
+func TestParseCourse(t *testing.T) {
+	course, errs := ParseCourse(nostr.Event{
+		Kind: KindCourse,
+		Tags: nostr.Tags{
+			{"d", "pebble"},
+			{"title", "Pebble Beach"},
+			{"p", "op", "", "operator"},
+			{"hole", "1", "4", "1"},
+			{"hole", "2", "2", "1"},
+			{"tee", "blue", "72.1", "131"},
+			{"yardage", "1", "blue", "380"},
+			{"yardage", "2", "red", "160"},
+		},
+	})
+	assert.Equal(t, "Pebble Beach", course.Title)
+	assert.Equal(t, "op", course.OperatorPubkey)
+	assert.Equal(t, 6, course.TotalPar)
+	assert.Equal(t, []int{4, 2}, course.HolePars())
+	tee, ok := course.Tee("blue")
+	require.True(t, ok)
+	assert.Equal(t, 131, tee.Slope)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "hole", Hole: 2, Message: "par 2 outside 3-6"},
+		{Tag: "hole", Hole: 2, Message: "handicap 1 used by more than one hole"},
+		{Tag: "yardage", Hole: 2, Message: `tee "red" is not defined on the course`},
+	}, errs)
+}
+
+func TestParseCourseRejectsOversizedHole(t *testing.T) {
+	course, errs := ParseCourse(nostr.Event{
+		Kind: KindCourse,
+		Tags: nostr.Tags{
+			{"hole", "1", "4", "1"},
+			{"hole", "2000000000", "4", "2"},
+		},
+	})
+	assert.Equal(t, []int{4}, course.HolePars())
+	assert.Equal(t, ValidationErrors{
+		{Tag: "hole", Message: `invalid hole number "2000000000"`},
+	}, errs)
+}
+
+func TestParseCourseGeo(t *testing.T) {
+	course, errs := ParseCourse(nostr.Event{
+		Kind: KindCourse,
+		Tags: nostr.Tags{
+			{"d", "skagen"},
+			{"g", "u4pr"},
+			{"g", "u4pruydqqvj"},
+			{"hole", "1", "4", "1"},
+			{"hole", "2", "3", "2"},
+			{"teebox", "1", "57.6490", "10.4070"},
+			{"green", "1", "57.6510", "10.4100"},
+			{"green", "2", "57.6520", "10.4080"},
+			{"green", "3", "57.6530", "10.4090"},
+			{"teebox", "2", "95", "10"},
+		},
+	})
+	assert.Equal(t, "u4pruydqqvj", course.Geohash)
+	require.NotNil(t, course.Position)
+	assert.InDelta(t, 57.64911, course.Position.Lat, 0.0001)
+	assert.InDelta(t, 10.40744, course.Position.Lon, 0.0001)
+	require.Len(t, course.HoleGeo, 3)
+	assert.Equal(t, &LatLon{57.6490, 10.4070}, course.HoleGeo[0].Tee)
+	assert.Equal(t, &LatLon{57.6510, 10.4100}, course.HoleGeo[0].Green)
+	assert.Nil(t, course.HoleGeo[1].Tee)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "teebox", Hole: 2, Message: "position 95,10 is off the globe"},
+		{Tag: "green", Hole: 3, Message: "hole is not defined on the course"},
+	}, errs)
+
+	// explicit coordinates win over the geohash
+	course, errs = ParseCourse(nostr.Event{
+		Kind: KindCourse,
+		Tags: nostr.Tags{{"g", "u4pr"}, {"lat", "-33.9"}, {"lon", "18.4"}},
+	})
+	require.Empty(t, errs)
+	assert.Equal(t, &LatLon{-33.9, 18.4}, course.Position)
+
+	_, errs = ParseCourse(nostr.Event{Kind: KindCourse, Tags: nostr.Tags{{"g", "u4pa"}, {"lat", "10"}}})
+	assert.Equal(t, ValidationErrors{
+		{Tag: "lat", Message: "expected both a lat and a lon tag"},
+		{Tag: "g", Message: `invalid geohash character 'a'`},
+	}, errs)
+}
+
+func TestParseTournament(t *testing.T) {
+	tournament, errs := ParseTournament(nostr.Event{
+		Kind: KindTournament,
+		Tags: nostr.Tags{
+			{"d", "club-champs"},
+			{"name", "Club Champs"},
+			{"start", "1717200000"},
+			{"status", "in_progress"},
+			{"course", "33501:abc:pebble"},
+			{"p", "aaaa"},
+			{"p", "bbbb"},
+			{"format", "best-ball"},
+			{"team", "Eagles", "aaaa", "bbbb"},
+			{"rounds", "4"},
+			{"cut", "top", "65"},
+			{"tiebreak", "playoff", "Countback", "coin"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{{Tag: "tiebreak", Message: `unknown tiebreak rule "coin"`}}, errs)
+	assert.Equal(t, []string{TiebreakPlayoff, TiebreakCountback}, tournament.Tiebreaks)
+	assert.Equal(t, "Club Champs", tournament.Title)
+	assert.Equal(t, int64(1717200000), tournament.Start)
+	assert.Equal(t, []string{"aaaa", "bbbb"}, tournament.Roster)
+	assert.Equal(t, FormatBestBall, tournament.Format)
+	assert.Equal(t, []Team{{Name: "Eagles", Members: []string{"aaaa", "bbbb"}}}, tournament.Teams)
+	assert.Equal(t, 4, tournament.Rounds)
+	assert.Equal(t, &CutRule{AfterRound: 2, Top: 65}, tournament.Cut)
+
+	_, errs = ParseTournament(nostr.Event{Kind: KindTournament, Tags: nostr.Tags{{"start", "soon"}, {"status", "paused"}}})
+	assert.Len(t, errs, 2)
+
+	tournament, errs = ParseTournament(nostr.Event{Kind: KindTournament, Tags: nostr.Tags{{"cut", "within", "10", "1"}}})
+	assert.Nil(t, tournament.Cut)
+	assert.Equal(t, ValidationErrors{{Tag: "cut", Message: "cut after round 1 of a 1 round tournament"}}, errs)
+
+	tournament, errs = ParseTournament(nostr.Event{Kind: KindTournament, Tags: nostr.Tags{{"rounds", "1000000000"}}})
+	assert.Equal(t, 1, tournament.Rounds)
+	assert.Equal(t, ValidationErrors{{Tag: "rounds", Message: `invalid round count "1000000000"`}}, errs)
+}
+
+func TestParseTeams(t *testing.T) {
+	round, errs := ParseRound(nostr.Event{
+		Kind: KindRound,
+		Tags: nostr.Tags{
+			{"team", "A", "aaaa", "bbbb"},
+			{"team", "B", "cccc", "aaaa"},
+			{"team", "C"},
+			{"team", "D", "aaaa"},
+		},
+	})
+	assert.Equal(t, []Team{
+		{Name: "A", Members: []string{"aaaa", "bbbb"}},
+		{Name: "B", Members: []string{"cccc"}},
+	}, round.Teams)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "team", Message: "player aaaa is already on a team"},
+		{Tag: "team", Message: `expected ["team", <name>, <pubkey>...]`},
+		{Tag: "team", Message: "player aaaa is already on a team"},
+		{Tag: "team", Message: `team "D" has no players`},
+	}, errs)
+}
+
+func TestParsePlayoff(t *testing.T) {
+	record, errs := ParseRoundRecord(nostr.Event{
+		Kind: KindRoundRecord,
+		Tags: nostr.Tags{
+			{"e", "round"},
+			{"score", "1", "4"},
+			{"score", "2", "5", "playoff"},
+			{"score", "1", "4", "playoff"},
+			{"total", "4"},
+		},
+	})
+	require.Empty(t, errs)
+	assert.Equal(t, 4, record.Sum())
+	assert.Equal(t, []HoleScore{{Hole: 1, Strokes: 4}, {Hole: 2, Strokes: 5}}, record.Playoff)
+}
+
+func TestParseFlights(t *testing.T) {
+	tournament, errs := ParseTournament(nostr.Event{
+		Kind: KindTournament,
+		Tags: nostr.Tags{
+			{"flight", "A", "", "12.4"},
+			{"flight", "B", "12.5"},
+			{"flight", "A"},
+			{"p", "aaaa", "", "participant"},
+			{"p", "bbbb", "", "host"},
+			{"division", "aaaa", "Seniors"},
+			{"division", "aaaa", "Juniors"},
+			{"division", "bbbb"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{
+		{Tag: "flight", Message: `duplicate flight "A"`},
+		{Tag: "division", Message: "player aaaa is in two divisions"},
+		{Tag: "division", Message: `expected ["division", <pubkey>, <flight>]`},
+	}, errs)
+	assert.Equal(t, []Flight{
+		{Name: "A", HasBand: true, MinIndex: MinHandicapIndex, MaxIndex: 12.4},
+		{Name: "B", HasBand: true, MinIndex: 12.5, MaxIndex: MaxHandicapIndex},
+		{Name: "Seniors", MinIndex: 0, MaxIndex: 0},
+	}, tournament.Flights)
+	assert.Equal(t, map[string]string{"aaaa": "Seniors"}, tournament.Divisions)
+	assert.True(t, tournament.Flights[1].Contains(20))
+	assert.False(t, tournament.Flights[2].Contains(20))
+}
+
+func TestParsePurse(t *testing.T) {
+	tournament, errs := ParseTournament(nostr.Event{
+		Kind: KindTournament,
+		Tags: nostr.Tags{
+			{"purse", "100000", "sats"},
+			{"payout", "2", "30%"},
+			{"payout", "1", "50%"},
+			{"payout", "3", "5000"},
+			{"payout", "3", "1000"},
+			{"sidepot", "Closest to the pin #7", "2000", "aaaa"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{{Tag: "payout", Message: "duplicate payout for place 3"}}, errs)
+	require.NotNil(t, tournament.Purse)
+	assert.Equal(t, []Payout{{Place: 1, Percent: 50}, {Place: 2, Percent: 30}, {Place: 3, Amount: 5000}}, tournament.Purse.Payouts)
+	assert.Equal(t, 50000.0, tournament.Purse.Payouts[0].Value(tournament.Purse.Amount))
+	assert.Equal(t, []SidePot{{Name: "Closest to the pin #7", Amount: 2000, Winner: "aaaa"}}, tournament.Purse.SidePots)
+
+	_, errs = ParseTournament(nostr.Event{Kind: KindTournament, Tags: nostr.Tags{{"payout", "1", "60%"}}})
+	assert.Equal(t, ValidationErrors{{Tag: "payout", Message: "percentage payouts need a purse amount"}}, errs)
+
+	// units are matched whatever their case
+	tournament, _ = ParseTournament(nostr.Event{Kind: KindTournament, Tags: nostr.Tags{{"purse", "5000", " SATS "}}})
+	assert.Equal(t, "sats", tournament.Purse.Unit)
+}
+
+func TestParseBracket(t *testing.T) {
+	tournament, errs := ParseTournament(nostr.Event{
+		Kind: KindTournament,
+		Tags: nostr.Tags{
+			{"seed", "2", "bbbb"},
+			{"seed", "1", "aaaa"},
+			{"seed", "4", "aaaa"},
+			{"match", "1", "1", "round1"},
+			{"match", "1", "2", "round2", "bbbb", "cccc"},
+			{"match", "1", "2", "round3"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{
+		{Tag: "seed", Message: "player aaaa is seeded twice"},
+		{Tag: "match", Message: "duplicate match 2 of round 1"},
+	}, errs)
+	require.NotNil(t, tournament.Bracket)
+	assert.Equal(t, []string{"aaaa", "bbbb", "", ""}, tournament.Bracket.Seeds)
+	assert.Equal(t, []BracketMatch{
+		{Round: 1, Position: 1, RoundID: "round1"},
+		{Round: 1, Position: 2, RoundID: "round2", PlayerA: "bbbb", PlayerB: "cccc"},
+	}, tournament.Bracket.Matches)
+
+	// brackets are bounded so a tag can't make us build billions of slots
+	tournament, errs = ParseTournament(nostr.Event{
+		Kind: KindTournament,
+		Tags: nostr.Tags{
+			{"seed", "1000000000", "aaaa"},
+			{"seed", "128", "bbbb"},
+			{"match", "62", "1", "round1"},
+			{"match", "7", "2", "round2"},
+			{"match", "1", "65", "round3"},
+			{"match", "7", "1", "final"},
+			{"match", "1", "64", "round4"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{
+		{Tag: "seed", Message: "seed 1000000000 is over the maximum of 128"},
+		{Tag: "match", Message: `invalid match round "62"`},
+		{Tag: "match", Message: `invalid match position "2"`},
+		{Tag: "match", Message: `invalid match position "65"`},
+	}, errs)
+	require.NotNil(t, tournament.Bracket)
+	assert.Len(t, tournament.Bracket.Seeds, MaxBracketSeeds)
+	assert.Len(t, tournament.Bracket.Matches, 2)
+}
+
+func TestParseLeague(t *testing.T) {
+	league, errs := ParseLeague(nostr.Event{
+		Kind: KindLeague,
+		Tags: nostr.Tags{
+			{"d", "order-of-merit"},
+			{"name", "Order of Merit"},
+			{"season", "2025"},
+			{"a", "31923:abc:spring-open"},
+			{"a", "33501:abc:pebble"},
+			{"a", "31923:abc:summer-cup"},
+			{"points", "100", "60", "40"},
+			{"best", "3"},
+		},
+	})
+	assert.Equal(t, ValidationErrors{{Tag: "a", Message: `"33501:abc:pebble" is not a 31923 tournament coordinate`}}, errs)
+	assert.Equal(t, "Order of Merit", league.Title)
+	assert.Equal(t, "2025", league.Season)
+	assert.Equal(t, []string{"31923:abc:spring-open", "31923:abc:summer-cup"}, league.Tournaments)
+	assert.Equal(t, []int{100, 60, 40}, league.Points)
+	assert.Equal(t, 3, league.Best)
+
+	league, errs = ParseLeague(nostr.Event{Kind: KindLeague, Tags: nostr.Tags{{"points", "10", "five"}}})
+	assert.Equal(t, DefaultLeaguePoints, league.Points)
+	assert.Len(t, errs, 2)
+
+	// a tournament listed twice counts once, and the schedule is bounded
+	tags := nostr.Tags{{"a", "31923:abc:t0"}}
+	for i := range MaxLeagueTournaments + 1 {
+		tags = append(tags, nostr.Tag{"a", "31923:abc:t" + strconv.Itoa(i)})
+	}
+	league, errs = ParseLeague(nostr.Event{Kind: KindLeague, Tags: tags})
+	assert.Len(t, league.Tournaments, MaxLeagueTournaments)
+	assert.Equal(t, ValidationErrors{
+		{Tag: "a", Message: `tournament "31923:abc:t0" is listed more than once`},
+		{Tag: "a", Message: `more than 52 tournaments, "31923:abc:t52" is left out`},
+	}, errs)
+}
+
+func TestIsLeague(t *testing.T) {
+	assert.True(t, IsLeague(nostr.Event{Kind: KindLeague, Tags: nostr.Tags{
+		{"a", "31923:abc:spring-open"},
+		{"a", "31923:abc:summer-cup"},
+	}}))
+	assert.True(t, IsLeague(nostr.Event{Kind: KindLeague, Tags: nostr.Tags{
+		{"t", "golf-league"},
+		{"a", "31922:abc:practice-day"},
+	}}))
+
+	// a plain NIP-52 calendar mixing date and time events isn't a league
+	assert.False(t, IsLeague(nostr.Event{Kind: KindLeague, Tags: nostr.Tags{
+		{"d", "meetups"},
+		{"title", "Community meetups"},
+		{"a", "31922:abc:birthday"},
+		{"a", "31923:abc:conference"},
+	}}))
+	assert.False(t, IsLeague(nostr.Event{Kind: KindLeague, Tags: nostr.Tags{{"title", "Empty calendar"}}}))
+}
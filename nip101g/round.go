@@ -0,0 +1,498 @@
+package nip101g
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// HoleScore is the number of strokes taken on one hole.
+type HoleScore struct {
+	Hole    int
+	Strokes int
+}
+
+// Scorecard holds the hole-by-hole scores shared by rounds, round records and
+// live scorecards.
+type Scorecard struct {
+	Scores  []HoleScore // valid scores in the order they appear on the event
+	Total   int         // value of the "total" tag, 0 when absent
+	Playoff []HoleScore // playoff holes in the order played, from ["score", <n>, <strokes>, "playoff"] tags
+}
+
+// Sum adds up all hole scores.
+func (sc Scorecard) Sum() int {
+	sum := 0
+	for _, hs := range sc.Scores {
+		sum += hs.Strokes
+	}
+	return sum
+}
+
+// TotalOrSum returns the declared total, falling back to the sum of hole scores.
+func (sc Scorecard) TotalOrSum() int {
+	if sc.Total > 0 {
+		return sc.Total
+	}
+	return sc.Sum()
+}
+
+// HolesPlayed is the number of holes with a score.
+func (sc Scorecard) HolesPlayed() int {
+	return len(sc.Scores)
+}
+
+// Strokes returns the score for a hole, or 0 if it wasn't played.
+func (sc Scorecard) Strokes(hole int) int {
+	for _, hs := range sc.Scores {
+		if hs.Hole == hole {
+			return hs.Strokes
+		}
+	}
+	return 0
+}
+
+// CourseSnapshot is the copy of the course layout embedded in a round's content.
+type CourseSnapshot struct {
+	CourseName string
+	TeeSet     string
+	HoleCount  int
+	HolePars   []int // par per hole (index 0 = hole 1), nil when the snapshot has no holes
+	TotalPar   int
+}
+
+// Scoring formats a round can declare with a ["format", <name>] tag. Rounds
+// without one are stroke play.
+const (
+	FormatStroke             = "stroke"
+	FormatStableford         = "stableford"
+	FormatModifiedStableford = "modified_stableford"
+	FormatMatchPlay          = "match_play"
+	FormatSkins              = "skins"
+	FormatNassau             = "nassau"
+	FormatBestBall           = "best_ball"
+	FormatScramble           = "scramble"
+	FormatAlternateShot      = "alternate_shot"
+)
+
+var roundFormats = []string{
+	FormatStroke, FormatStableford, FormatModifiedStableford, FormatMatchPlay,
+	FormatSkins, FormatNassau, FormatBestBall, FormatScramble, FormatAlternateShot,
+}
+
+// Team groups players who score together, from a
+// ["team", <name>, <pubkey>, <pubkey>...] tag on a round or tournament.
+type Team struct {
+	Name    string
+	Members []string // pubkeys, in tag order
+}
+
+// Round is a kind 1501 round initiation.
+type Round struct {
+	CourseRef     string // "33501:<pubkey>:<d>"
+	TournamentRef string // "31923:<pubkey>:<d>" when the round belongs to a tournament
+	Number        int    // round of a multi-round tournament (1 = first), from the "round" tag
+	Date          string
+	TeeSet        string
+	Format        string // one of the Format constants, "" when not declared
+	Players       []Player
+	Teams         []Team
+	Handicaps     map[string]float64 // handicap index by player pubkey, from "handicap" tags
+	Snapshot      CourseSnapshot
+	Notes         string
+	Scorecard
+}
+
+// RoundNumber is the round of the tournament this is, 1 when not declared.
+func (r Round) RoundNumber() int {
+	return max(1, r.Number)
+}
+
+// HoleCount is the number of holes in the round, DefaultHoleCount if unknown.
+func (r Round) HoleCount() int {
+	if r.Snapshot.HoleCount > 0 {
+		return min(r.Snapshot.HoleCount, MaxHoleCount)
+	}
+	return DefaultHoleCount
+}
+
+// RoundRecord is a kind 1502 final record. It has the same shape as a round
+// plus the reference to the 1501 it closes.
+type RoundRecord struct {
+	Round
+	RoundID string
+}
+
+// LiveScorecard is a kind 31501 (or legacy 30501) scorecard updated while a
+// round is being played.
+type LiveScorecard struct {
+	DTag      string
+	RoundID   string
+	CourseRef string
+	Date      string
+	TeeSet    string
+	Status    string
+	Players   []Player
+	Scorecard
+}
+
+type roundContent struct {
+	CourseSnapshot *struct {
+		CourseName string `json:"course_name"`
+		TeeSet     string `json:"tee_set"`
+		HoleCount  int    `json:"hole_count"`
+		Holes      []struct {
+			HoleNumber int `json:"hole_number"`
+			Par        int `json:"par"`
+		} `json:"holes"`
+	} `json:"course_snapshot"`
+	Notes  string `json:"notes"`
+	Scores []struct {
+		HoleNumber int `json:"holeNumber"`
+		Strokes    int `json:"strokes"`
+	} `json:"scores"`
+}
+
+// parseContent decodes the JSON content used by rounds and live scorecards.
+// Content that isn't a JSON object is not an error, it just carries no data.
+func parseContent(content string, errs *ValidationErrors) roundContent {
+	var rc roundContent
+	if !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return rc
+	}
+	if err := json.Unmarshal([]byte(content), &rc); err != nil {
+		errs.add("content", 0, "invalid JSON: %s", err)
+	}
+	return rc
+}
+
+func parseSnapshot(rc roundContent, errs *ValidationErrors) CourseSnapshot {
+	var snap CourseSnapshot
+	if rc.CourseSnapshot == nil {
+		return snap
+	}
+
+	snap.CourseName = rc.CourseSnapshot.CourseName
+	snap.TeeSet = rc.CourseSnapshot.TeeSet
+	snap.HoleCount = rc.CourseSnapshot.HoleCount
+	if snap.HoleCount < 0 || snap.HoleCount > MaxHoleCount {
+		errs.add("course_snapshot", 0, "hole count %d outside 1-%d", snap.HoleCount, MaxHoleCount)
+		snap.HoleCount = 0
+	}
+	holes := rc.CourseSnapshot.Holes
+	if len(holes) == 0 {
+		return snap
+	}
+	if snap.HoleCount == 0 {
+		snap.HoleCount = min(len(holes), MaxHoleCount)
+	}
+
+	snap.HolePars = make([]int, snap.HoleCount)
+	seen := make(map[int]bool, len(holes))
+	for _, h := range holes {
+		if h.HoleNumber < 1 || h.HoleNumber > snap.HoleCount {
+			errs.add("course_snapshot", 0, "hole number %d outside 1-%d", h.HoleNumber, snap.HoleCount)
+			continue
+		}
+		if seen[h.HoleNumber] {
+			errs.add("course_snapshot", h.HoleNumber, "duplicate hole")
+			continue
+		}
+		seen[h.HoleNumber] = true
+		if h.Par < MinPar || h.Par > MaxPar {
+			errs.add("course_snapshot", h.HoleNumber, "par %d outside %d-%d", h.Par, MinPar, MaxPar)
+		}
+		snap.HolePars[h.HoleNumber-1] = h.Par
+		snap.TotalPar += h.Par
+	}
+
+	return snap
+}
+
+// scorecardParser accumulates hole scores while checking for bad, out of
+// range and duplicate holes.
+type scorecardParser struct {
+	holeCount int
+	seen      map[int]bool
+	errs      *ValidationErrors
+	sc        Scorecard
+}
+
+func newScorecardParser(holeCount int, errs *ValidationErrors) *scorecardParser {
+	return &scorecardParser{holeCount: holeCount, seen: make(map[int]bool), errs: errs}
+}
+
+func (p *scorecardParser) addScore(source string, hole, strokes int) {
+	if hole < 1 || hole > p.holeCount {
+		p.errs.add(source, 0, "hole number %d outside 1-%d", hole, p.holeCount)
+		return
+	}
+	if strokes < 1 {
+		p.errs.add(source, hole, "invalid stroke count %d", strokes)
+		return
+	}
+	if p.seen[hole] {
+		p.errs.add(source, hole, "duplicate hole")
+		return
+	}
+	p.seen[hole] = true
+	p.sc.Scores = append(p.sc.Scores, HoleScore{Hole: hole, Strokes: strokes})
+}
+
+func (p *scorecardParser) scoreTag(tag nostr.Tag) {
+	if len(tag) >= 4 && tag[3] == "playoff" {
+		p.playoffTag(tag)
+		return
+	}
+	if len(tag) < 3 {
+		p.errs.add("score", 0, "expected [\"score\", <hole>, <strokes>]")
+		return
+	}
+	hole, err := strconv.Atoi(tag[1])
+	if err != nil {
+		p.errs.add("score", 0, "invalid hole number %q", tag[1])
+		return
+	}
+	strokes, err := strconv.Atoi(tag[2])
+	if err != nil {
+		p.errs.add("score", hole, "invalid stroke count %q", tag[2])
+		return
+	}
+	p.addScore("score", hole, strokes)
+}
+
+// playoffTag reads a ["score", <playoff hole>, <strokes>, "playoff"] tag. Playoff
+// holes are numbered from 1 and don't count towards the total.
+func (p *scorecardParser) playoffTag(tag nostr.Tag) {
+	hole, err := strconv.Atoi(tag[1])
+	if err != nil || hole < 1 {
+		p.errs.add("score", 0, "invalid playoff hole %q", tag[1])
+		return
+	}
+	strokes, err := strconv.Atoi(tag[2])
+	if err != nil || strokes < 1 {
+		p.errs.add("score", 0, "invalid playoff stroke count %q", tag[2])
+		return
+	}
+	if slices.ContainsFunc(p.sc.Playoff, func(hs HoleScore) bool { return hs.Hole == hole }) {
+		p.errs.add("score", 0, "duplicate playoff hole %d", hole)
+		return
+	}
+	p.sc.Playoff = append(p.sc.Playoff, HoleScore{Hole: hole, Strokes: strokes})
+}
+
+func (p *scorecardParser) totalTag(tag nostr.Tag) {
+	total, err := strconv.Atoi(tag[1])
+	if err != nil {
+		p.errs.add("total", 0, "invalid total %q", tag[1])
+		return
+	}
+	p.sc.Total = total
+}
+
+func (p *scorecardParser) finish() Scorecard {
+	slices.SortFunc(p.sc.Playoff, func(a, b HoleScore) int { return a.Hole - b.Hole })
+	if p.sc.Total > 0 && len(p.sc.Scores) > 0 {
+		if sum := p.sc.Sum(); sum != p.sc.Total {
+			p.errs.add("total", 0, "total %d does not match the sum of hole scores %d", p.sc.Total, sum)
+		}
+	}
+	return p.sc
+}
+
+// ParseRound parses a kind 1501 round initiation.
+func ParseRound(event nostr.Event) (Round, ValidationErrors) {
+	var errs ValidationErrors
+	round := parseRound(event, &errs)
+	return round, errs
+}
+
+func parseRound(event nostr.Event, errs *ValidationErrors) Round {
+	rc := parseContent(event.Content, errs)
+	round := Round{
+		Snapshot: parseSnapshot(rc, errs),
+		Notes:    rc.Notes,
+	}
+	round.TeeSet = round.Snapshot.TeeSet
+
+	p := newScorecardParser(round.HoleCount(), errs)
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "course":
+			if round.CourseRef == "" {
+				round.CourseRef = tag[1]
+			}
+		case "a":
+			if round.TournamentRef == "" && strings.HasPrefix(tag[1], "31923:") {
+				round.TournamentRef = tag[1]
+			}
+		case "date":
+			if round.Date == "" {
+				round.Date = tag[1]
+			}
+		case "round":
+			n, err := strconv.Atoi(tag[1])
+			if err != nil || n < 1 {
+				errs.add("round", 0, "invalid round number %q", tag[1])
+				continue
+			}
+			round.Number = n
+		case "tee":
+			round.TeeSet = tag[1]
+		case "format":
+			if round.Format == "" {
+				round.Format = parseFormat(tag[1], errs)
+			}
+		case "p":
+			round.Players = append(round.Players, parsePlayer(tag))
+		case "team":
+			if team, ok := parseTeam(tag, round.Teams, errs); ok {
+				round.Teams = append(round.Teams, team)
+			}
+		case "handicap":
+			pubkey, index, ok := parseHandicap(tag, errs)
+			if !ok {
+				continue
+			}
+			if round.Handicaps == nil {
+				round.Handicaps = make(map[string]float64)
+			}
+			round.Handicaps[pubkey] = index
+		case "score":
+			p.scoreTag(tag)
+		case "total":
+			p.totalTag(tag)
+		}
+	}
+	round.Scorecard = p.finish()
+
+	return round
+}
+
+// parseFormat normalizes a format name, accepting "Modified Stableford" or
+// "match-play" for the canonical forms. Unknown formats are dropped.
+func parseFormat(value string, errs *ValidationErrors) string {
+	format := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(value)))
+	if !slices.Contains(roundFormats, format) {
+		errs.add("format", 0, "unknown format %q", value)
+		return ""
+	}
+	return format
+}
+
+// parseTeam reads a ["team", <name>, <pubkey>...] tag. A player can only be on
+// one of the teams already parsed; later duplicates are dropped.
+func parseTeam(tag nostr.Tag, teams []Team, errs *ValidationErrors) (Team, bool) {
+	if len(tag) < 3 || tag[1] == "" {
+		errs.add("team", 0, "expected [\"team\", <name>, <pubkey>...]")
+		return Team{}, false
+	}
+	team := Team{Name: tag[1]}
+	for _, pk := range tag[2:] {
+		if pk == "" {
+			continue
+		}
+		taken := slices.ContainsFunc(teams, func(t Team) bool { return slices.Contains(t.Members, pk) })
+		if taken || slices.Contains(team.Members, pk) {
+			errs.add("team", 0, "player %s is already on a team", pk)
+			continue
+		}
+		team.Members = append(team.Members, pk)
+	}
+	if len(team.Members) == 0 {
+		errs.add("team", 0, "team %q has no players", team.Name)
+		return Team{}, false
+	}
+	return team, true
+}
+
+// parseHandicap reads a ["handicap", <pubkey>, <index>] tag. Plus handicaps
+// are written as negative indexes.
+func parseHandicap(tag nostr.Tag, errs *ValidationErrors) (pubkey string, index float64, ok bool) {
+	if len(tag) < 3 {
+		errs.add("handicap", 0, "expected [\"handicap\", <pubkey>, <index>]")
+		return "", 0, false
+	}
+	index, err := strconv.ParseFloat(tag[2], 64)
+	if err != nil {
+		errs.add("handicap", 0, "invalid handicap index %q", tag[2])
+		return "", 0, false
+	}
+	if index < MinHandicapIndex || index > MaxHandicapIndex {
+		errs.add("handicap", 0, "handicap index %g outside %g-%g", index, MinHandicapIndex, MaxHandicapIndex)
+		return "", 0, false
+	}
+	return tag[1], index, true
+}
+
+// ParseRoundRecord parses a kind 1502 final round record.
+func ParseRoundRecord(event nostr.Event) (RoundRecord, ValidationErrors) {
+	var errs ValidationErrors
+	rec := RoundRecord{Round: parseRound(event, &errs)}
+	if eTag := event.Tags.Find("e"); eTag != nil {
+		rec.RoundID = eTag[1]
+	} else {
+		errs.add("e", 0, "missing reference to the round initiation")
+	}
+	return rec, errs
+}
+
+// ParseLiveScorecard parses a kind 31501 (or legacy 30501) live scorecard.
+// Scores come from the JSON content when it has any, otherwise from score tags.
+func ParseLiveScorecard(event nostr.Event) (LiveScorecard, ValidationErrors) {
+	var errs ValidationErrors
+	lc := LiveScorecard{}
+
+	rc := parseContent(event.Content, &errs)
+	p := newScorecardParser(DefaultHoleCount, &errs)
+	for _, s := range rc.Scores {
+		if s.Strokes == 0 {
+			continue // not played yet
+		}
+		p.addScore("content.scores", s.HoleNumber, s.Strokes)
+	}
+	useTags := len(p.sc.Scores) == 0
+
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "d":
+			lc.DTag = tag[1]
+		case "e":
+			if lc.RoundID == "" {
+				lc.RoundID = tag[1]
+			}
+		case "course":
+			if lc.CourseRef == "" {
+				lc.CourseRef = tag[1]
+			}
+		case "date":
+			if lc.Date == "" {
+				lc.Date = tag[1]
+			}
+		case "tee":
+			lc.TeeSet = tag[1]
+		case "status":
+			lc.Status = tag[1]
+		case "p":
+			lc.Players = append(lc.Players, parsePlayer(tag))
+		case "score":
+			if useTags {
+				p.scoreTag(tag)
+			}
+		case "total":
+			p.totalTag(tag)
+		}
+	}
+	lc.Scorecard = p.finish()
+
+	return lc, errs
+}
@@ -0,0 +1,480 @@
+package nip101g
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+var tournamentStatuses = []string{"registration_open", "registration_closed", "in_progress", "complete"}
+
+// MaxTournamentRounds is the most rounds a tournament can declare.
+const MaxTournamentRounds = 8
+
+// Tiebreak rules a tournament can declare, in the order they are tried, with a
+// ["tiebreak", <rule>, <rule>...] tag.
+const (
+	TiebreakCountback = "countback" // back 9, back 6, back 3, last hole of the final round
+	TiebreakNet       = "net"       // lowest net score
+	TiebreakPlayoff   = "playoff"   // playoff holes, sudden death
+)
+
+var tiebreakRules = []string{TiebreakCountback, TiebreakNet, TiebreakPlayoff}
+
+// CutRule trims the field after a round of a multi-round tournament, from a
+// ["cut", "top", <n>, <after round>] or ["cut", "within", <strokes>, <after round>] tag.
+// Players tied on the cut score always make it.
+type CutRule struct {
+	AfterRound int
+	Top        int // top N and ties, 0 when cutting by strokes
+	Within     int // within this many strokes of the lead, used when Top is 0
+}
+
+// Flight is a division of the field with its own leaderboard, from a
+// ["flight", <name>] tag, or ["flight", <name>, <min index>, <max index>] for a
+// handicap band (either bound can be left empty). Players can also be put in a
+// flight by name with a ["division", <pubkey>, <flight>] tag. The 4th field of
+// a p tag is not used for this, as NIP-52 gives it the participant's role.
+type Flight struct {
+	Name     string
+	HasBand  bool
+	MinIndex float64 // inclusive, MinHandicapIndex when open
+	MaxIndex float64 // inclusive, MaxHandicapIndex when open
+}
+
+// Contains reports whether a handicap index falls in the flight's band.
+func (f Flight) Contains(index float64) bool {
+	return f.HasBand && index >= f.MinIndex && index <= f.MaxIndex
+}
+
+// Purse is the prize money of a tournament, from a ["purse", <amount>, <unit>]
+// tag, ["payout", <place>, <amount or percent>, <flight>] tags and
+// ["sidepot", <name>, <amount>, <winner pubkey>] tags. The unit is "sats" or
+// a currency code like "USD".
+type Purse struct {
+	Amount   float64 // 0 when only fixed payouts are declared
+	Unit     string
+	Payouts  []Payout
+	SidePots []SidePot
+}
+
+// Payout is what one place of the leaderboard pays.
+type Payout struct {
+	Place   int
+	Amount  float64 // fixed amount, 0 when paid as a percentage
+	Percent float64 // percentage of the purse amount
+	Flight  string  // "" for every flight
+}
+
+// Value is the amount the payout is worth out of a purse.
+func (p Payout) Value(purse float64) float64 {
+	if p.Percent > 0 {
+		return purse * p.Percent / 100
+	}
+	return p.Amount
+}
+
+// SidePot is a prize outside the leaderboard, like closest to the pin.
+type SidePot struct {
+	Name   string
+	Amount float64
+	Winner string // pubkey, "" until decided
+}
+
+// Bracket is a match play knockout, from ["seed", <n>, <pubkey>] tags and
+// ["match", <round>, <position>, <1501 id>, <pubkey>, <pubkey>] tags linking
+// each match to the round it is played in. First round pairings follow the
+// seeds unless a match tag names the players; later rounds are filled with
+// the winners of the matches before.
+type Bracket struct {
+	Seeds   []string // pubkey by seed (index 0 = seed 1), "" for a missing seed
+	Matches []BracketMatch
+}
+
+// The largest bracket we take: 128 players, so 7 rounds, round r having
+// 2^(7-r) matches.
+const (
+	MaxBracketSeeds  = 128
+	MaxBracketRounds = 7
+)
+
+// BracketMatch is one match of a bracket, by round and position from the top
+// (both starting at 1).
+type BracketMatch struct {
+	Round    int
+	Position int
+	RoundID  string // the 1501 the match is played in, "" until it is set up
+	PlayerA  string // pubkeys when named on the tag
+	PlayerB  string
+}
+
+// Tournament is a kind 31923 tournament.
+type Tournament struct {
+	DTag      string
+	Title     string
+	Location  string
+	Start     int64 // unix timestamp
+	Status    string
+	CourseRef string // "33501:<pubkey>:<d>"
+	TeeSet    string
+	Image     string
+	Format    string // one of the Format constants, "" when not declared
+	Rounds    int    // number of rounds, 1 unless a "rounds" tag says otherwise
+	Cut       *CutRule
+	Roster    []string
+	Teams     []Team
+	Tiebreaks []string // Tiebreak constants in the order they are applied
+	Flights   []Flight
+	Divisions map[string]string // flight name by player pubkey, from division tags
+	Purse     *Purse            // nil when no prize money is declared
+	Bracket   *Bracket          // nil unless the tournament is a match play knockout
+}
+
+// ParseTournament parses a kind 31923 tournament.
+func ParseTournament(event nostr.Event) (Tournament, ValidationErrors) {
+	var errs ValidationErrors
+	t := Tournament{Rounds: 1}
+
+	name := ""
+	var cutTag nostr.Tag
+	var divisionNames []string
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "d":
+			t.DTag = tag[1]
+		case "title":
+			if t.Title == "" {
+				t.Title = tag[1]
+			}
+		case "name":
+			if name == "" {
+				name = tag[1]
+			}
+		case "location":
+			if t.Location == "" {
+				t.Location = tag[1]
+			}
+		case "start":
+			ts, err := strconv.ParseInt(tag[1], 10, 64)
+			if err != nil {
+				errs.add("start", 0, "invalid timestamp %q", tag[1])
+				continue
+			}
+			t.Start = ts
+		case "status":
+			if t.Status == "" {
+				t.Status = tag[1]
+			}
+		case "course":
+			if t.CourseRef == "" {
+				t.CourseRef = tag[1]
+			}
+		case "tee":
+			if t.TeeSet == "" {
+				t.TeeSet = tag[1]
+			}
+		case "image":
+			if t.Image == "" {
+				t.Image = tag[1]
+			}
+		case "format":
+			if t.Format == "" {
+				t.Format = parseFormat(tag[1], &errs)
+			}
+		case "rounds":
+			n, err := strconv.Atoi(tag[1])
+			if err != nil || n < 1 || n > MaxTournamentRounds {
+				errs.add("rounds", 0, "invalid round count %q", tag[1])
+				continue
+			}
+			t.Rounds = n
+		case "cut":
+			if cutTag == nil {
+				cutTag = tag
+			}
+		case "p":
+			t.Roster = append(t.Roster, tag[1])
+		case "division":
+			if len(tag) < 3 || tag[2] == "" {
+				errs.add("division", 0, "expected [\"division\", <pubkey>, <flight>]")
+				continue
+			}
+			if t.Divisions == nil {
+				t.Divisions = make(map[string]string)
+			}
+			if _, ok := t.Divisions[tag[1]]; ok {
+				errs.add("division", 0, "player %s is in two divisions", tag[1])
+				continue
+			}
+			t.Divisions[tag[1]] = tag[2]
+			divisionNames = append(divisionNames, tag[2])
+		case "flight":
+			if f, ok := parseFlight(tag, &errs); ok {
+				if slices.ContainsFunc(t.Flights, func(o Flight) bool { return o.Name == f.Name }) {
+					errs.add("flight", 0, "duplicate flight %q", f.Name)
+					continue
+				}
+				t.Flights = append(t.Flights, f)
+			}
+		case "purse", "payout", "sidepot":
+			if t.Purse == nil {
+				t.Purse = &Purse{Unit: "sats"}
+			}
+			parsePurseTag(tag, t.Purse, &errs)
+		case "seed", "match":
+			if t.Bracket == nil {
+				t.Bracket = &Bracket{}
+			}
+			parseBracketTag(tag, t.Bracket, &errs)
+		case "team":
+			if team, ok := parseTeam(tag, t.Teams, &errs); ok {
+				t.Teams = append(t.Teams, team)
+			}
+		case "tiebreak":
+			for _, rule := range tag[1:] {
+				rule = strings.ToLower(strings.TrimSpace(rule))
+				if !slices.Contains(tiebreakRules, rule) {
+					errs.add("tiebreak", 0, "unknown tiebreak rule %q", rule)
+					continue
+				}
+				if !slices.Contains(t.Tiebreaks, rule) {
+					t.Tiebreaks = append(t.Tiebreaks, rule)
+				}
+			}
+		}
+	}
+	if t.Title == "" {
+		t.Title = name
+	}
+	if cutTag != nil {
+		t.Cut = parseCut(cutTag, t.Rounds, &errs)
+	}
+	// flights only named by division tags are added after the declared ones
+	for _, name := range divisionNames {
+		if !slices.ContainsFunc(t.Flights, func(f Flight) bool { return f.Name == name }) {
+			t.Flights = append(t.Flights, Flight{Name: name})
+		}
+	}
+
+	if t.Purse != nil {
+		checkPayouts(t.Purse, &errs)
+	}
+
+	if t.Status != "" && !slices.Contains(tournamentStatuses, t.Status) {
+		errs.add("status", 0, "unknown status %q", t.Status)
+	}
+	if t.CourseRef != "" && !strings.HasPrefix(t.CourseRef, "33501:") {
+		errs.add("course", 0, "%q is not a 33501 course coordinate", t.CourseRef)
+	}
+
+	return t, errs
+}
+
+// parseCut reads a cut tag. The cut comes after the middle round unless the tag
+// says otherwise (after round 2 of 4, or 2 of 3).
+func parseCut(tag nostr.Tag, rounds int, errs *ValidationErrors) *CutRule {
+	if len(tag) < 3 {
+		errs.add("cut", 0, "expected [\"cut\", \"top\" or \"within\", <number>, <after round>]")
+		return nil
+	}
+	n, err := strconv.Atoi(tag[2])
+	if err != nil || n < 0 {
+		errs.add("cut", 0, "invalid cut value %q", tag[2])
+		return nil
+	}
+
+	cut := &CutRule{AfterRound: (rounds + 1) / 2}
+	switch tag[1] {
+	case "top":
+		if n == 0 {
+			errs.add("cut", 0, "invalid cut value %q", tag[2])
+			return nil
+		}
+		cut.Top = n
+	case "within":
+		cut.Within = n
+	default:
+		errs.add("cut", 0, "unknown cut rule %q", tag[1])
+		return nil
+	}
+	if len(tag) >= 4 {
+		after, err := strconv.Atoi(tag[3])
+		if err != nil {
+			errs.add("cut", 0, "invalid cut round %q", tag[3])
+			return nil
+		}
+		cut.AfterRound = after
+	}
+	if cut.AfterRound < 1 || cut.AfterRound >= rounds {
+		errs.add("cut", 0, "cut after round %d of a %d round tournament", cut.AfterRound, rounds)
+		return nil
+	}
+	return cut
+}
+
+// parseFlight reads a flight tag and its optional handicap band.
+func parseFlight(tag nostr.Tag, errs *ValidationErrors) (Flight, bool) {
+	if tag[1] == "" {
+		errs.add("flight", 0, "expected [\"flight\", <name>, <min index>, <max index>]")
+		return Flight{}, false
+	}
+	f := Flight{Name: tag[1], MinIndex: MinHandicapIndex, MaxIndex: MaxHandicapIndex}
+	for i, bound := range []*float64{&f.MinIndex, &f.MaxIndex} {
+		if len(tag) < i+3 || tag[i+2] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(tag[i+2], 64)
+		if err != nil {
+			errs.add("flight", 0, "invalid handicap index %q", tag[i+2])
+			return Flight{}, false
+		}
+		*bound = v
+		f.HasBand = true
+	}
+	if f.MinIndex > f.MaxIndex {
+		errs.add("flight", 0, "flight %q has its minimum index above its maximum", f.Name)
+		return Flight{}, false
+	}
+	return f, true
+}
+
+// parsePurseTag adds a purse, payout or sidepot tag to the purse.
+func parsePurseTag(tag nostr.Tag, purse *Purse, errs *ValidationErrors) {
+	switch tag[0] {
+	case "purse":
+		amount, err := strconv.ParseFloat(tag[1], 64)
+		if err != nil || amount < 0 {
+			errs.add("purse", 0, "invalid purse amount %q", tag[1])
+			return
+		}
+		purse.Amount = amount
+		if len(tag) >= 3 {
+			if unit := strings.ToLower(strings.TrimSpace(tag[2])); unit != "" {
+				purse.Unit = unit
+			}
+		}
+	case "payout":
+		if len(tag) < 3 {
+			errs.add("payout", 0, "expected [\"payout\", <place>, <amount or percent>]")
+			return
+		}
+		place, err := strconv.Atoi(tag[1])
+		if err != nil || place < 1 {
+			errs.add("payout", 0, "invalid payout place %q", tag[1])
+			return
+		}
+		payout := Payout{Place: place}
+		if len(tag) >= 4 {
+			payout.Flight = tag[3]
+		}
+		value, percent := strings.CutSuffix(strings.TrimSpace(tag[2]), "%")
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil || amount <= 0 {
+			errs.add("payout", 0, "invalid payout amount %q", tag[2])
+			return
+		}
+		if percent {
+			payout.Percent = amount
+		} else {
+			payout.Amount = amount
+		}
+		if slices.ContainsFunc(purse.Payouts, func(p Payout) bool { return p.Place == place && p.Flight == payout.Flight }) {
+			errs.add("payout", 0, "duplicate payout for place %d", place)
+			return
+		}
+		purse.Payouts = append(purse.Payouts, payout)
+	case "sidepot":
+		if len(tag) < 3 {
+			errs.add("sidepot", 0, "expected [\"sidepot\", <name>, <amount>, <winner>]")
+			return
+		}
+		amount, err := strconv.ParseFloat(tag[2], 64)
+		if err != nil || amount <= 0 {
+			errs.add("sidepot", 0, "invalid side pot amount %q", tag[2])
+			return
+		}
+		pot := SidePot{Name: tag[1], Amount: amount}
+		if len(tag) >= 4 {
+			pot.Winner = tag[3]
+		}
+		purse.SidePots = append(purse.SidePots, pot)
+	}
+}
+
+// checkPayouts makes sure percentage payouts can be worked out and don't pay
+// more than the purse, flight by flight.
+func checkPayouts(purse *Purse, errs *ValidationErrors) {
+	percents := make(map[string]float64)
+	for _, p := range purse.Payouts {
+		percents[p.Flight] += p.Percent
+	}
+	for flight, percent := range percents {
+		switch {
+		case percent > 0 && purse.Amount == 0:
+			errs.add("payout", 0, "percentage payouts need a purse amount")
+			return
+		case percent > 100 && flight == "":
+			errs.add("payout", 0, "payouts add up to %g%% of the purse", percent)
+		case percent > 100:
+			errs.add("payout", 0, "payouts of flight %q add up to %g%% of the purse", flight, percent)
+		}
+	}
+	slices.SortFunc(purse.Payouts, func(a, b Payout) int { return a.Place - b.Place })
+}
+
+// parseBracketTag adds a seed or match tag to the bracket.
+func parseBracketTag(tag nostr.Tag, bracket *Bracket, errs *ValidationErrors) {
+	switch tag[0] {
+	case "seed":
+		seed, err := strconv.Atoi(tag[1])
+		if err != nil || seed < 1 || len(tag) < 3 || tag[2] == "" {
+			errs.add("seed", 0, "expected [\"seed\", <number>, <pubkey>]")
+			return
+		}
+		if seed > MaxBracketSeeds {
+			errs.add("seed", 0, "seed %d is over the maximum of %d", seed, MaxBracketSeeds)
+			return
+		}
+		for len(bracket.Seeds) < seed {
+			bracket.Seeds = append(bracket.Seeds, "")
+		}
+		if bracket.Seeds[seed-1] != "" {
+			errs.add("seed", 0, "duplicate seed %d", seed)
+			return
+		}
+		if slices.Contains(bracket.Seeds, tag[2]) {
+			errs.add("seed", 0, "player %s is seeded twice", tag[2])
+			return
+		}
+		bracket.Seeds[seed-1] = tag[2]
+	case "match":
+		if len(tag) < 4 {
+			errs.add("match", 0, "expected [\"match\", <round>, <position>, <round event id>]")
+			return
+		}
+		round, err := strconv.Atoi(tag[1])
+		if err != nil || round < 1 || round > MaxBracketRounds {
+			errs.add("match", 0, "invalid match round %q", tag[1])
+			return
+		}
+		position, err := strconv.Atoi(tag[2])
+		if err != nil || position < 1 || position > 1<<(MaxBracketRounds-round) {
+			errs.add("match", 0, "invalid match position %q", tag[2])
+			return
+		}
+		if slices.ContainsFunc(bracket.Matches, func(m BracketMatch) bool { return m.Round == round && m.Position == position }) {
+			errs.add("match", 0, "duplicate match %d of round %d", position, round)
+			return
+		}
+		match := BracketMatch{Round: round, Position: position, RoundID: tag[3]}
+		if len(tag) >= 6 {
+			match.PlayerA, match.PlayerB = tag[4], tag[5]
+		}
+		bracket.Matches = append(bracket.Matches, match)
+	}
+}
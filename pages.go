@@ -29,6 +29,7 @@ const (
 	CourseData
 	LiveScorecard
 	Tournament
+	League
 	Other
 )
 
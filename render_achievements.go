@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// renderAchievements serves a golfer's achievements as unsigned NIP-58 events:
+// the badge definitions and one award per achievement, issued by one of the
+// trusted pubkeys (?issuer=<npub or hex>, the first one by default) for it to
+// sign and publish.
+func renderAchievements(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	issuer := r.URL.Query().Get("issuer")
+	if prefix, value, err := nip19.Decode(issuer); err == nil && prefix == "npub" {
+		issuer = value.(string)
+	}
+	if issuer == "" && len(s.TrustedPubKeys) > 0 {
+		issuer = s.TrustedPubKeys[0]
+	}
+	if !slices.Contains(s.TrustedPubKeys, issuer) {
+		http.Error(w, "badges can only be issued by a trusted pubkey", http.StatusBadRequest)
+		return
+	}
+
+	code := r.PathValue("code")
+	profile, err := sys.FetchProfileFromInput(ctx, code)
+	if err != nil {
+		log.Warn().Err(err).Str("code", code).Msg("error fetching profile on render_achievements")
+		http.Error(w, "profile not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	if banned, _ := internal.isBannedPubkey(profile.PubKey); banned {
+		http.Error(w, "pubkey banned", http.StatusNotFound)
+		return
+	}
+
+	achievements := golferHistories.get(ctx, profile.PubKey).stats.Achievements
+
+	var result struct {
+		Definitions []nostr.Event `json:"definitions"`
+		Awards      []nostr.Event `json:"awards"`
+	}
+	for _, badge := range achievementBadges {
+		result.Definitions = append(result.Definitions, badgeDefinition(badge, issuer))
+	}
+	for _, a := range achievements {
+		result.Awards = append(result.Awards, badgeAward(a, profile.PubKey, issuer))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=600")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Warn().Err(err).Msg("error encoding achievements")
+	}
+}
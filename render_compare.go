@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+func renderCompare(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	var pubkeys [2]string
+	for i, code := range []string{r.PathValue("a"), r.PathValue("b")} {
+		profile, err := sys.FetchProfileFromInput(ctx, code)
+		if err != nil {
+			log.Warn().Err(err).Str("code", code).Msg("error fetching profile on render_compare")
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusNotFound)
+			errorTemplate(ErrorPageParams{Errors: err.Error()}).Render(ctx, w)
+			return
+		}
+		if banned, _ := internal.isBannedPubkey(profile.PubKey); banned {
+			http.Error(w, "pubkey banned", http.StatusNotFound)
+			return
+		}
+		pubkeys[i] = profile.PubKey
+	}
+	if pubkeys[0] == pubkeys[1] {
+		http.Error(w, "can't compare a golfer with themselves", http.StatusBadRequest)
+		return
+	}
+
+	cd := buildComparePageData(ctx, pubkeys[0], pubkeys[1])
+
+	w.Header().Set("Cache-Control", "max-age=600")
+	err := golfCompareTemplate(ComparePageParams{
+		OpenGraphParams: OpenGraphParams{
+			SingleTitle: cd.A.Player.DisplayName + " vs " + cd.B.Player.DisplayName,
+			Text:        compareOGDescription(cd),
+		},
+		Compare: cd,
+	}).Render(ctx, w)
+	if err != nil {
+		log.Warn().Err(err).Msg("error rendering tmpl")
+	}
+}
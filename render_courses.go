@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiatjaf/njump/nip101g"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+func renderCourses(w http.ResponseWriter, r *http.Request) {
+	search := parseCourseSearch(r.URL.Query())
+	ready := courseDirectory.ready()
+
+	if ready {
+		w.Header().Set("Cache-Control", "max-age=300")
+	} else {
+		w.Header().Set("Cache-Control", "max-age=30")
+	}
+	err := golfCourseDirectoryTemplate(CourseDirectoryPageParams{
+		OpenGraphParams: OpenGraphParams{
+			SingleTitle: "Golf Courses",
+			Text:        "Browse and search every golf course published on Gambit Golf.",
+		},
+		Search:    search,
+		Countries: courseDirectory.countries(),
+		Courses:   courseDirectory.search(search),
+		Ready:     ready,
+	}).Render(r.Context(), w)
+	if err != nil {
+		log.Warn().Err(err).Msg("error rendering tmpl")
+	}
+}
+
+// renderCoursesGeoJSON serves every course in the directory with a known
+// position as a GeoJSON feature collection.
+func renderCoursesGeoJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "max-age=300")
+	writeGeoJSON(w, courseDirectory.geoJSON())
+}
+
+// renderCourseGeoJSON serves /course/<naddr>.geojson, a single course as a
+// GeoJSON feature with its hole layout.
+func renderCourseGeoJSON(w http.ResponseWriter, r *http.Request) {
+	code, ok := strings.CutSuffix(r.PathValue("file"), ".geojson")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	evt, _, err := getEvent(ctx, code, false)
+	if err != nil {
+		log.Warn().Err(err).Str("code", code).Msg("error fetching course on render_course_geojson")
+		w.Header().Set("Cache-Control", "max-age=60")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if evt.Kind != nip101g.KindCourse {
+		http.Error(w, "not a course", http.StatusNotFound)
+		return
+	}
+	if banned, _ := internal.isBannedEvent(evt.ID); banned {
+		http.Error(w, "event banned", http.StatusNotFound)
+		return
+	}
+	if banned, _ := internal.isBannedPubkey(evt.PubKey); banned {
+		http.Error(w, "pubkey banned", http.StatusNotFound)
+		return
+	}
+
+	courseDirectory.add(ctx, evt)
+	course, _ := nip101g.ParseCourse(*evt)
+	naddr, _ := nip19.EncodeEntity(evt.PubKey, evt.Kind, evt.Tags.GetD(), []string{gambitRelay})
+
+	w.Header().Set("Cache-Control", "max-age=3600")
+	writeGeoJSON(w, courseFeature(course, naddr))
+}
+
+func writeGeoJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warn().Err(err).Msg("error encoding geojson")
+	}
+}
+
+// renderCourseHistory shows every revision we have of a course with what
+// changed in each.
+func renderCourseHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	code := r.PathValue("code")
+	evt, _, err := getEvent(ctx, code, false)
+	if err != nil {
+		log.Warn().Err(err).Str("code", code).Msg("error fetching course on render_course_history")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusNotFound)
+		errorTemplate(ErrorPageParams{Errors: err.Error()}).Render(ctx, w)
+		return
+	}
+	if evt.Kind != nip101g.KindCourse {
+		http.Error(w, "not a course", http.StatusNotFound)
+		return
+	}
+	if banned, _ := internal.isBannedEvent(evt.ID); banned {
+		http.Error(w, "event banned", http.StatusNotFound)
+		return
+	}
+	if banned, _ := internal.isBannedPubkey(evt.PubKey); banned {
+		http.Error(w, "pubkey banned", http.StatusNotFound)
+		return
+	}
+
+	// the relay may have a newer revision than the one we were linked to
+	coord := courseCoordinate(evt)
+	fetchCourseEvent(ctx, coord)
+	courseDirectory.add(ctx, evt)
+	revisions := buildCourseRevisions(courseRevisionEvents(ctx, coord))
+	if len(revisions) == 0 {
+		revisions = buildCourseRevisions([]*nostr.Event{evt})
+	}
+	naddr, _ := nip19.EncodeEntity(evt.PubKey, evt.Kind, evt.Tags.GetD(), []string{gambitRelay})
+	title := courseTitle(revisions[0].Course.Title)
+
+	w.Header().Set("Cache-Control", "max-age=600")
+	err = golfCourseHistoryTemplate(CourseHistoryPageParams{
+		OpenGraphParams: OpenGraphParams{
+			SingleTitle: title + " revision history",
+			Text:        strconv.Itoa(len(revisions)) + " revisions of " + title + " on Gambit Golf.",
+		},
+		Title:     title,
+		Naddr:     naddr,
+		Revisions: revisions,
+	}).Render(ctx, w)
+	if err != nil {
+		log.Warn().Err(err).Msg("error rendering tmpl")
+	}
+}
@@ -9,10 +9,12 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/a-h/templ"
+	"github.com/fiatjaf/njump/nip101g"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip05"
 	"github.com/nbd-wtf/go-nostr/nip19"
@@ -614,6 +616,15 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 		if data.event.Kind == 1501 {
 			// Multi-player round page: fetch 1502s, 31501s, profiles
 			roundData := buildRoundPageData(ctx, data.event.Event, data.Kind1501Metadata)
+			roundProblems := data.GolfProblems
+			if roundData.TeeUnknown {
+				roundProblems = append(roundProblems, nip101g.ValidationError{
+					Tag:     "tee",
+					Message: fmt.Sprintf("tee %q is not defined on the course", roundData.TeeSet),
+				})
+			}
+			roundData.Problems = append(appendProblems(nil, "Round", data.event.ID, roundProblems), roundData.Problems...)
+			roundData.Achievements = buildRoundAchievements(ctx, roundData)
 
 			opengraph.Superscript = "Golf Round"
 			if roundData.CourseName != "" {
@@ -632,6 +643,16 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 			if roundData.Date != "" {
 				opengraph.Text = fmt.Sprintf("Played on %s", formatDate(roundData.Date))
 			}
+			if len(roundData.Teams) > 0 && roundData.Teams[0].Thru > 0 {
+				opengraph.Subscript = fmt.Sprintf("Leading team: %s (%s)", roundData.Teams[0].Name, teamScoreDisplay(roundData.Teams[0]))
+			}
+			if roundData.Format.Name != "" && len(roundData.PlayerScores) > 0 {
+				if opengraph.Text != "" {
+					opengraph.Text = roundData.Format.summary() + " - " + opengraph.Text
+				} else {
+					opengraph.Text = roundData.Format.summary()
+				}
+			}
 
 			params := GolfRoundPageParams{
 				BaseEventPageParams: baseEventPageParams,
@@ -675,6 +696,7 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 				},
 				Details:   detailsData,
 				GolfRound: *data.Kind1501Metadata,
+				Problems:  appendProblems(nil, "Round record", data.event.ID, data.GolfProblems),
 				Clients:   generateClientList(data.event.Kind, data.nevent),
 			}
 
@@ -693,6 +715,7 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 			opengraph.Subscript = data.Kind33501Metadata.Location
 		}
 		if data.Kind33501Metadata != nil {
+			courseDirectory.add(ctx, data.event.Event)
 			opengraph.Text = fmt.Sprintf("%d holes, Par %d", len(data.Kind33501Metadata.Holes), data.Kind33501Metadata.TotalPar)
 		}
 
@@ -704,9 +727,18 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 				NaddrNaked:  data.naddrNaked,
 				NeventNaked: data.neventNaked,
 			},
-			Details: detailsData,
-			Course:  *data.Kind33501Metadata,
-			Clients: generateClientList(data.event.Kind, data.naddr),
+			Details:  detailsData,
+			Course:   *data.Kind33501Metadata,
+			Activity: buildCourseActivity(ctx, data.Kind33501Metadata.Course, courseCoordinate(data.event.Event)),
+			Map:      buildCourseMap(data.Kind33501Metadata.Course),
+			Problems: appendProblems(nil, "Course", data.event.ID, data.GolfProblems),
+			Clients:  generateClientList(data.event.Kind, data.naddr),
+		}
+		params.Verification = verifyCourse(ctx, data.event.Event, data.Kind33501Metadata.Course)
+		if !params.Verification.Attested() {
+			if duplicate, ok := courseDirectory.attestedDuplicate(courseCoordinate(data.event.Event)); ok {
+				params.VerifiedDuplicate = &duplicate
+			}
 		}
 
 		component = golfCoursePageTemplate(params, isEmbed)
@@ -735,6 +767,7 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 			},
 			Details:   detailsData,
 			LiveRound: *data.Kind30501Metadata,
+			Problems:  appendProblems(nil, "Live scorecard", data.event.ID, data.GolfProblems),
 			Clients:   generateClientList(data.event.Kind, data.naddr),
 		}
 
@@ -742,7 +775,10 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 
 	case Tournament:
 		meta := data.TournamentMetadata
-		tournamentData := buildTournamentPageData(ctx, data.event.Event, meta, data.naddr)
+		net := r.URL.Query().Get("scoring") == "net"
+		gridRound, _ := strconv.Atoi(r.URL.Query().Get("round"))
+		tournamentData := buildTournamentPageData(ctx, data.event.Event, meta, data.naddr, net, r.URL.Query().Get("view"), gridRound)
+		tournamentData.Problems = append(appendProblems(nil, "Tournament", data.event.ID, data.GolfProblems), tournamentData.Problems...)
 
 		opengraph.Superscript = "Tournament"
 		if tournamentData.Title != "" {
@@ -750,6 +786,8 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 		}
 		if tournamentData.Image != "" {
 			opengraph.BigImage = tournamentData.Image
+		} else if tournamentData.Bracket != nil {
+			opengraph.BigImage = fmt.Sprintf("https://%s/image/%s", host, code)
 		}
 		opengraph.Text = tournamentOGDescription(tournamentData)
 
@@ -768,6 +806,34 @@ func renderEvent(w http.ResponseWriter, r *http.Request) {
 
 		component = golfTournamentTemplate(params, isEmbed)
 
+	case League:
+		leagueData := buildLeaguePageData(ctx, data.LeagueMetadata, data.naddr)
+
+		opengraph.Superscript = "League"
+		if leagueData.Title != "" {
+			opengraph.Superscript = leagueData.Title
+		}
+		if leagueData.Image != "" {
+			opengraph.BigImage = leagueData.Image
+		}
+		opengraph.Text = leagueOGDescription(leagueData)
+
+		params := GolfLeaguePageParams{
+			BaseEventPageParams: baseEventPageParams,
+			OpenGraphParams:     opengraph,
+			HeadParams: HeadParams{
+				IsProfile:   false,
+				NaddrNaked:  data.naddrNaked,
+				NeventNaked: data.neventNaked,
+			},
+			Details:  detailsData,
+			League:   leagueData,
+			Problems: appendProblems(nil, "League", data.event.ID, data.GolfProblems),
+			Clients:  generateClientList(data.event.Kind, data.naddr),
+		}
+
+		component = golfLeagueTemplate(params, isEmbed)
+
 	case Other:
 		detailsData.HideDetails = false // always open this since we know nothing else about the event
 
@@ -52,6 +52,12 @@ func renderImage(w http.ResponseWriter, r *http.Request) {
 		code = strings.TrimSuffix(code, ext)
 	}
 
+	// golfer profile charts live under /image/<npub>/<chart>.png
+	if profileCode, chart, ok := strings.Cut(code, "/"); ok {
+		renderGolferChartImage(w, r, profileCode, chart)
+		return
+	}
+
 	data, err := grabData(ctx, code, false)
 	if err != nil {
 		http.Error(w, "error fetching event: "+err.Error(), http.StatusNotFound)
@@ -97,6 +103,25 @@ func renderImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Match play tournaments get a picture of their bracket
+	if data.event.Kind == 31923 && data.TournamentMetadata != nil && data.TournamentMetadata.Bracket != nil {
+		bracket := buildBracketData(ctx, data.TournamentMetadata.Bracket)
+		img, err := drawGolfBracketImage(bracket, data.TournamentMetadata.Title)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to draw golf bracket image")
+			http.Error(w, "error writing golf bracket image!", 500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "max-age=600")
+
+		if err := png.Encode(w, img); err != nil {
+			log.Printf("error encoding golf bracket image: %s", err)
+		}
+		return
+	}
+
 	content := data.event.Content
 	content = strings.Replace(content, "\r\n", "\n", -1)
 	content = multiNewlineRe.ReplaceAllString(content, "\n\n")
@@ -437,6 +462,22 @@ func drawGolfScorecardImage(
 	}
 	courseWidth, _ := img.MeasureString(courseName)
 	img.DrawString(courseName, (float64(width)-courseWidth)/2, cardY+140)
+
+	// Tee played, with its rating, slope and yardage from the course
+	if golfData.CourseRef != "" {
+		if course, ok := fetchCourseAt(ctx, golfData.CourseRef, roundTime(golfData.Date, date)); ok {
+			if tee, _ := newRoundTee(course, golfData.TeeSet); tee != nil {
+				teeText := roundTeeLabel(tee)
+				if tee.TotalYards > 0 {
+					teeText += fmt.Sprintf(" · %d yds", tee.TotalYards)
+				}
+				img.SetFontFace(detailFont)
+				img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+				teeWidth, _ := img.MeasureString(teeText)
+				img.DrawString(teeText, (float64(width)-teeWidth)/2, cardY+180)
+			}
+		}
+	}
 	
 	// Score section (combined score and par difference on one line)
 	img.SetFontFace(scoreFont)
@@ -491,3 +532,232 @@ func drawGolfScorecardImage(
 	
 	return img.Image(), nil
 }
+
+// drawGolfBracketImage draws a match play bracket scaled to fit a 1200x630
+// card in the Gambit Golf colors.
+func drawGolfBracketImage(bd BracketData, title string) (image image.Image, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while drawing golf bracket image")
+			log.Warn().Interface("r", r).Msg("panic while drawing golf bracket image")
+		}
+	}()
+
+	width, height := 1200.0, 630.0
+	img := gg.NewContext(int(width), int(height))
+	img.SetColor(color.RGBA{3, 7, 18, 255}) // brandBackground: #030712 (gray-950)
+	img.DrawRectangle(0, 0, width, height)
+	img.Fill()
+
+	face := func(size float64) xfont.Face {
+		return truetype.NewFace(dateFont, &truetype.Options{Size: size, DPI: 72, Hinting: xfont.HintingFull})
+	}
+
+	if title == "" {
+		title = "Match Play"
+	}
+	img.SetFontFace(face(36))
+	img.SetColor(color.RGBA{16, 185, 129, 255}) // brandPrimary: #10B981
+	img.DrawString(title, 40, 60)
+	img.SetFontFace(face(20))
+	brandWidth, _ := img.MeasureString("gambit.golf")
+	img.DrawString("gambit.golf", width-brandWidth-40, 60)
+
+	// fit the bracket under the title
+	top, margin := 90.0, 40.0
+	scale := min((width-2*margin)/bd.Width, (height-top-margin)/bd.Height, 1.5)
+	x := func(v float64) float64 { return margin + v*scale }
+	y := func(v float64) float64 { return top + v*scale }
+
+	img.SetColor(color.RGBA{71, 85, 105, 255}) // slate-600
+	img.SetLineWidth(max(1, 2*scale))
+	for _, l := range bd.lines() {
+		mid := (l.X1 + l.X2) / 2
+		img.MoveTo(x(l.X1), y(l.Y1))
+		img.LineTo(x(mid), y(l.Y1))
+		img.LineTo(x(mid), y(l.Y2))
+		img.LineTo(x(l.X2), y(l.Y2))
+		img.Stroke()
+	}
+
+	nameFace := face(14 * scale)
+	headerFace := face(12 * scale)
+	for _, r := range bd.Rounds {
+		img.SetFontFace(headerFace)
+		img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+		img.DrawString(r.Name, x(r.X), y(14))
+		for _, m := range r.Matches {
+			img.SetColor(color.RGBA{15, 23, 42, 255}) // slate-900 #0F172A
+			img.DrawRoundedRectangle(x(m.X), y(m.Y), bracketBoxWidth*scale, bracketBoxHeight*scale, 6*scale)
+			img.Fill()
+
+			img.SetFontFace(nameFace)
+			for side, s := range []BracketSlot{m.A, m.B} {
+				switch {
+				case m.Winner == side+1:
+					img.SetColor(color.RGBA{16, 185, 129, 255}) // brandPrimary: #10B981
+				case s.Player == nil || m.Winner != 0:
+					img.SetColor(color.RGBA{100, 116, 139, 255}) // slate-500
+				default:
+					img.SetColor(color.RGBA{226, 232, 240, 255}) // slate-200 #E2E8F0
+				}
+				name := bracketSlotName(s)
+				if seed := bracketSeedLabel(s); seed != "" {
+					name = seed + "  " + name
+				}
+				img.DrawString(name, x(m.X+8), y(m.Y+22+float64(side)*22))
+			}
+		}
+	}
+
+	return img.Image(), nil
+}
+
+func renderGolferChartImage(w http.ResponseWriter, r *http.Request, code string, chart string) {
+	ctx := r.Context()
+
+	profile, err := sys.FetchProfileFromInput(ctx, code)
+	if err != nil {
+		http.Error(w, "error fetching profile: "+err.Error(), http.StatusNotFound)
+		log.Warn().Err(err).Str("code", code).Msg("profile not found on render_image")
+		return
+	}
+	if banned, _ := internal.isBannedPubkey(profile.PubKey); banned {
+		http.Error(w, "pubkey banned", http.StatusNotFound)
+		return
+	}
+
+	history := golferHistories.get(ctx, profile.PubKey)
+	line, bar := buildGolferCharts(history.stats, history.handicap).chart(chart)
+	if line == nil && bar == nil {
+		http.Error(w, "unknown chart "+chart, http.StatusNotFound)
+		return
+	}
+
+	img, err := drawGolfChartImage(line, bar, profile.ShortName())
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to draw golf chart image")
+		http.Error(w, "error writing golf chart image!", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "max-age=3600")
+
+	if err := png.Encode(w, img); err != nil {
+		log.Printf("error encoding golf chart image: %s", err)
+	}
+}
+
+// drawGolfChartImage draws one of a golfer's profile charts, either a line or
+// a bar chart, scaled up from its SVG layout to a 1200x630 card.
+func drawGolfChartImage(line *LineChart, bar *BarChart, player string) (image image.Image, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while drawing golf chart image")
+			log.Warn().Interface("r", r).Msg("panic while drawing golf chart image")
+		}
+	}()
+
+	width, height := 1200.0, 630.0
+	img := gg.NewContext(int(width), int(height))
+	img.SetColor(color.RGBA{3, 7, 18, 255}) // brandBackground: #030712 (gray-950)
+	img.DrawRectangle(0, 0, width, height)
+	img.Fill()
+
+	face := func(size float64) xfont.Face {
+		return truetype.NewFace(dateFont, &truetype.Options{Size: size, DPI: 72, Hinting: xfont.HintingFull})
+	}
+
+	var title string
+	if line != nil {
+		title = line.Title
+	} else {
+		title = bar.Title
+	}
+	img.SetFontFace(face(36))
+	img.SetColor(color.RGBA{16, 185, 129, 255}) // brandPrimary: #10B981
+	img.DrawString(title, 40, 60)
+	img.SetFontFace(face(20))
+	brandWidth, _ := img.MeasureString("gambit.golf")
+	img.DrawString("gambit.golf", width-brandWidth-40, 60)
+	img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+	img.DrawString(player, 40, 92)
+
+	// fit the chart under the title
+	top, margin := 110.0, 40.0
+	scale := min((width-2*margin)/chartWidth, (height-top-margin)/chartHeight)
+	x := func(v float64) float64 { return margin + v*scale }
+	y := func(v float64) float64 { return top + v*scale }
+	labelFace := face(11 * scale)
+
+	empty := (line != nil && len(line.Values) == 0) || (bar != nil && bar.Total == 0)
+	if empty {
+		img.SetFontFace(face(24))
+		img.DrawStringAnchored("No rounds yet", width/2, top+(height-top)/2, 0.5, 0.5)
+		return img.Image(), nil
+	}
+
+	if line != nil {
+		img.SetFontFace(labelFace)
+		img.SetLineWidth(1)
+		for _, v := range line.ticks() {
+			img.SetColor(color.RGBA{51, 65, 85, 255}) // slate-700
+			img.DrawLine(x(chartLeft), y(line.y(v)), x(chartWidth-chartRight), y(line.y(v)))
+			img.Stroke()
+			img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+			img.DrawStringAnchored(line.tickLabel(v), x(chartLeft-6), y(line.y(v)), 1, 0.35)
+		}
+		first, last := line.dateLabels()
+		img.DrawStringAnchored(first, x(chartLeft), y(chartHeight-8), 0, 0)
+		img.DrawStringAnchored(last, x(chartWidth-chartRight), y(chartHeight-8), 1, 0)
+
+		img.SetColor(color.RGBA{168, 162, 158, 255}) // stone-400 #A8A29E
+		points := line.points()
+		for i, p := range points {
+			if i > 0 {
+				img.DrawLine(x(points[i-1].X), y(points[i-1].Y), x(p.X), y(p.Y))
+				img.Stroke()
+			}
+			img.DrawCircle(x(p.X), y(p.Y), 3*scale)
+			img.Fill()
+		}
+
+		img.SetColor(color.RGBA{16, 185, 129, 255}) // brandPrimary: #10B981
+		img.SetLineWidth(2.5 * scale)
+		img.SetLineJoin(gg.LineJoinRound)
+		for _, segment := range line.segments() {
+			for i, p := range segment {
+				if i == 0 {
+					img.MoveTo(x(p.X), y(p.Y))
+				} else {
+					img.LineTo(x(p.X), y(p.Y))
+				}
+			}
+			img.Stroke()
+		}
+
+		img.SetFontFace(face(18))
+		img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+		legend := line.ValueLabel + " (dots)   " + line.LineLabel + " (line)"
+		img.DrawStringAnchored(legend, width-margin, 92, 1, 0)
+		return img.Image(), nil
+	}
+
+	img.SetFontFace(labelFace)
+	img.SetColor(color.RGBA{51, 65, 85, 255}) // slate-700
+	img.SetLineWidth(1)
+	img.DrawLine(x(chartLeft), y(chartHeight-chartBottom), x(chartWidth-chartRight), y(chartHeight-chartBottom))
+	img.Stroke()
+	for _, b := range bar.bars() {
+		img.SetColor(color.RGBA{16, 185, 129, 255}) // brandPrimary: #10B981
+		img.DrawRectangle(x(b.X), y(b.Y), b.Width*scale, b.Height*scale)
+		img.Fill()
+		img.SetColor(color.RGBA{226, 232, 240, 255}) // slate-200 #E2E8F0
+		img.DrawStringAnchored(b.Share, x(b.X+b.Width/2), y(b.Y-4), 0.5, 0)
+		img.SetColor(color.RGBA{148, 163, 184, 255}) // slate-400 #94A3B8
+		img.DrawStringAnchored(b.Label, x(b.X+b.Width/2), y(chartHeight-8), 0.5, 0)
+	}
+
+	return img.Image(), nil
+}
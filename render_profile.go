@@ -58,6 +58,9 @@ func renderProfile(ctx context.Context, r *http.Request, w http.ResponseWriter,
 	}
 
 	var lastNotes []EnhancedEvent
+	var handicap HandicapSummary
+	var golfer GolferStats
+	var charts GolferCharts
 	var cacheControl string = "max-age=86400"
 	if !isEmbed {
 		var justFetched bool
@@ -65,6 +68,12 @@ func renderProfile(ctx context.Context, r *http.Request, w http.ResponseWriter,
 		if justFetched && profile.Event != nil {
 			cacheControl = "only-if-cached"
 		}
+		if !isSitemap && !isRSS {
+			// golfers get their rounds instead of their last notes
+			history := golferHistories.get(ctx, profile.PubKey)
+			handicap, golfer = history.handicap, history.stats
+			charts = buildGolferCharts(golfer, handicap)
+		}
 	}
 
 	w.Header().Set("Cache-Control", cacheControl)
@@ -107,6 +116,9 @@ func renderProfile(ctx context.Context, r *http.Request, w http.ResponseWriter,
 			Nprofile:                   nprofile,
 			AuthorRelays:               relaysPretty(ctx, profile.PubKey),
 			LastNotes:                  lastNotes,
+			Handicap:                   handicap,
+			Golfer:                     golfer,
+			Charts:                     charts,
 			Clients: generateClientList(0, nprofile,
 				func(c ClientReference, s string) string {
 					if c == nostrudel {
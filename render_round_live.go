@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// roundCell is one element of the round page the live stream can rewrite,
+// addressed by its HTML id. An empty Text or Class leaves that part alone.
+type roundCell struct {
+	ID    string `json:"id"`
+	Text  string `json:"text,omitempty"`
+	Class string `json:"class,omitempty"`
+}
+
+// roundUpdate is pushed whenever a score event changes the round page.
+type roundUpdate struct {
+	State  string      `json:"state,omitempty"`  // set when the round state changed
+	Cells  []roundCell `json:"cells,omitempty"`  // cells whose text or class changed
+	Reload bool        `json:"reload,omitempty"` // score rows were added or removed, the page must be rendered again
+}
+
+// roundComment is the JSON shape of a new comment pushed to the browser.
+type roundComment struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Picture string `json:"picture"`
+	Content string `json:"content"`
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Pinned  bool   `json:"pinned"`
+}
+
+func newRoundComment(c CommentData) roundComment {
+	return roundComment{
+		ID:      c.EventId,
+		Name:    c.Author.DisplayName,
+		Picture: c.Author.Picture,
+		Content: c.Content,
+		Time:    formatCommentTime(c.CreatedAt),
+		Type:    c.Type,
+		Pinned:  c.IsPinned,
+	}
+}
+
+// holeCellID is the HTML id of a player's score cell; idx 0 = hole 1.
+func holeCellID(pubkey string, idx int) string {
+	return fmt.Sprintf("hole-%s-%d", pubkey, idx+1)
+}
+
+// netCellID is the HTML id of a player's net score cell; idx 0 = hole 1.
+func netCellID(pubkey string, idx int) string {
+	return fmt.Sprintf("net-%s-%d", pubkey, idx+1)
+}
+
+// matchCellID is the HTML id of a match status; i is its position in RoundFormat.Matches.
+func matchCellID(i int) string {
+	return fmt.Sprintf("match-%d", i)
+}
+
+// skinCellID is the HTML id of the skins result for a hole.
+func skinCellID(hole int) string {
+	return fmt.Sprintf("skin-%d", hole)
+}
+
+// bestBallCellID is the HTML id of the best ball score on a hole; idx 0 = hole 1.
+func bestBallCellID(idx int) string {
+	return fmt.Sprintf("bb-%d", idx+1)
+}
+
+func matchStatusClass(m MatchResult) string {
+	if m.Finished {
+		return "text-lg font-bold text-gray-900"
+	}
+	return "text-lg font-bold text-green-700"
+}
+
+// liveDotClass shows the "in progress" dot next to players without a final record.
+func liveDotClass(ps PlayerScoreData) string {
+	if ps.IsFinal {
+		return "hidden"
+	}
+	return "w-1.5 h-1.5 bg-green-500 rounded-full flex-shrink-0"
+}
+
+// roundCells lists every score-dependent element of the round page, with the
+// same text and classes golf_round.templ renders.
+func roundCells(rpd RoundPageData) []roundCell {
+	// the back nine is only rendered for rounds of more than nine holes
+	holes := 9
+	if rpd.HoleCount > 9 {
+		holes = 18
+	}
+
+	var cells []roundCell
+	for _, ps := range rpd.PlayerScores {
+		pk := ps.Player.PubkeyHex
+		for i := range holes {
+			cells = append(cells, roundCell{
+				ID:    holeCellID(pk, i),
+				Text:  scoreDisplay(ps.HoleScores, i),
+				Class: scoreCellClass(ps.HoleScores, rpd.HolePars, i),
+			})
+		}
+		cells = append(cells, roundCell{ID: "out-" + pk, Text: nineTotal(ps.HoleScores, 0, 9)})
+		if holes > 9 {
+			cells = append(cells,
+				roundCell{ID: "in-" + pk, Text: nineTotal(ps.HoleScores, 9, 18)},
+				roundCell{ID: "tot-" + pk, Text: strconv.Itoa(ps.Total), Class: totalScoreClass(ps.ScoreToPar)},
+			)
+		}
+		cells = append(cells,
+			roundCell{ID: "sum-" + pk, Text: strconv.Itoa(ps.Total), Class: "text-lg font-bold font-mono " + totalScoreClass(ps.ScoreToPar)},
+			roundCell{ID: "sumtp-" + pk, Text: "(" + formatScoreToPar(ps.ScoreToPar) + ")", Class: "text-sm font-mono " + totalScoreClass(ps.ScoreToPar)},
+			roundCell{ID: "dot-" + pk, Class: liveDotClass(ps)},
+		)
+		if !ps.HasHandicap {
+			continue
+		}
+		for i := range holes {
+			cells = append(cells, roundCell{
+				ID:    netCellID(pk, i),
+				Text:  scoreDisplay(ps.NetHoleScores, i),
+				Class: scoreCellClass(ps.NetHoleScores, rpd.HolePars, i),
+			})
+		}
+		cells = append(cells, roundCell{ID: "netout-" + pk, Text: nineTotal(ps.NetHoleScores, 0, 9)})
+		if holes > 9 {
+			cells = append(cells,
+				roundCell{ID: "netin-" + pk, Text: nineTotal(ps.NetHoleScores, 9, 18)},
+				roundCell{ID: "nettot-" + pk, Text: strconv.Itoa(ps.NetTotal), Class: totalScoreClass(ps.NetToPar)},
+			)
+		}
+		cells = append(cells, roundCell{ID: "sumnet-" + pk, Text: "net " + strconv.Itoa(ps.NetTotal) + " (" + formatScoreToPar(ps.NetToPar) + ")", Class: "text-sm font-mono " + totalScoreClass(ps.NetToPar)})
+	}
+	cells = append(cells, formatCells(rpd)...)
+	return append(cells, teamCells(rpd.Teams)...)
+}
+
+// formatCells lists the values of the format section rendered by golfRoundFormat.
+func formatCells(rpd RoundPageData) []roundCell {
+	var cells []roundCell
+	for i, m := range rpd.Format.Matches {
+		cells = append(cells, roundCell{ID: matchCellID(i), Text: m.Status, Class: matchStatusClass(m)})
+	}
+	for _, st := range rpd.Format.Standings {
+		pk := st.Player.PubkeyHex
+		cells = append(cells,
+			roundCell{ID: "fmtrank-" + pk, Text: st.Rank},
+			roundCell{ID: "fmt-" + pk, Text: st.Display},
+		)
+	}
+	for _, s := range rpd.Format.Skins {
+		cells = append(cells, roundCell{ID: skinCellID(s.Hole), Text: skinDisplay(s), Class: skinCellClass(s)})
+	}
+	for i := range rpd.Format.BestBall {
+		cells = append(cells, roundCell{
+			ID:    bestBallCellID(i),
+			Text:  scoreDisplay(rpd.Format.BestBall, i),
+			Class: scoreCellClass(rpd.Format.BestBall, rpd.HolePars, i),
+		})
+	}
+	if len(rpd.Format.BestBall) > 0 {
+		cells = append(cells, roundCell{ID: "bbtot", Text: strconv.Itoa(rpd.Format.BestBallTotal), Class: totalScoreClass(rpd.Format.BestBallToPar)})
+	}
+	return cells
+}
+
+func scoringPubkeys(rpd RoundPageData) []string {
+	pks := make([]string, len(rpd.PlayerScores))
+	for i, ps := range rpd.PlayerScores {
+		pks[i] = ps.Player.PubkeyHex
+	}
+	return pks
+}
+
+// diffRound returns what changed on the round page between prev and next.
+func diffRound(prev, next RoundPageData) roundUpdate {
+	var update roundUpdate
+	if prev.State != next.State {
+		update.State = next.State
+	}
+	if !slices.Equal(scoringPubkeys(prev), scoringPubkeys(next)) {
+		update.Reload = true
+		return update
+	}
+
+	update.Cells = diffCells(roundCells(prev), roundCells(next))
+	return update
+}
+
+// renderRoundLive streams changes to a round page as server-sent events:
+// "round" events carry score cells and state transitions, "comment" events
+// carry new kind 1111 comments. Both start with the current state so a browser
+// reconnecting after a drop catches up on what it missed.
+func renderRoundLive(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	data, err := grabData(ctx, r.PathValue("code"), false)
+	cancel()
+	if err != nil || data.Kind1501Metadata == nil || data.event.Kind != 1501 {
+		http.Error(w, "round not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	rc := http.NewResponseController(w)
+
+	since := nostr.Now()
+	board := loadRoundBoard(r.Context(), data.event.Event, data.Kind1501Metadata)
+	prev := board.pageData()
+
+	if err := writeSSE(w, rc, "round", roundUpdate{State: prev.State, Cells: roundCells(prev)}); err != nil {
+		return
+	}
+	for _, c := range board.comments {
+		if err := writeSSE(w, rc, "comment", newRoundComment(c)); err != nil {
+			return
+		}
+	}
+
+	events := sys.Pool.SubscribeMany(r.Context(), []string{gambitRelay}, nostr.Filter{
+		Kinds: []int{1502, 31501, 1111},
+		Tags:  nostr.TagMap{"e": {data.event.ID}},
+		Since: &since,
+	})
+
+	keepalive := time.NewTicker(25 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ie, ok := <-events:
+			if !ok {
+				return
+			}
+			if ie.Kind == 1111 {
+				if c, ok := board.addComment(r.Context(), ie.Event); ok {
+					if err := writeSSE(w, rc, "comment", newRoundComment(c)); err != nil {
+						return
+					}
+				}
+				continue
+			}
+			if !board.addScore(ie.Event) {
+				continue
+			}
+			next := board.pageData()
+			update := diffRound(prev, next)
+			prev = next
+			if update.State == "" && len(update.Cells) == 0 && !update.Reload {
+				continue
+			}
+			if err := writeSSE(w, rc, "round", update); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
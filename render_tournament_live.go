@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// leaderboardRow is the JSON shape of one leaderboard row pushed to the browser.
+type leaderboardRow struct {
+	PubKey     string   `json:"pubkey"`
+	Name       string   `json:"name"`
+	Picture    string   `json:"picture"`
+	Rank       string   `json:"rank"`
+	Tiebreak   string   `json:"tiebreak,omitempty"`
+	Flight     int      `json:"flight"`
+	Score      string   `json:"score"`
+	ScoreClass string   `json:"scoreClass"`
+	Net        string   `json:"net"`
+	NetClass   string   `json:"netClass"`
+	Thru       string   `json:"thru"`
+	Playing    bool     `json:"playing"`
+	RowClass   string   `json:"rowClass"`
+	Rounds     []string `json:"rounds,omitempty"` // R1…Rn of a multi-round leaderboard
+	Tot        string   `json:"tot,omitempty"`
+}
+
+// leaderboardUpdate carries the rows that changed since the previous update and,
+// when players moved, the new row order (by pubkey). Team leaderboard values
+// that changed are sent as cells, like on the round page. The cut lines of
+// every flight are sent whenever they or the row order changed.
+type leaderboardUpdate struct {
+	Rows  []leaderboardRow `json:"rows"`
+	Order []string         `json:"order,omitempty"`
+	Teams []roundCell      `json:"teams,omitempty"`
+	Cuts  []cutLineUpdate  `json:"cuts,omitempty"`
+}
+
+// cutLineUpdate places the cut line of a flight after the row of a player, or
+// removes it when After is empty.
+type cutLineUpdate struct {
+	Flight int    `json:"flight"`
+	After  string `json:"after"`
+	Label  string `json:"label"`
+}
+
+func newLeaderboardRow(e LeaderboardEntry) leaderboardRow {
+	row := leaderboardRow{
+		PubKey:     e.Player.PubkeyHex,
+		Name:       e.Player.DisplayName,
+		Picture:    e.Player.Picture,
+		Rank:       e.Rank,
+		Tiebreak:   e.Tiebreak,
+		Flight:     e.Flight,
+		Score:      leaderboardScoreDisplay(e),
+		ScoreClass: leaderboardScoreClass(e),
+		Net:        leaderboardNetDisplay(e),
+		NetClass:   leaderboardNetClass(e),
+		Thru:       e.Thru,
+		Playing:    e.IsPlaying,
+		RowClass:   leaderboardRowClass(e),
+	}
+	if len(e.Rounds) > 1 {
+		for _, rr := range e.Rounds {
+			row.Rounds = append(row.Rounds, leaderboardRoundDisplay(rr))
+		}
+		row.Tot = leaderboardTotalDisplay(e)
+	}
+	return row
+}
+
+// diffLeaderboard returns the rows of next that differ from prev. Passing a nil
+// prev yields a full snapshot.
+func diffLeaderboard(prev, next []LeaderboardEntry) leaderboardUpdate {
+	old := make(map[string]leaderboardRow, len(prev))
+	prevOrder := make([]string, len(prev))
+	for i, e := range prev {
+		old[e.Player.PubkeyHex] = newLeaderboardRow(e)
+		prevOrder[i] = e.Player.PubkeyHex
+	}
+
+	var update leaderboardUpdate
+	order := make([]string, len(next))
+	for i, e := range next {
+		row := newLeaderboardRow(e)
+		order[i] = row.PubKey
+		if o, ok := old[row.PubKey]; !ok || !reflect.DeepEqual(o, row) {
+			update.Rows = append(update.Rows, row)
+		}
+	}
+	if !slices.Equal(prevOrder, order) {
+		update.Order = order
+	}
+	return update
+}
+
+// cutLines is the cut line of every flight of the leaderboard, nil when the
+// tournament has no cut.
+func (b *tournamentBoard) cutLines(entries []LeaderboardEntry) []cutLineUpdate {
+	if b.cut == nil {
+		return nil
+	}
+	var cuts []cutLineUpdate
+	for _, f := range b.flightSections(entries) {
+		cut := cutLineUpdate{Flight: f.Index, After: f.CutAfter}
+		_, cut.Label = b.cutLine(entries, f.Index)
+		cuts = append(cuts, cut)
+	}
+	return cuts
+}
+
+// tournamentStream keeps one relay subscription per tournament, shared by all
+// the spectators watching it, and fans leaderboard updates out to them.
+type tournamentStream struct {
+	key     string // aCoord, plus "/net" for the net leaderboard
+	aCoord  string
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	board   *tournamentBoard
+	entries []LeaderboardEntry
+	teams   []TeamScoreData
+	cuts    []cutLineUpdate
+	clients map[chan leaderboardUpdate]struct{}
+	closed  bool
+}
+
+var (
+	tournamentStreams   = make(map[string]*tournamentStream)
+	tournamentStreamsMu sync.Mutex
+)
+
+// joinTournamentStream registers a spectator, starting the stream if nobody
+// was watching the tournament yet. It returns the channel updates arrive on and
+// a full snapshot of the current leaderboard.
+func joinTournamentStream(tournamentEvent *nostr.Event, meta *TournamentMetadata, net bool) (*tournamentStream, chan leaderboardUpdate, leaderboardUpdate) {
+	aCoord := tournamentCoord(tournamentEvent)
+	key := aCoord
+	if net {
+		key += "/net"
+	}
+
+	for {
+		tournamentStreamsMu.Lock()
+		ts, ok := tournamentStreams[key]
+		if !ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			ts = &tournamentStream{
+				key:     key,
+				aCoord:  aCoord,
+				cancel:  cancel,
+				clients: make(map[chan leaderboardUpdate]struct{}),
+			}
+			tournamentStreams[key] = ts
+
+			// hold the stream lock until the initial board is loaded so joiners wait for it
+			ts.mu.Lock()
+			tournamentStreamsMu.Unlock()
+
+			since := nostr.Now()
+			ts.board, _ = loadTournamentBoard(ctx, tournamentEvent, meta, net)
+			ts.entries = ts.board.entries()
+			ts.teams = ts.board.teamScores()
+			ts.cuts = ts.board.cutLines(ts.entries)
+			go ts.run(ctx, since)
+		} else {
+			tournamentStreamsMu.Unlock()
+			ts.mu.Lock()
+			if ts.closed {
+				// the last spectator left while we were waiting, start over
+				ts.mu.Unlock()
+				continue
+			}
+		}
+
+		ch := make(chan leaderboardUpdate, 16)
+		ts.clients[ch] = struct{}{}
+		snapshot := diffLeaderboard(nil, ts.entries)
+		snapshot.Teams = teamCells(ts.teams)
+		snapshot.Cuts = ts.cuts
+		ts.mu.Unlock()
+		return ts, ch, snapshot
+	}
+}
+
+// leave unregisters a spectator and stops the stream when it was the last one.
+func (ts *tournamentStream) leave(ch chan leaderboardUpdate) {
+	tournamentStreamsMu.Lock()
+	defer tournamentStreamsMu.Unlock()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	delete(ts.clients, ch)
+	if len(ts.clients) == 0 {
+		ts.closed = true
+		ts.cancel()
+		delete(tournamentStreams, ts.key)
+	}
+}
+
+// run listens for new 1501s on the tournament and for 1502s/31501s on its
+// rounds, resubscribing to scores whenever a round is added.
+func (ts *tournamentStream) run(ctx context.Context, since nostr.Timestamp) {
+	rounds := sys.Pool.SubscribeMany(ctx, []string{gambitRelay}, nostr.Filter{
+		Kinds: []int{1501},
+		Tags:  nostr.TagMap{"a": {ts.aCoord}},
+		Since: &since,
+	})
+
+	for {
+		ts.mu.Lock()
+		roundIDs := ts.board.roundIDs()
+		ts.mu.Unlock()
+
+		scoresCtx, cancelScores := context.WithCancel(ctx)
+		var scores chan nostr.RelayEvent // stays nil (never ready) until there is a round to follow
+		if len(roundIDs) > 0 {
+			scores = sys.Pool.SubscribeMany(scoresCtx, []string{gambitRelay}, nostr.Filter{
+				Kinds: []int{1502, 31501},
+				Tags:  nostr.TagMap{"e": roundIDs},
+				Since: &since,
+			})
+		}
+
+	listen:
+		for {
+			select {
+			case <-ctx.Done():
+				cancelScores()
+				return
+			case ie, ok := <-rounds:
+				if !ok {
+					cancelScores()
+					return
+				}
+				ts.mu.Lock()
+				added := ts.board.addRound(ie.Event)
+				missing := ts.board.missingProfiles()
+				ts.mu.Unlock()
+				if !added {
+					continue
+				}
+
+				// profiles are fetched without holding the lock, so spectators
+				// and scores aren't held up by the profile relays
+				var profiles map[string]PlayerData
+				if len(missing) > 0 {
+					profiles = fetchPlayerProfiles(ctx, missing)
+				}
+				ts.mu.Lock()
+				ts.board.addProfiles(profiles)
+				ts.publish()
+				ts.mu.Unlock()
+				break listen
+			case ie, ok := <-scores:
+				if !ok {
+					scores = nil
+					continue
+				}
+				ts.mu.Lock()
+				if ts.board.addScore(ie.Event) {
+					ts.publish()
+				}
+				ts.mu.Unlock()
+			}
+		}
+		cancelScores()
+	}
+}
+
+// publish recomputes the leaderboard and sends what changed to every spectator.
+// It must be called with ts.mu held.
+func (ts *tournamentStream) publish() {
+	next := ts.board.entries()
+	update := diffLeaderboard(ts.entries, next)
+	ts.entries = next
+	nextTeams := ts.board.teamScores()
+	update.Teams = diffCells(teamCells(ts.teams), teamCells(nextTeams))
+	ts.teams = nextTeams
+	cuts := ts.board.cutLines(next)
+	if !slices.Equal(cuts, ts.cuts) || update.Order != nil {
+		update.Cuts = cuts
+	}
+	ts.cuts = cuts
+	if len(update.Rows) == 0 && update.Order == nil && len(update.Teams) == 0 && update.Cuts == nil {
+		return
+	}
+
+	// a client that can't keep up is disconnected rather than skipped, as it
+	// only applies diffs: its browser reconnects and starts over from a snapshot
+	for ch := range ts.clients {
+		select {
+		case ch <- update:
+		default:
+			log.Debug().Str("tournament", ts.aCoord).Msg("disconnecting slow client from leaderboard updates")
+			delete(ts.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// renderTournamentLive streams leaderboard updates for a tournament as
+// server-sent events: a full snapshot first, then only the rows that change.
+func renderTournamentLive(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	data, err := grabData(ctx, r.PathValue("code"), false)
+	cancel()
+	if err != nil || data.TournamentMetadata == nil {
+		http.Error(w, "tournament not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	rc := http.NewResponseController(w)
+
+	net := r.URL.Query().Get("scoring") == "net"
+	ts, ch, snapshot := joinTournamentStream(data.event.Event, data.TournamentMetadata, net)
+	defer ts.leave(ch)
+
+	if err := writeSSE(w, rc, "leaderboard", snapshot); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(25 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-ch:
+			if !ok {
+				// we fell behind, end the stream so the browser reconnects
+				return
+			}
+			if err := writeSSE(w, rc, "leaderboard", update); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes one server-sent event with a JSON payload and flushes it.
+func writeSSE(w http.ResponseWriter, rc *http.ResponseController, event string, payload any) error {
+	j, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, j); err != nil {
+		return err
+	}
+	return rc.Flush()
+}